@@ -0,0 +1,91 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/analytics"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"go.uber.org/zap"
+)
+
+// Analyze prints the centrality, components and communities of the relation graph.
+var Analyze action.Action = func(ctx context.Context, _ []string) error {
+	format := viper.GetString(config.Keys.AnalyzeFormat)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format %q, use table or json", format)
+	}
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	l := v1.NewLogic(v1.LogicConfig{
+		DB: dbClient,
+	})
+
+	report, err := l.AnalyzeGraph(ctx, analytics.Options{
+		Top:       viper.GetInt(config.Keys.AnalyzeTop),
+		BatchSize: viper.GetInt(config.Keys.AnalyzeBatchSize),
+	})
+	if err != nil {
+		zap.L().Error("Error analyzing graph", zap.Error(err))
+
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(report)
+	}
+
+	return printTable(os.Stdout, report)
+}
+
+func printTable(out io.Writer, report *analytics.Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "%d entities with %d relations\n\n", report.Entities, report.Relations)
+
+	fmt.Fprintln(w, "DEGREE\tIN\tOUT\tTOTAL")
+	for _, score := range report.Degree {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", score.Name, score.In, score.Out, score.Total)
+	}
+
+	fmt.Fprintln(w, "\nPAGERANK\tSCORE")
+	for _, score := range report.PageRank {
+		fmt.Fprintf(w, "%s\t%.4f\n", score.Name, score.Score)
+	}
+
+	fmt.Fprintf(w, "\nCOMPONENTS (%d)\tSIZE\tMEMBERS\n", report.ComponentCount)
+	for _, group := range report.Components {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", group.ID, group.Size, strings.Join(group.Members, ", "))
+	}
+
+	fmt.Fprintf(w, "\nCOMMUNITIES (%d)\tSIZE\tMEMBERS\n", report.CommunityCount)
+	for _, group := range report.Communities {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", group.ID, group.Size, strings.Join(group.Members, ", "))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package backfillinverses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"go.uber.org/zap"
+)
+
+// BackfillInverses creates the inverse and symmetric counterparts the ontology declares for relations created before
+// it did.
+var BackfillInverses action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	// load ontology
+	o, strictness, err := action.LoadOntology(ctx, dbClient)
+	if err != nil {
+		zap.L().Error("Error loading ontology", zap.Error(err))
+
+		return err
+	}
+	if o == nil {
+		return errors.New("no ontology to backfill from, set one with ontology set or --" + config.Keys.OntologyFile)
+	}
+
+	l := v1.NewLogic(v1.LogicConfig{
+		DB:                 dbClient,
+		Ontology:           o,
+		OntologyStrictness: strictness,
+	})
+
+	var created []*models.Relation
+	backfill := func(ctx context.Context, l logic.Logic) error {
+		var err error
+		created, err = l.BackfillInverses(ctx)
+
+		return err
+	}
+
+	dryRun := viper.GetBool(config.Keys.DryRun)
+	if dryRun {
+		_, err = l.DryRun(ctx, backfill)
+	} else {
+		err = backfill(ctx, l)
+	}
+	if err != nil {
+		zap.L().Error("Error backfilling inverse relations", zap.Error(err))
+
+		return err
+	}
+
+	for _, relation := range created {
+		fmt.Printf("+ %s %s %s\n", relation.From.Name, relation.Type, relation.To.Name)
+	}
+	if dryRun {
+		fmt.Printf("dry run: %d relations would be created\n", len(created))
+
+		return nil
+	}
+	fmt.Printf("created %d relations in session %s\n", len(created), l.SessionID())
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// Backup writes an archive of the database to the file given as the first argument. The archive is written to a
+// temporary file next to it first, so an interrupted backup doesn't leave a partial archive behind.
+var Backup action.Action = func(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected the archive file as the only argument")
+	}
+	path := args[0]
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// only left behind if the backup failed
+		_ = os.Remove(file.Name())
+	}()
+
+	manifest, err := dbClient.Backup(ctx, file)
+	if err != nil {
+		_ = file.Close()
+		zap.L().Error("Error backing up database", zap.Error(err))
+
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	for _, table := range manifest.Tables {
+		fmt.Printf("%s: %d rows\n", table.Name, table.Count)
+	}
+	fmt.Printf("backed up schema version %s to %s\n", manifest.SchemaVersion, path)
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package configprint
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPrint prints the effective config as YAML with secrets redacted.
+var ConfigPrint action.Action = func(_ context.Context, _ []string) error {
+	settings := viper.AllSettings()
+	delete(settings, "help")
+	for key, value := range settings {
+		settings[key] = config.Redact(key, value)
+	}
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		fmt.Printf("# read from %s\n", file)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(settings); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,83 @@
+package copydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	"go.uber.org/zap"
+)
+
+// Copy copies the memory of one database into another, migrating it first. Running it again resumes the copy.
+var Copy action.Action = func(ctx context.Context, _ []string) error {
+	from := viper.GetString(config.Keys.CopyFrom)
+	to := viper.GetString(config.Keys.CopyTo)
+	if from == to {
+		return errors.New("can't copy a database into itself")
+	}
+	// the source is identified without its password, so changing it doesn't restart the copy
+	source := fmt.Sprint(config.Redact(config.Keys.CopyFrom, from))
+	copyConfig := bun.CopyConfig{
+		Source:    source,
+		Namespace: viper.GetString(config.Keys.CopyNamespace),
+		BatchSize: viper.GetInt(config.Keys.CopyBatchSize),
+	}
+
+	// create database clients
+	sourceClient, err := newDBClient(ctx, from)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.String("database", "source"), zap.Error(err))
+
+		return err
+	}
+	defer closeDBClient(sourceClient, "source")
+	targetClient, err := newDBClient(ctx, to)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.String("database", "target"), zap.Error(err))
+
+		return err
+	}
+	defer closeDBClient(targetClient, "target")
+
+	result, err := sourceClient.CopyTo(ctx, targetClient, copyConfig)
+	if err != nil {
+		zap.L().Error("Error copying database", zap.Error(err))
+
+		return err
+	}
+
+	for _, table := range bun.CopyTables {
+		if skipped := result.Skipped[table]; skipped > 0 {
+			fmt.Printf("%s: %d copied, %d skipped\n", table, result.Copied[table], skipped)
+			continue
+		}
+		fmt.Printf("%s: %d copied\n", table, result.Copied[table])
+	}
+
+	if err := sourceClient.VerifyCopy(ctx, targetClient, copyConfig); err != nil {
+		zap.L().Error("Error verifying copy", zap.Error(err))
+
+		return fmt.Errorf("copy doesn't match the source: %w", err)
+	}
+	fmt.Printf("verified copy of %s\n", source)
+
+	return nil
+}
+
+// newDBClient creates a database client from the database config values for the database at url.
+func newDBClient(ctx context.Context, url string) (*bun.Client, error) {
+	cfg := action.DBClientConfig()
+	cfg.URL = url
+
+	return bun.New(ctx, cfg)
+}
+
+func closeDBClient(client *bun.Client, database string) {
+	if err := client.Close(); err != nil {
+		zap.L().Error("Error closing bun client", zap.String("database", database), zap.Error(err))
+	}
+}
@@ -0,0 +1,50 @@
+package action
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+)
+
+// NewDBClient creates a database client from the database config values.
+func NewDBClient(ctx context.Context) (*bun.Client, error) {
+	return bun.New(ctx, DBClientConfig())
+}
+
+// DBClientConfig returns the database client config from the database config values.
+func DBClientConfig() bun.ClientConfig {
+	retry := bun.DefaultRetryConfig
+	retry.MaxElapsedTime = viper.GetDuration(config.Keys.DBRetryMaxElapsed)
+
+	return bun.ClientConfig{
+		URL:           viper.GetString(config.Keys.DBURL),
+		Type:          viper.GetString(config.Keys.DBType),
+		Address:       viper.GetString(config.Keys.DBAddress),
+		Port:          viper.GetUint16(config.Keys.DBPort),
+		User:          viper.GetString(config.Keys.DBUser),
+		Password:      viper.GetString(config.Keys.DBPassword),
+		Database:      viper.GetString(config.Keys.DBDatabase),
+		TLSMode:       viper.GetString(config.Keys.DBTLSMode),
+		TLSCACert:     viper.GetString(config.Keys.DBTLSCACert),
+		TLSClientCert: viper.GetString(config.Keys.DBTLSClientCert),
+		TLSClientKey:  viper.GetString(config.Keys.DBTLSClientKey),
+		TLSServerName: viper.GetString(config.Keys.DBTLSServerName),
+		TLSMinVersion: viper.GetString(config.Keys.DBTLSMinVersion),
+		SQLite: &bun.SQLiteConfig{
+			ForeignKeys: viper.GetBool(config.Keys.DBSQLiteForeignKeys),
+			JournalMode: viper.GetString(config.Keys.DBSQLiteJournalMode),
+			BusyTimeout: viper.GetDuration(config.Keys.DBSQLiteBusyTimeout),
+			Synchronous: viper.GetString(config.Keys.DBSQLiteSynchronous),
+		},
+		MaxOpenConns:         viper.GetInt(config.Keys.DBMaxOpenConns),
+		MaxIdleConns:         viper.GetInt(config.Keys.DBMaxIdleConns),
+		ConnMaxLifetime:      viper.GetDuration(config.Keys.DBConnMaxLifetime),
+		ConnMaxIdleTime:      viper.GetDuration(config.Keys.DBConnMaxIdleTime),
+		StatementTimeout:     viper.GetDuration(config.Keys.DBStatementTimeout),
+		Wait:                 viper.GetDuration(config.Keys.DBWait),
+		MigrationLockTimeout: viper.GetDuration(config.Keys.MigrateLockTimeout),
+		Retry:                &retry,
+	}
+}
@@ -2,7 +2,9 @@ package direct
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,8 +16,11 @@ import (
 	"github.com/tyrm/mcp-dbmem/internal/adapter"
 	"github.com/tyrm/mcp-dbmem/internal/config"
 	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	"github.com/tyrm/mcp-dbmem/internal/health"
+	"github.com/tyrm/mcp-dbmem/internal/inference"
 	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
-	"github.com/uptrace/uptrace-go/uptrace"
+	"github.com/tyrm/mcp-dbmem/internal/metrics"
+	"github.com/tyrm/mcp-dbmem/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -24,31 +29,36 @@ var Direct action.Action = func(ctx context.Context, _ []string) error {
 	zap.L().Info("starting pgmcp")
 
 	// Setup tracing
-	if viper.GetString(config.Keys.UptraceDSN) != "" {
-		uptrace.ConfigureOpentelemetry(
-			uptrace.WithServiceName("mcp-dbmem"),
-			uptrace.WithServiceVersion(viper.GetString(config.Keys.SoftwareVersion)),
-			uptrace.WithDSN(viper.GetString(config.Keys.UptraceDSN)),
-		)
-		// Send buffered spans and free resources.
-		defer func() {
-			if err := uptrace.Shutdown(context.Background()); err != nil {
-				zap.L().Error("Error shutting down uptrace", zap.Error(err))
-			}
-		}()
+	shutdownTelemetry, err := action.SetupTelemetry(ctx)
+	if err != nil {
+		zap.L().Error("Error setting up tracing", zap.Error(err))
+
+		return err
+	}
+	// Send buffered spans and free resources.
+	defer func() {
+		if err := shutdownTelemetry(context.Background()); err != nil {
+			zap.L().Error("Error shutting down tracing", zap.Error(err))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// metrics are collected if they're served
+	var m *metrics.Metrics
+	httpAddress := viper.GetString(config.Keys.HTTPAddress)
+	if httpAddress == "" {
+		httpAddress = viper.GetString(config.Keys.MetricsAddress)
+	}
+	if httpAddress != "" {
+		m = metrics.New()
 	}
 
 	// create database client
-	dbClient, err := bun.New(ctx, bun.ClientConfig{
-		Type:      viper.GetString(config.Keys.DBType),
-		Address:   viper.GetString(config.Keys.DBAddress),
-		Port:      viper.GetUint16(config.Keys.DBPort),
-		User:      viper.GetString(config.Keys.DBUser),
-		Password:  viper.GetString(config.Keys.DBPassword),
-		Database:  viper.GetString(config.Keys.DBDatabase),
-		TLSMode:   viper.GetString(config.Keys.DBTLSMode),
-		TLSCACert: viper.GetString(config.Keys.DBTLSCACert),
-	})
+	dbConfig := action.DBClientConfig()
+	dbConfig.Metrics = m.Registerer()
+	dbClient, err := bun.New(ctx, dbConfig)
 	if err != nil {
 		zap.L().Error("Error creating bun client", zap.Error(err))
 
@@ -61,46 +71,78 @@ var Direct action.Action = func(ctx context.Context, _ []string) error {
 		}
 	}()
 
+	// refuse to serve a schema that doesn't match this version
+	if err := checkSchema(ctx, dbClient); err != nil {
+		zap.L().Error("Error checking database schema", zap.Error(err))
+
+		return err
+	}
+
+	// load ontology
+	o, strictness, err := action.LoadOntology(ctx, dbClient)
+	if err != nil {
+		zap.L().Error("Error loading ontology", zap.Error(err))
+
+		return err
+	}
+
+	// load inference rules
+	var rules *inference.Rules
+	if path := viper.GetString(config.Keys.RulesFile); path != "" {
+		rules, err = inference.Load(path)
+		if err != nil {
+			zap.L().Error("Error loading inference rules", zap.Error(err))
+
+			return err
+		}
+		zap.L().Info("loaded inference rules", zap.String("file", path), zap.Int("rules", len(rules.Rules)))
+	}
+
 	// build logic
 	logic := v1.NewLogic(v1.LogicConfig{
-		DB: dbClient,
+		DB:                 dbClient,
+		Ontology:           o,
+		OntologyStrictness: strictness,
+		Rules:              rules,
+		InferenceMaxDepth:  viper.GetInt(config.Keys.InferenceMaxDepth),
 	})
+	zap.L().Info("starting session", zap.String("session_id", logic.SessionID()))
 
-	direct := adapter.NewDirectAdapter(logic)
+	direct := adapter.NewDirectAdapter(adapter.DirectAdapterConfig{
+		Logic:   logic,
+		DryRun:  viper.GetBool(config.Keys.DryRun),
+		Metrics: m,
+	})
 
 	// add tools
 	server := mcp.NewServer(stdio.NewStdioServerTransport())
-	if err := server.RegisterTool("create_entities", "Create multiple new entities in the knowledge graph", direct.CreateEntities); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("create_relations", "Create multiple new relations between entities in the knowledge graph. Relations should be in active voice", direct.CreateRelations); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("add_observations", "Add new observations to existing entities in the knowledge graph", direct.AddObservations); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("delete_entities", "Delete multiple entities and their associated relations from the knowledge graph", direct.DeleteEntities); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("delete_observations", "Delete specific observations from entities in the knowledge graph", direct.DeleteObservations); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("delete_relations", "Delete multiple relations from the knowledge graph", direct.DeleteRelations); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("read_graph", "Read the entire knowledge graph", direct.ReadGraph); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("search_nodes", "Search for nodes in the knowledge graph based on a query", direct.SearchNodes); err != nil {
-		return err
-	}
-	if err := server.RegisterTool("open_nodes", "Open specific nodes in the knowledge graph by their names", direct.OpenNodes); err != nil {
+	if err := direct.Apply(server); err != nil {
 		return err
 	}
 
 	// ** start application **
 	errChan := make(chan error)
 
+	// serve health checks and metrics on a side port, stdio carries the MCP messages
+	if httpAddress != "" {
+		mux := http.NewServeMux()
+		health.Register(mux, health.Config{
+			Ready:   dbClient.Ready,
+			Version: viper.GetString(config.Keys.SoftwareVersion),
+			Commit:  viper.GetString(config.Keys.SoftwareCommit),
+		})
+		mux.Handle("GET /metrics", m.Handler())
+		if err := action.ServeHTTP(ctx, httpAddress, mux, errChan); err != nil {
+			zap.L().Error("Error serving http", zap.Error(err))
+
+			return err
+		}
+
+		go m.RefreshGraphCounts(ctx, func(ctx context.Context) (*models.GraphCounts, error) {
+			return dbClient.CountGraph(ctx)
+		}, viper.GetDuration(config.Keys.MetricsRefreshInterval))
+	}
+
 	// Wait for SIGINT and SIGTERM (HIT CTRL-C)
 	stopSigChan := make(chan os.Signal, 1)
 	signal.Notify(stopSigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -122,6 +164,29 @@ var Direct action.Action = func(ctx context.Context, _ []string) error {
 		zap.L().Fatal("fatal error", zap.Error(err))
 	}
 
+	// stop the side servers before the database is closed
+	cancel()
+
 	zap.L().Info("done")
 	return nil
 }
+
+// checkSchema checks the database schema matches the migrations of this version, applying the pending ones first if
+// auto migrate is on.
+func checkSchema(ctx context.Context, dbClient *bun.Client) error {
+	err := dbClient.CheckSchema(ctx)
+	var schemaErr *bun.SchemaVersionError
+	if !errors.As(err, &schemaErr) || schemaErr.Ahead() {
+		return err
+	}
+
+	if !viper.GetBool(config.Keys.AutoMigrate) {
+		return fmt.Errorf("%w; run mcp-dbmem migrate or start with --%s", err, config.Keys.AutoMigrate)
+	}
+	zap.L().Info("applying pending migrations", zap.Strings("migrations", schemaErr.Pending))
+	if err := dbClient.DoMigration(ctx); err != nil {
+		return fmt.Errorf("can't migrate: %w", err)
+	}
+
+	return dbClient.CheckSchema(ctx)
+}
@@ -0,0 +1,79 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"go.uber.org/zap"
+)
+
+// Doctor prints the consistency problems in the graph. With --fix the ones that can be fixed are.
+var Doctor action.Action = func(ctx context.Context, _ []string) error {
+	fix := viper.GetBool(config.Keys.DoctorFix)
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	// load the ontology so merged relations keep their inverses
+	o, strictness, err := action.LoadOntology(ctx, dbClient)
+	if err != nil {
+		zap.L().Error("Error loading ontology", zap.Error(err))
+
+		return err
+	}
+
+	l := v1.NewLogic(v1.LogicConfig{
+		DB:                 dbClient,
+		Ontology:           o,
+		OntologyStrictness: strictness,
+	})
+
+	report, err := l.LintGraph(ctx, fix)
+	if err != nil {
+		zap.L().Error("Error checking graph", zap.Error(err))
+
+		return err
+	}
+	if len(report.Issues) == 0 {
+		fmt.Println("no problems found")
+
+		return nil
+	}
+
+	fixable, fixed := 0, 0
+	for _, issue := range report.Issues {
+		status := "  "
+		switch {
+		case issue.Fixed:
+			status = "✓ "
+			fixed++
+		case issue.Fixable:
+			fixable++
+		}
+		fmt.Printf("%s%s: %s\n", status, issue.Kind, issue.Message)
+	}
+
+	if fix {
+		fmt.Printf("%d problems, %d fixed in session %s\n", len(report.Issues), fixed, l.SessionID())
+
+		return nil
+	}
+	fmt.Printf("%d problems, %d can be fixed with --%s\n", len(report.Issues), fixable, config.Keys.DoctorFix)
+
+	return nil
+}
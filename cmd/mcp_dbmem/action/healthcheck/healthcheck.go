@@ -0,0 +1,36 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"go.uber.org/zap"
+)
+
+// Healthcheck runs the readiness check of /readyz, failing if the database can't be reached or isn't migrated.
+var Healthcheck action.Action = func(ctx context.Context, _ []string) error {
+	ctx, cancel := context.WithTimeout(ctx, viper.GetDuration(config.Keys.HealthcheckTimeout))
+	defer cancel()
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		return fmt.Errorf("not ready: %w", err)
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	if err := dbClient.Ready(ctx); err != nil {
+		return fmt.Errorf("not ready: %w", err)
+	}
+	zap.L().Info("ready")
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package migratecreate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun/migrations"
+)
+
+// MigrateCreate creates a migration named by the first argument from the migration template.
+var MigrateCreate action.Action = func(_ context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected the migration name as the only argument")
+	}
+
+	path, err := migrations.Create(viper.GetString(config.Keys.MigrationsDir), args[0], time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s\n", path)
+
+	return nil
+}
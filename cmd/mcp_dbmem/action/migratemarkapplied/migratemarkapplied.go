@@ -0,0 +1,44 @@
+package migratemarkapplied
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// MigrateMarkApplied marks every pending migration applied without running it.
+var MigrateMarkApplied action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	group, err := dbClient.MarkMigrationsApplied(ctx)
+	if err != nil {
+		zap.L().Error("Error marking migrations applied", zap.Error(err))
+
+		return err
+	}
+	if group.IsZero() {
+		fmt.Println("nothing to mark applied")
+		return nil
+	}
+
+	for _, m := range group.Migrations {
+		fmt.Println(m.String())
+	}
+	fmt.Printf("marked applied %d migrations in group %d\n", len(group.Migrations), group.ID)
+
+	return nil
+}
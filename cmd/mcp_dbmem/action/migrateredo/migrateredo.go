@@ -0,0 +1,44 @@
+package migrateredo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// MigrateRedo rolls back the last group of applied migrations and applies them again.
+var MigrateRedo action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	group, err := dbClient.RedoMigrations(ctx)
+	if err != nil {
+		zap.L().Error("Error redoing migrations", zap.Error(err))
+
+		return err
+	}
+	if group.IsZero() {
+		fmt.Println("nothing to redo")
+		return nil
+	}
+
+	for _, m := range group.Migrations {
+		fmt.Println(m.String())
+	}
+	fmt.Printf("applied again %d migrations in group %d\n", len(group.Migrations), group.ID)
+
+	return nil
+}
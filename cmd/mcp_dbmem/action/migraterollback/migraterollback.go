@@ -0,0 +1,44 @@
+package migraterollback
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// MigrateRollback rolls back the last group of applied migrations, printing them.
+var MigrateRollback action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	group, err := dbClient.RollbackMigrations(ctx)
+	if err != nil {
+		zap.L().Error("Error rolling back migrations", zap.Error(err))
+
+		return err
+	}
+	if group.IsZero() {
+		fmt.Println("nothing to roll back")
+		return nil
+	}
+
+	for _, m := range group.Migrations {
+		fmt.Println(m.String())
+	}
+	fmt.Printf("rolled back %d migrations in group %d\n", len(group.Migrations), group.ID)
+
+	return nil
+}
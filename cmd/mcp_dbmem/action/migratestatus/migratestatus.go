@@ -0,0 +1,52 @@
+package migratestatus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// MigrateStatus prints every migration with whether and when it was applied.
+var MigrateStatus action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	ms, err := dbClient.MigrationStatus(ctx)
+	if err != nil {
+		zap.L().Error("Error reading migration status", zap.Error(err))
+
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "MIGRATION\tGROUP\tAPPLIED AT")
+	for _, m := range ms {
+		if !m.IsApplied() {
+			_, _ = fmt.Fprintf(w, "%s\t-\tpending\n", m.String())
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", m.String(), m.GroupID, m.MigratedAt.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d applied, %d pending\n", len(ms.Applied()), len(ms.Unapplied()))
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package migrateunlock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"go.uber.org/zap"
+)
+
+// MigrateUnlock releases a migration lock left behind by a migration that didn't exit cleanly.
+var MigrateUnlock action.Action = func(ctx context.Context, _ []string) error {
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	if err := dbClient.UnlockMigrations(ctx); err != nil {
+		zap.L().Error("Error releasing the migration lock", zap.Error(err))
+
+		return err
+	}
+	fmt.Println("released the migration lock")
+
+	return nil
+}
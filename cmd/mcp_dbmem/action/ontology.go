@@ -0,0 +1,47 @@
+package action
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"go.uber.org/zap"
+)
+
+// LoadOntology loads the ontology from the ontology file if one is set, or else the one stored in the database. It
+// returns nil if there is neither.
+func LoadOntology(ctx context.Context, dbClient db.DB) (*ontology.Ontology, ontology.Strictness, error) {
+	strictness, err := ontology.ParseStrictness(viper.GetString(config.Keys.OntologyStrictness))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if path := viper.GetString(config.Keys.OntologyFile); path != "" {
+		o, err := ontology.Load(path)
+		if err != nil {
+			return nil, "", err
+		}
+		zap.L().Info("loaded ontology", zap.String("file", path), zap.String("strictness", string(strictness)))
+
+		return o, strictness, nil
+	}
+
+	stored, err := dbClient.ReadLatestOntology(ctx)
+	switch {
+	case errors.Is(err, db.ErrNoEntries):
+		return nil, ontology.StrictnessOff, nil
+	case err != nil:
+		return nil, "", err
+	}
+
+	o, err := ontology.Parse([]byte(stored.Document))
+	if err != nil {
+		return nil, "", err
+	}
+	zap.L().Info("loaded ontology from database", zap.Int64("id", stored.ID), zap.String("strictness", string(strictness)))
+
+	return o, strictness, nil
+}
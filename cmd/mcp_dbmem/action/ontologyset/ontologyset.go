@@ -0,0 +1,56 @@
+package ontologyset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"go.uber.org/zap"
+)
+
+// OntologySet stores the ontology in the YAML file given as the first argument in the database.
+var OntologySet action.Action = func(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected the ontology file as the only argument")
+	}
+
+	/* #nosec G304 */
+	document, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	// only store ontologies that can be loaded
+	if _, err := ontology.Parse(document); err != nil {
+		return err
+	}
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	stored := &models.Ontology{
+		Document: string(document),
+	}
+	if err := dbClient.CreateOntology(ctx, stored); err != nil {
+		zap.L().Error("Error storing ontology", zap.Error(err))
+
+		return err
+	}
+	fmt.Printf("stored ontology %d from %s\n", stored.ID, args[0])
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"go.uber.org/zap"
+)
+
+// Purge permanently removes rows that have been in the trash for longer than --older-than.
+var Purge action.Action = func(ctx context.Context, _ []string) error {
+	olderThan := viper.GetDuration(config.Keys.PurgeOlderThan)
+	if olderThan < 0 {
+		return fmt.Errorf("--%s can't be negative", config.Keys.PurgeOlderThan)
+	}
+	deletedBefore := time.Now().Add(-olderThan)
+	zap.L().Info("purging trash", zap.Time("deleted_before", deletedBefore))
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	l := v1.NewLogic(v1.LogicConfig{
+		DB: dbClient,
+	})
+
+	result, err := l.PurgeTrash(ctx, deletedBefore)
+	if err != nil {
+		zap.L().Error("Error purging trash", zap.Error(err))
+
+		return err
+	}
+	fmt.Printf("purged %d entities, %d observations and %d relations\n", result.Entities, result.Observations, result.Relations)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/archive"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	"go.uber.org/zap"
+)
+
+// Restore restores the archive given as the first argument into the database, which must be empty unless merging.
+var Restore action.Action = func(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected the archive file as the only argument")
+	}
+	merge := viper.GetBool(config.Keys.RestoreMerge)
+
+	/* #nosec G304 */
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	result, err := dbClient.Restore(ctx, file, merge)
+	if err != nil {
+		zap.L().Error("Error restoring database", zap.Error(err))
+		if errors.Is(err, bun.ErrNotEmpty) {
+			return fmt.Errorf("%w; restore with --%s to keep the rows already there", err, config.Keys.RestoreMerge)
+		}
+		if errors.Is(err, bun.ErrMergeConflict) {
+			return fmt.Errorf("%w; restore it into an empty database and copy that into this one instead", err)
+		}
+
+		return err
+	}
+
+	for _, table := range archive.Tables {
+		if skipped := result.Skipped[table]; skipped > 0 {
+			fmt.Printf("%s: %d restored, %d skipped\n", table, result.Restored[table], skipped)
+			continue
+		}
+		fmt.Printf("%s: %d restored\n", table, result.Restored[table])
+	}
+	fmt.Printf("restored schema version %s from %s\n", result.Manifest.SchemaVersion, args[0])
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"go.uber.org/zap"
+)
+
+// Rollback inverts journaled changes. Without --apply it only prints the planned changes.
+var Rollback action.Action = func(ctx context.Context, _ []string) error {
+	target, err := parseTarget(viper.GetString(config.Keys.RollbackTo))
+	if err != nil {
+		return err
+	}
+	target.SessionID = viper.GetString(config.Keys.RollbackSession)
+
+	// create database client
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.Error(err))
+
+		return err
+	}
+	defer func() {
+		err := dbClient.Close()
+		if err != nil {
+			zap.L().Error("Error closing bun client", zap.Error(err))
+		}
+	}()
+
+	l := v1.NewLogic(v1.LogicConfig{
+		DB: dbClient,
+	})
+
+	changes, err := l.ReadChangesForRollback(ctx, target)
+	if err != nil {
+		zap.L().Error("Error reading changes", zap.Error(err))
+
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("nothing to roll back")
+
+		return nil
+	}
+
+	// print the diff, newest first as that is the order they are undone in
+	for i := len(changes) - 1; i >= 0; i-- {
+		fmt.Println(describe(changes[i]))
+	}
+
+	if !viper.GetBool(config.Keys.RollbackApply) {
+		fmt.Printf("dry run: %d changes would be rolled back, run again with --%s to apply\n", len(changes), config.Keys.RollbackApply)
+
+		return nil
+	}
+
+	if err := l.Rollback(ctx, changes); err != nil {
+		zap.L().Error("Error rolling back changes", zap.Error(err))
+
+		return err
+	}
+	fmt.Printf("rolled back %d changes in session %s\n", len(changes), l.SessionID())
+
+	return nil
+}
+
+// parseTarget reads a change id or a RFC 3339 time.
+func parseTarget(to string) (logic.RollbackTarget, error) {
+	if to == "" {
+		return logic.RollbackTarget{}, fmt.Errorf("--%s is required", config.Keys.RollbackTo)
+	}
+
+	if changeID, err := strconv.ParseInt(to, 10, 64); err == nil {
+		if changeID < 0 {
+			return logic.RollbackTarget{}, errors.New("change id can't be negative")
+		}
+
+		return logic.RollbackTarget{ChangeID: changeID}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return logic.RollbackTarget{}, fmt.Errorf("%s is neither a change id nor a RFC 3339 time", to)
+	}
+
+	return logic.RollbackTarget{Time: t}, nil
+}
+
+// describe returns the inverse of change as a diff line.
+func describe(change *models.Change) string {
+	sign := "+"
+	switch change.Action {
+	case models.ChangeActionCreate:
+		sign = "-"
+	case models.ChangeActionUpdate:
+		sign = "~"
+	}
+
+	return fmt.Sprintf("%s %s %d %s (undo %s #%d, session %s, %s)",
+		sign, change.Kind, change.RecordID, change.Data, change.Action, change.ID, change.SessionID, change.CreatedAt.Format(time.RFC3339))
+}
@@ -0,0 +1,103 @@
+package syncdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	"go.uber.org/zap"
+)
+
+// Sync syncs the memory of the database with a remote one in both directions and prints the conflicts it resolved.
+var Sync action.Action = func(ctx context.Context, _ []string) error {
+	format := viper.GetString(config.Keys.SyncFormat)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unknown format %q, use table or json", format)
+	}
+	syncConfig := bun.SyncConfig{
+		Strategy: bun.SyncStrategy(viper.GetString(config.Keys.SyncStrategy)),
+		Full:     viper.GetBool(config.Keys.SyncFull),
+		DryRun:   viper.GetBool(config.Keys.SyncDryRun),
+	}
+
+	// create database clients
+	dbClient, err := action.NewDBClient(ctx)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.String("database", "local"), zap.Error(err))
+
+		return err
+	}
+	defer closeDBClient(dbClient, "local")
+	remoteConfig := action.DBClientConfig()
+	remoteConfig.URL = viper.GetString(config.Keys.SyncRemote)
+	remoteClient, err := bun.New(ctx, remoteConfig)
+	if err != nil {
+		zap.L().Error("Error creating bun client", zap.String("database", "remote"), zap.Error(err))
+
+		return err
+	}
+	defer closeDBClient(remoteClient, "remote")
+
+	result, err := dbClient.Sync(ctx, remoteClient, syncConfig)
+	if err != nil {
+		zap.L().Error("Error syncing database", zap.Error(err))
+
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(result)
+	}
+	if err := printTable(os.Stdout, result); err != nil {
+		return err
+	}
+	if syncConfig.DryRun {
+		fmt.Println("dry run, nothing was changed")
+	}
+
+	return nil
+}
+
+func printTable(out io.Writer, result *bun.SyncResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "KIND\tPULLED\tPUSHED\tLINKED")
+	for _, kind := range bun.SyncKinds {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", kind, result.Pulled[kind], result.Pushed[kind], result.Linked[kind])
+	}
+
+	if len(result.Conflicts) > 0 {
+		_, _ = fmt.Fprintln(w, "\nCONFLICT\tRECORD\tLOCAL\tREMOTE\tKEPT\tREASON")
+		for _, conflict := range result.Conflicts {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				conflict.Kind, conflict.Record, conflict.Local, conflict.Remote, conflict.Kept, conflict.Reason)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if result.Full {
+		_, _ = fmt.Fprintf(out, "compared every row, %d conflicts\n", len(result.Conflicts))
+	} else {
+		_, _ = fmt.Fprintf(out, "compared the rows changed since the last sync, %d conflicts\n", len(result.Conflicts))
+	}
+
+	return nil
+}
+
+func closeDBClient(client *bun.Client, database string) {
+	if err := client.Close(); err != nil {
+		zap.L().Error("Error closing bun client", zap.String("database", database), zap.Error(err))
+	}
+}
@@ -0,0 +1,39 @@
+package action
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/telemetry"
+)
+
+// SetupTelemetry starts exporting traces as configured and returns a function flushing them.
+func SetupTelemetry(ctx context.Context) (func(ctx context.Context) error, error) {
+	headers, err := telemetry.ParseKeyValues(viper.GetStringSlice(config.Keys.TraceOTLPHeaders))
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := telemetry.ParseKeyValues(viper.GetStringSlice(config.Keys.TraceResourceAttributes))
+	if err != nil {
+		return nil, err
+	}
+
+	var samplerRatio *float64
+	if viper.IsSet(config.Keys.TraceSamplerRatio) {
+		ratio := viper.GetFloat64(config.Keys.TraceSamplerRatio)
+		samplerRatio = &ratio
+	}
+
+	return telemetry.Setup(ctx, telemetry.Config{
+		Exporter:           viper.GetString(config.Keys.TraceExporter),
+		Protocol:           viper.GetString(config.Keys.TraceOTLPProtocol),
+		Endpoint:           viper.GetString(config.Keys.TraceOTLPEndpoint),
+		Headers:            headers,
+		Insecure:           viper.GetBool(config.Keys.TraceOTLPInsecure),
+		SamplerRatio:       samplerRatio,
+		ResourceAttributes: attributes,
+		ServiceVersion:     viper.GetString(config.Keys.SoftwareVersion),
+		UptraceDSN:         viper.GetString(config.Keys.UptraceDSN),
+	})
+}
@@ -0,0 +1,15 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Analyze adds flags for the analyze command.
+func Analyze(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().String(config.Keys.AnalyzeFormat, values.AnalyzeFormat, usage.AnalyzeFormat)
+	cmd.Flags().Int(config.Keys.AnalyzeTop, values.AnalyzeTop, usage.AnalyzeTop)
+	cmd.Flags().Int(config.Keys.AnalyzeBatchSize, values.AnalyzeBatchSize, usage.AnalyzeBatchSize)
+}
@@ -0,0 +1,14 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// BackfillInverses adds flags for the backfill-inverses command.
+func BackfillInverses(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().Bool(config.Keys.DryRun, values.DryRun, usage.DryRun)
+	Ontology(cmd, values)
+}
@@ -0,0 +1,11 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Backup adds flags for the backup command.
+func Backup(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+}
@@ -0,0 +1,11 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// ConfigPrint adds flags for the config print command, every setting the server uses is shown.
+func ConfigPrint(cmd *cobra.Command, values config.Values) {
+	Direct(cmd, values)
+}
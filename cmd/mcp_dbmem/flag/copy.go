@@ -0,0 +1,19 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Copy adds flags for the copy command.
+func Copy(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().String(config.Keys.CopyFrom, values.CopyFrom, usage.CopyFrom)
+	cmd.Flags().String(config.Keys.CopyTo, values.CopyTo, usage.CopyTo)
+	cmd.Flags().String(config.Keys.CopyNamespace, values.CopyNamespace, usage.CopyNamespace)
+	cmd.Flags().Int(config.Keys.CopyBatchSize, values.CopyBatchSize, usage.CopyBatchSize)
+	cmd.Flags().Duration(config.Keys.MigrateLockTimeout, values.MigrateLockTimeout, usage.MigrateLockTimeout)
+	_ = cmd.MarkFlagRequired(config.Keys.CopyFrom)
+	_ = cmd.MarkFlagRequired(config.Keys.CopyTo)
+}
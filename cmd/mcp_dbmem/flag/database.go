@@ -7,12 +7,30 @@ import (
 
 // Database adds flags for database configuration.
 func Database(cmd *cobra.Command, values config.Values) {
+	cmd.PersistentFlags().String(config.Keys.DBURL, values.DBURL, usage.DBURL)
+	cmd.PersistentFlags().String(config.Keys.DBURLFile, values.DBURLFile, usage.DBURLFile)
 	cmd.PersistentFlags().String(config.Keys.DBType, values.DBType, usage.DBType)
 	cmd.PersistentFlags().String(config.Keys.DBAddress, values.DBAddress, usage.DBAddress)
 	cmd.PersistentFlags().Uint16(config.Keys.DBPort, values.DBPort, usage.DBPort)
 	cmd.PersistentFlags().String(config.Keys.DBUser, values.DBUser, usage.DBUser)
 	cmd.PersistentFlags().String(config.Keys.DBPassword, values.DBPassword, usage.DBPassword)
+	cmd.PersistentFlags().String(config.Keys.DBPasswordFile, values.DBPasswordFile, usage.DBPasswordFile)
 	cmd.PersistentFlags().String(config.Keys.DBDatabase, values.DBDatabase, usage.DBDatabase)
 	cmd.PersistentFlags().String(config.Keys.DBTLSMode, values.DBTLSMode, usage.DBTLSMode)
 	cmd.PersistentFlags().String(config.Keys.DBTLSCACert, values.DBTLSCACert, usage.DBTLSCACert)
+	cmd.PersistentFlags().String(config.Keys.DBTLSClientCert, values.DBTLSClientCert, usage.DBTLSClientCert)
+	cmd.PersistentFlags().String(config.Keys.DBTLSClientKey, values.DBTLSClientKey, usage.DBTLSClientKey)
+	cmd.PersistentFlags().String(config.Keys.DBTLSServerName, values.DBTLSServerName, usage.DBTLSServerName)
+	cmd.PersistentFlags().String(config.Keys.DBTLSMinVersion, values.DBTLSMinVersion, usage.DBTLSMinVersion)
+	cmd.PersistentFlags().Duration(config.Keys.DBWait, values.DBWait, usage.DBWait)
+	cmd.PersistentFlags().Duration(config.Keys.DBRetryMaxElapsed, values.DBRetryMaxElapsed, usage.DBRetryMaxElapsed)
+	cmd.PersistentFlags().Int(config.Keys.DBMaxOpenConns, values.DBMaxOpenConns, usage.DBMaxOpenConns)
+	cmd.PersistentFlags().Int(config.Keys.DBMaxIdleConns, values.DBMaxIdleConns, usage.DBMaxIdleConns)
+	cmd.PersistentFlags().Duration(config.Keys.DBConnMaxLifetime, values.DBConnMaxLifetime, usage.DBConnMaxLifetime)
+	cmd.PersistentFlags().Duration(config.Keys.DBConnMaxIdleTime, values.DBConnMaxIdleTime, usage.DBConnMaxIdleTime)
+	cmd.PersistentFlags().Duration(config.Keys.DBStatementTimeout, values.DBStatementTimeout, usage.DBStatementTimeout)
+	cmd.PersistentFlags().Bool(config.Keys.DBSQLiteForeignKeys, values.DBSQLiteForeignKeys, usage.DBSQLiteForeignKeys)
+	cmd.PersistentFlags().String(config.Keys.DBSQLiteJournalMode, values.DBSQLiteJournalMode, usage.DBSQLiteJournalMode)
+	cmd.PersistentFlags().Duration(config.Keys.DBSQLiteBusyTimeout, values.DBSQLiteBusyTimeout, usage.DBSQLiteBusyTimeout)
+	cmd.PersistentFlags().String(config.Keys.DBSQLiteSynchronous, values.DBSQLiteSynchronous, usage.DBSQLiteSynchronous)
 }
@@ -8,4 +8,15 @@ import (
 // Direct adds flags for the direct command.
 func Direct(cmd *cobra.Command, values config.Values) {
 	Database(cmd, values)
+
+	cmd.Flags().Bool(config.Keys.DryRun, values.DryRun, usage.DryRun)
+	cmd.Flags().Bool(config.Keys.AutoMigrate, values.AutoMigrate, usage.AutoMigrate)
+	cmd.Flags().Duration(config.Keys.MigrateLockTimeout, values.MigrateLockTimeout, usage.MigrateLockTimeout)
+	cmd.Flags().String(config.Keys.HTTPAddress, values.HTTPAddress, usage.HTTPAddress)
+	cmd.Flags().Duration(config.Keys.MetricsRefreshInterval, values.MetricsRefreshInterval, usage.MetricsRefreshInterval)
+	cmd.Flags().String(config.Keys.MetricsAddress, values.MetricsAddress, usage.MetricsAddress)
+	_ = cmd.Flags().MarkDeprecated(config.Keys.MetricsAddress, "use --"+config.Keys.HTTPAddress+" instead")
+	Ontology(cmd, values)
+	cmd.Flags().String(config.Keys.RulesFile, values.RulesFile, usage.RulesFile)
+	cmd.Flags().Int(config.Keys.InferenceMaxDepth, values.InferenceMaxDepth, usage.InferenceMaxDepth)
 }
@@ -0,0 +1,14 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Doctor adds flags for the doctor command.
+func Doctor(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+	Ontology(cmd, values)
+
+	cmd.Flags().Bool(config.Keys.DoctorFix, values.DoctorFix, usage.DoctorFix)
+}
@@ -7,6 +7,20 @@ import (
 
 // Global adds flags that are common to all commands.
 func Global(cmd *cobra.Command, values config.Values) {
+	cmd.PersistentFlags().String(config.Keys.ConfigFile, values.ConfigFile, usage.ConfigFile)
 	cmd.PersistentFlags().String(config.Keys.LogLevel, values.LogLevel, usage.LogLevel)
+	cmd.PersistentFlags().String(config.Keys.LogFormat, values.LogFormat, usage.LogFormat)
+	cmd.PersistentFlags().String(config.Keys.LogFile, values.LogFile, usage.LogFile)
+	cmd.PersistentFlags().Int(config.Keys.LogFileMaxSize, values.LogFileMaxSize, usage.LogFileMaxSize)
+	cmd.PersistentFlags().Int(config.Keys.LogFileMaxBackups, values.LogFileMaxBackups, usage.LogFileMaxBackups)
+	cmd.PersistentFlags().Bool(config.Keys.LogRedactContents, values.LogRedactContents, usage.LogRedactContents)
+	cmd.PersistentFlags().String(config.Keys.TraceExporter, values.TraceExporter, usage.TraceExporter)
+	cmd.PersistentFlags().String(config.Keys.TraceOTLPProtocol, values.TraceOTLPProtocol, usage.TraceOTLPProtocol)
+	cmd.PersistentFlags().String(config.Keys.TraceOTLPEndpoint, values.TraceOTLPEndpoint, usage.TraceOTLPEndpoint)
+	cmd.PersistentFlags().StringSlice(config.Keys.TraceOTLPHeaders, values.TraceOTLPHeaders, usage.TraceOTLPHeaders)
+	cmd.PersistentFlags().Bool(config.Keys.TraceOTLPInsecure, values.TraceOTLPInsecure, usage.TraceOTLPInsecure)
+	cmd.PersistentFlags().Float64(config.Keys.TraceSamplerRatio, values.TraceSamplerRatio, usage.TraceSamplerRatio)
+	cmd.PersistentFlags().StringSlice(config.Keys.TraceResourceAttributes, values.TraceResourceAttributes, usage.TraceResourceAttributes)
 	cmd.PersistentFlags().String(config.Keys.UptraceDSN, values.UptraceDSN, usage.UptraceDSN)
+	cmd.PersistentFlags().String(config.Keys.UptraceDSNFile, values.UptraceDSNFile, usage.UptraceDSNFile)
 }
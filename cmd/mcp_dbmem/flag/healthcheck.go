@@ -0,0 +1,13 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Healthcheck adds flags for the healthcheck command.
+func Healthcheck(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().Duration(config.Keys.HealthcheckTimeout, values.HealthcheckTimeout, usage.HealthcheckTimeout)
+}
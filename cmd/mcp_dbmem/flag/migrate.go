@@ -5,7 +5,14 @@ import (
 	"github.com/tyrm/mcp-dbmem/internal/config"
 )
 
-// Migrate adds flags for the migrate command.
+// Migrate adds flags for the migrate command and its subcommands.
 func Migrate(cmd *cobra.Command, values config.Values) {
 	Database(cmd, values)
+
+	cmd.PersistentFlags().Duration(config.Keys.MigrateLockTimeout, values.MigrateLockTimeout, usage.MigrateLockTimeout)
+}
+
+// MigrateCreate adds flags for the migrate create command.
+func MigrateCreate(cmd *cobra.Command, values config.Values) {
+	cmd.Flags().String(config.Keys.MigrationsDir, values.MigrationsDir, usage.MigrationsDir)
 }
@@ -0,0 +1,17 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Ontology adds flags for the commands that load the ontology.
+func Ontology(cmd *cobra.Command, values config.Values) {
+	cmd.Flags().String(config.Keys.OntologyFile, values.OntologyFile, usage.OntologyFile)
+	cmd.Flags().String(config.Keys.OntologyStrictness, values.OntologyStrictness, usage.OntologyStrictness)
+}
+
+// OntologySet adds flags for the ontology set command.
+func OntologySet(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+}
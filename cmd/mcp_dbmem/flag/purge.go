@@ -0,0 +1,13 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Purge adds flags for the purge command.
+func Purge(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().Duration(config.Keys.PurgeOlderThan, values.PurgeOlderThan, usage.PurgeOlderThan)
+}
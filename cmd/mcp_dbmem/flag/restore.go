@@ -0,0 +1,15 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Restore adds flags for the restore command.
+func Restore(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().Bool(config.Keys.RestoreMerge, values.RestoreMerge, usage.RestoreMerge)
+	cmd.Flags().Bool(config.Keys.RestoreIntoEmptyOnly, values.RestoreIntoEmptyOnly, usage.RestoreIntoEmptyOnly)
+	cmd.MarkFlagsMutuallyExclusive(config.Keys.RestoreMerge, config.Keys.RestoreIntoEmptyOnly)
+}
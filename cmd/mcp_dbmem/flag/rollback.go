@@ -0,0 +1,15 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Rollback adds flags for the rollback command.
+func Rollback(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().String(config.Keys.RollbackTo, values.RollbackTo, usage.RollbackTo)
+	cmd.Flags().String(config.Keys.RollbackSession, values.RollbackSession, usage.RollbackSession)
+	cmd.Flags().Bool(config.Keys.RollbackApply, values.RollbackApply, usage.RollbackApply)
+}
@@ -0,0 +1,18 @@
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tyrm/mcp-dbmem/internal/config"
+)
+
+// Sync adds flags for the sync command.
+func Sync(cmd *cobra.Command, values config.Values) {
+	Database(cmd, values)
+
+	cmd.Flags().String(config.Keys.SyncRemote, values.SyncRemote, usage.SyncRemote)
+	cmd.Flags().String(config.Keys.SyncStrategy, values.SyncStrategy, usage.SyncStrategy)
+	cmd.Flags().Bool(config.Keys.SyncFull, values.SyncFull, usage.SyncFull)
+	cmd.Flags().Bool(config.Keys.SyncDryRun, values.SyncDryRun, usage.SyncDryRun)
+	cmd.Flags().String(config.Keys.SyncFormat, values.SyncFormat, usage.SyncFormat)
+	_ = cmd.MarkFlagRequired(config.Keys.SyncRemote)
+}
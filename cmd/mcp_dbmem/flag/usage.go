@@ -3,14 +3,79 @@ package flag
 import "github.com/tyrm/mcp-dbmem/internal/config"
 
 var usage = config.KeyNames{
-	LogLevel:        "Log level",
-	SoftwareVersion: "Software version",
-	DBType:          "Database type [postgres, sqlite]",
-	DBAddress:       "Database address",
-	DBPort:          "Database port",
-	DBUser:          "Database user",
-	DBPassword:      "Database password",
-	DBDatabase:      "Database name",
-	DBTLSMode:       "Database TLS mode",
-	DBTLSCACert:     "Database TLS CA certificate",
+	ConfigFile:              "Config file, YAML or TOML. config.yaml or config.toml is looked for in the XDG config directories if it's not set",
+	LogLevel:                "Log level [debug, info, warn, error]",
+	LogFormat:               "Log format [console, json]",
+	LogFile:                 "File to log to instead of stderr",
+	LogFileMaxSize:          "Megabytes the log file may grow to before it's rotated, 0 never rotates it",
+	LogFileMaxBackups:       "Rotated log files to keep",
+	LogRedactContents:       "Keep observation contents out of the logs",
+	SoftwareVersion:         "Software version",
+	SoftwareCommit:          "Software commit",
+	TraceExporter:           "Where to send traces [none, otlp, stdout, uptrace], defaults to OTEL_TRACES_EXPORTER, or uptrace if an Uptrace DSN is set",
+	TraceOTLPProtocol:       "Protocol to send traces to the OTLP collector with [grpc, http/protobuf]",
+	TraceOTLPEndpoint:       "OTLP collector to send traces to, OTEL_EXPORTER_OTLP_ENDPOINT if empty",
+	TraceOTLPHeaders:        "Headers to send traces to the OTLP collector with, as key=value pairs",
+	TraceOTLPInsecure:       "Send traces to the OTLP collector without TLS",
+	TraceSamplerRatio:       "Fraction of traces to sample, OTEL_TRACES_SAMPLER is used if not set",
+	TraceResourceAttributes: "Attributes describing this process added to every trace, as key=value pairs",
+	UptraceDSN:              "Uptrace DSN to send traces to",
+	UptraceDSNFile:          "File to read the Uptrace DSN from",
+	DBURL:                   "Database connection url [postgres://, mysql://, sqlite://, file:], overrides the other connection settings",
+	DBURLFile:               "File to read the database connection url from",
+	DBType:                  "Database type [postgres, sqlite]",
+	DBAddress:               "Database address",
+	DBPort:                  "Database port",
+	DBUser:                  "Database user",
+	DBPassword:              "Database password",
+	DBPasswordFile:          "File to read the database password from",
+	DBDatabase:              "Database name",
+	DBTLSMode:               "Database TLS mode [disable, enable, verify-ca, require, verify-full]",
+	DBTLSCACert:             "Database TLS CA certificate",
+	DBTLSClientCert:         "Database TLS client certificate, for servers requiring mutual TLS",
+	DBTLSClientKey:          "Database TLS client key",
+	DBTLSServerName:         "Name the database server certificate is verified for, the address if it's empty",
+	DBTLSMinVersion:         "Lowest TLS version accepted from the database [1.2, 1.3]",
+	DBWait:                  "How long to wait for the database to come up when connecting",
+	DBRetryMaxElapsed:       "How long reads and transactions failing with a transient error are retried, 0 disables retries",
+	DBMaxOpenConns:          "Most open database connections, 0 is 4 per CPU",
+	DBMaxIdleConns:          "Most idle database connections, 0 is the same as the most open connections",
+	DBConnMaxLifetime:       "Close database connections open this long, 0 keeps them",
+	DBConnMaxIdleTime:       "Close database connections idle this long, 0 keeps them",
+	DBStatementTimeout:      "Cancel statements running this long, only SELECTs on MySQL, 0 is no limit",
+	DBSQLiteForeignKeys:     "Enforce foreign keys on SQLite, without them deleting entities leaves their rows behind",
+	DBSQLiteJournalMode:     "SQLite journal mode [DELETE, TRUNCATE, PERSIST, MEMORY, WAL, OFF]",
+	DBSQLiteBusyTimeout:     "How long SQLite waits for a lock held by another connection",
+	DBSQLiteSynchronous:     "SQLite synchronous setting [OFF, NORMAL, FULL, EXTRA]",
+	DryRun:                  "Run every mutating tool as a dry run that returns the planned changes without making them",
+	AutoMigrate:             "Apply pending migrations on startup instead of refusing to serve",
+	OntologyFile:            "YAML file declaring the allowed entity and relation types, the ontology stored in the database is used if not set",
+	OntologyStrictness:      "What to do with changes that violate the ontology [off, warn, reject]",
+	HTTPAddress:             "Address to serve the health, version and Prometheus metrics endpoints on, e.g. :9090, they're off if it's empty",
+	HealthcheckTimeout:      "How long the health check may take",
+	MigrateLockTimeout:      "How long to wait for another migration holding the migration lock",
+	MigrationsDir:           "Directory new migrations are created in",
+	MetricsRefreshInterval:  "How often the entity, observation and relation counts are refreshed",
+	MetricsAddress:          "Deprecated, the old name of http-address",
+	RulesFile:               "YAML file declaring the rules relations are inferred with",
+	InferenceMaxDepth:       "How many rounds of inference rules are applied at most, bounding recursive rules",
+	AnalyzeFormat:           "Output format [table, json]",
+	AnalyzeTop:              "How many entities, components and communities to list",
+	AnalyzeBatchSize:        "How many relations and entities to read from the database at a time",
+	DoctorFix:               "Fix the problems that can be fixed, in a single transaction",
+	RollbackTo:              "Roll back every change after this RFC 3339 time or change id",
+	RollbackSession:         "Only roll back changes made by this session",
+	RollbackApply:           "Apply the rollback instead of only printing the planned changes",
+	PurgeOlderThan:          "Permanently remove rows that have been in the trash for longer than this",
+	RestoreMerge:            "Restore into a database that has rows, skipping the archived rows already there",
+	RestoreIntoEmptyOnly:    "Refuse to restore into a database that has rows, the default",
+	CopyFrom:                "Connection url of the database to copy from",
+	CopyTo:                  "Connection url of the database to copy into, it's migrated first",
+	CopyNamespace:           "Prefix the names and aliases of the copied entities with this namespace and a /",
+	CopyBatchSize:           "How many rows to copy per transaction",
+	SyncRemote:              "Connection url of the database to sync with",
+	SyncStrategy:            "How rows changed in both databases are resolved [lww, merge]",
+	SyncFull:                "Compare every row instead of the rows changed since the last sync",
+	SyncDryRun:              "Print what would be synced and the conflicts without changing either database",
+	SyncFormat:              "Output format [table, json]",
 }
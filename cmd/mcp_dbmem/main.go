@@ -8,10 +8,29 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/analyze"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/backfillinverses"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/backup"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/configprint"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/copydb"
 	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/direct"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/doctor"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/healthcheck"
 	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migrate"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migratecreate"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migratemarkapplied"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migrateredo"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migraterollback"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migratestatus"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/migrateunlock"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/ontologyset"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/purge"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/restore"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/rollback"
+	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/action/syncdb"
 	"github.com/tyrm/mcp-dbmem/cmd/mcp_dbmem/flag"
 	"github.com/tyrm/mcp-dbmem/internal/config"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
 	"go.uber.org/zap"
 )
 
@@ -31,7 +50,8 @@ func main() {
 		return
 	}
 	defer func() {
-		_ = zapLogger.Sync()
+		// the configured logger replaces this one once the config is read
+		_ = zap.L().Sync()
 	}()
 	zap.ReplaceGlobals(zapLogger)
 
@@ -44,6 +64,7 @@ func main() {
 	}
 
 	viper.Set(config.Keys.SoftwareVersion, version)
+	viper.Set(config.Keys.SoftwareCommit, Commit)
 
 	rootCmd := &cobra.Command{
 		Use:           "mcp-dbmem",
@@ -80,6 +101,251 @@ func main() {
 	flag.Migrate(migrateCmd, config.Defaults)
 	rootCmd.AddCommand(migrateCmd)
 
+	migrateStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "show which migrations are applied",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migratestatus.MigrateStatus, args)
+		},
+	}
+	migrateCmd.AddCommand(migrateStatusCmd)
+
+	migrateRollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "roll back the last group of applied migrations",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migraterollback.MigrateRollback, args)
+		},
+	}
+	migrateCmd.AddCommand(migrateRollbackCmd)
+
+	migrateRedoCmd := &cobra.Command{
+		Use:   "redo",
+		Short: "roll back the last group of applied migrations and apply them again",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migrateredo.MigrateRedo, args)
+		},
+	}
+	migrateCmd.AddCommand(migrateRedoCmd)
+
+	migrateMarkAppliedCmd := &cobra.Command{
+		Use:   "mark-applied",
+		Short: "mark pending migrations applied without running them",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migratemarkapplied.MigrateMarkApplied, args)
+		},
+	}
+	migrateCmd.AddCommand(migrateMarkAppliedCmd)
+
+	migrateUnlockCmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "release a migration lock left behind by a migration that didn't exit cleanly",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migrateunlock.MigrateUnlock, args)
+		},
+	}
+	migrateCmd.AddCommand(migrateUnlockCmd)
+
+	migrateCreateCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "create a migration from the migration template",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migratecreate.MigrateCreate, args)
+		},
+	}
+	flag.MigrateCreate(migrateCreateCmd, config.Defaults)
+	migrateCmd.AddCommand(migrateCreateCmd)
+
+	healthcheckCmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "check the database can be reached and is migrated, exiting non-zero if it isn't",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), healthcheck.Healthcheck, args)
+		},
+	}
+	flag.Healthcheck(healthcheckCmd, config.Defaults)
+	rootCmd.AddCommand(healthcheckCmd)
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "undo journaled changes, printing them first",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), rollback.Rollback, args)
+		},
+	}
+	flag.Rollback(rollbackCmd, config.Defaults)
+	rootCmd.AddCommand(rollbackCmd)
+
+	backupCmd := &cobra.Command{
+		Use:   "backup <file>",
+		Short: "write every row of the database to a compressed archive",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), backup.Backup, args)
+		},
+	}
+	flag.Backup(backupCmd, config.Defaults)
+	rootCmd.AddCommand(backupCmd)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "restore a backup archive into the database",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), restore.Restore, args)
+		},
+	}
+	flag.Restore(restoreCmd, config.Defaults)
+	rootCmd.AddCommand(restoreCmd)
+
+	copyCmd := &cobra.Command{
+		Use:   "copy",
+		Short: "copy the memory of one database into another",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), copydb.Copy, args)
+		},
+	}
+	flag.Copy(copyCmd, config.Defaults)
+	rootCmd.AddCommand(copyCmd)
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "sync the memory with another database in both directions",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), syncdb.Sync, args)
+		},
+	}
+	flag.Sync(syncCmd, config.Defaults)
+	rootCmd.AddCommand(syncCmd)
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "permanently remove old rows from the trash",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), purge.Purge, args)
+		},
+	}
+	flag.Purge(purgeCmd, config.Defaults)
+	rootCmd.AddCommand(purgeCmd)
+
+	ontologyCmd := &cobra.Command{
+		Use:   "ontology",
+		Short: "manage the ontology stored in the database",
+	}
+	ontologySetCmd := &cobra.Command{
+		Use:   "set <file>",
+		Short: "store an ontology YAML file in the database",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), ontologyset.OntologySet, args)
+		},
+	}
+	flag.OntologySet(ontologySetCmd, config.Defaults)
+	ontologyCmd.AddCommand(ontologySetCmd)
+	rootCmd.AddCommand(ontologyCmd)
+
+	backfillInversesCmd := &cobra.Command{
+		Use:   "backfill-inverses",
+		Short: "create the missing inverses of relations declared by the ontology",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), backfillinverses.BackfillInverses, args)
+		},
+	}
+	flag.BackfillInverses(backfillInversesCmd, config.Defaults)
+	rootCmd.AddCommand(backfillInversesCmd)
+
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "report the hub entities, components and communities of the graph",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), analyze.Analyze, args)
+		},
+	}
+	flag.Analyze(analyzeCmd, config.Defaults)
+	rootCmd.AddCommand(analyzeCmd)
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "check the graph for consistency problems and optionally fix them",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), doctor.Doctor, args)
+		},
+	}
+	flag.Doctor(doctorCmd, config.Defaults)
+	rootCmd.AddCommand(doctorCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "inspect the configuration",
+	}
+	configPrintCmd := &cobra.Command{
+		Use:   "print",
+		Short: "print the effective configuration with secrets redacted",
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), configprint.ConfigPrint, args)
+		},
+	}
+	flag.ConfigPrint(configPrintCmd, config.Defaults)
+	configCmd.AddCommand(configPrintCmd)
+	rootCmd.AddCommand(configCmd)
+
 	err = rootCmd.Execute()
 	if err != nil {
 		zap.L().Fatal("Error executing command", zap.Error(err))
@@ -91,6 +357,19 @@ func preRun(cmd *cobra.Command) error {
 		return fmt.Errorf("error initializing config: %w", err)
 	}
 
+	logger, err := logging.New(logging.Config{
+		Level:          viper.GetString(config.Keys.LogLevel),
+		Format:         viper.GetString(config.Keys.LogFormat),
+		File:           viper.GetString(config.Keys.LogFile),
+		FileMaxSize:    viper.GetInt(config.Keys.LogFileMaxSize),
+		FileMaxBackups: viper.GetInt(config.Keys.LogFileMaxBackups),
+		RedactContents: viper.GetBool(config.Keys.LogRedactContents),
+	})
+	if err != nil {
+		return fmt.Errorf("error initializing logger: %w", err)
+	}
+	zap.ReplaceGlobals(logger)
+
 	return nil
 }
 
@@ -2,8 +2,15 @@ package adapter
 
 import (
 	"context"
+	"time"
 
 	mcp "github.com/metoro-io/mcp-golang"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
+	"github.com/tyrm/mcp-dbmem/internal/metrics"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
 )
 
 type Adapter interface {
@@ -16,41 +23,112 @@ type Adapter interface {
 	DeleteObservations(ctx context.Context, args DeleteObservationsArgs) (*mcp.ToolResponse, error)
 	CreateRelations(ctx context.Context, args CreateRelationsArgs) (*mcp.ToolResponse, error)
 	DeleteRelations(ctx context.Context, args DeleteRelationsArgs) (*mcp.ToolResponse, error)
+	ListTrash(ctx context.Context, args ListTrashArgs) (*mcp.ToolResponse, error)
+	RestoreEntities(ctx context.Context, args RestoreEntitiesArgs) (*mcp.ToolResponse, error)
+	AddAliases(ctx context.Context, args AddAliasesArgs) (*mcp.ToolResponse, error)
+	RemoveAliases(ctx context.Context, args RemoveAliasesArgs) (*mcp.ToolResponse, error)
+	SuggestEntities(ctx context.Context, args SuggestEntitiesArgs) (*mcp.ToolResponse, error)
+	GetOntology(ctx context.Context, args GetOntologyArgs) (*mcp.ToolResponse, error)
+	Infer(ctx context.Context, args InferArgs) (*mcp.ToolResponse, error)
+	AnalyzeGraph(ctx context.Context, args AnalyzeGraphArgs) (*mcp.ToolResponse, error)
+	LintGraph(ctx context.Context, args LintGraphArgs) (*mcp.ToolResponse, error)
 	Apply(server *mcp.Server) error
 }
 
-func apply[A Adapter](a A, server *mcp.Server) error {
-	if err := server.RegisterTool("create_entities", "Create multiple new entities in the knowledge graph", a.CreateEntities); err != nil {
+func apply[A Adapter](a A, server *mcp.Server, m *metrics.Metrics) error {
+	if err := registerTool(server, m, "create_entities", "Create multiple new entities in the knowledge graph", a.CreateEntities); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("create_relations", "Create multiple new relations between entities in the knowledge graph. Relations should be in active voice", a.CreateRelations); err != nil {
+	if err := registerTool(server, m, "create_relations", "Create multiple new relations between entities in the knowledge graph. Relations should be in active voice", a.CreateRelations); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("add_observations", "Add new observations to existing entities in the knowledge graph", a.AddObservations); err != nil {
+	if err := registerTool(server, m, "add_observations", "Add new observations to existing entities in the knowledge graph", a.AddObservations); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("delete_entities", "Delete multiple entities and their associated relations from the knowledge graph", a.DeleteEntities); err != nil {
+	if err := registerTool(server, m, "delete_entities", "Delete multiple entities and their associated relations from the knowledge graph. Deleted entities are moved to the trash", a.DeleteEntities); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("delete_observations", "Delete specific observations from entities in the knowledge graph", a.DeleteObservations); err != nil {
+	if err := registerTool(server, m, "delete_observations", "Delete specific observations from entities in the knowledge graph", a.DeleteObservations); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("delete_relations", "Delete multiple relations from the knowledge graph", a.DeleteRelations); err != nil {
+	if err := registerTool(server, m, "delete_relations", "Delete multiple relations from the knowledge graph", a.DeleteRelations); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("read_graph", "Read the entire knowledge graph", a.ReadGraph); err != nil {
+	if err := registerTool(server, m, "read_graph", "Read the entire knowledge graph, optionally as it was at a point in time", a.ReadGraph); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("search_nodes", "Search for nodes in the knowledge graph based on a query", a.SearchNodes); err != nil {
+	if err := registerTool(server, m, "search_nodes", "Search for nodes in the knowledge graph based on a query", a.SearchNodes); err != nil {
 		return err
 	}
-	if err := server.RegisterTool("open_nodes", "Open specific nodes in the knowledge graph by their names", a.OpenNodes); err != nil {
+	if err := registerTool(server, m, "open_nodes", "Open specific nodes in the knowledge graph by their names or aliases", a.OpenNodes); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "list_trash", "List the deleted entities, observations and relations in the trash", a.ListTrash); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "restore_entities", "Restore deleted entities from the trash along with the observations and relations deleted with them", a.RestoreEntities); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "add_aliases", "Add alternative names entities can be referred to by in every tool", a.AddAliases); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "remove_aliases", "Remove alternative names from entities", a.RemoveAliases); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "suggest_entities", "Find existing entities with a name or alias similar to a name. Use it before creating an entity to avoid duplicates", a.SuggestEntities); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "get_ontology", "Read the allowed entity and relation types, which entity types each relation type connects and how many relations an entity may have", a.GetOntology); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "infer", "Derive relations from the stored ones with the configured inference rules, e.g. every transitive part_of parent of an entity. Inferred relations aren't stored unless persist is set", a.Infer); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "analyze_graph", "Find the hub entities of the knowledge graph by degree and PageRank, and the disconnected components and communities it falls into", a.AnalyzeGraph); err != nil {
+		return err
+	}
+	if err := registerTool(server, m, "lint_graph", "Find consistency problems in the knowledge graph such as duplicate entity names, dangling relations and repeated observations, and optionally fix them", a.LintGraph); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// registerTool registers handler as the tool name. Every call is traced and gets a request id, logged with the messages
+// logged during the call, and is logged and counted in m itself.
+func registerTool[T any](server *mcp.Server, m *metrics.Metrics, name, description string, handler func(ctx context.Context, args T) (*mcp.ToolResponse, error)) error {
+	return server.RegisterTool(name, description, func(ctx context.Context, args T) (*mcp.ToolResponse, error) {
+		ctx, span := toolTracer.Start(ctx, "tools/call "+name)
+		defer span.End()
+
+		ctx = logging.WithRequestID(ctx)
+		start := time.Now()
+		span.SetAttributes(
+			attribute.String("mcp.method.name", "tools/call"),
+			attribute.String("gen_ai.tool.name", name),
+			attribute.String("mcp.request_id", logging.RequestID(ctx)),
+		)
+		if counter, ok := any(args).(itemCounter); ok {
+			span.SetAttributes(attribute.Int("mcp.tool.items", counter.itemCount()))
+		}
+
+		response, err := handler(ctx, args)
+		if err != nil {
+			span.SetAttributes(attribute.String("mcp.tool.outcome", "error"))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			m.ObserveToolCall(name, time.Since(start), errorClass(err))
+			logging.L(ctx).Error("Tool call failed", zap.String("tool", name), zap.Duration("duration", time.Since(start)), zap.Error(err))
+			return response, err
+		}
+		span.SetAttributes(attribute.String("mcp.tool.outcome", "ok"))
+		m.ObserveToolCall(name, time.Since(start), metrics.ErrorClassNone)
+		logging.L(ctx).Debug("Tool called", zap.String("tool", name), zap.Duration("duration", time.Since(start)))
+
+		return response, nil
+	})
+}
+
 // Models
 
 // Entity represents an entity in the knowledge graph.
@@ -73,20 +151,53 @@ type Relation struct {
 	Type string `json:"relationType" jsonschema:"required,description=The type of the relation"`
 }
 
+// TrashedEntity represents an entity in the trash.
+type TrashedEntity struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"entityType"`
+	Observations []string  `json:"observations"`
+	DeletedAt    time.Time `json:"deletedAt"`
+}
+
+// TrashedObservation represents an observation that was deleted on its own.
+type TrashedObservation struct {
+	EntityName string    `json:"entityName"`
+	Contents   string    `json:"contents"`
+	DeletedAt  time.Time `json:"deletedAt"`
+}
+
+// TrashedRelation represents a relation in the trash.
+type TrashedRelation struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Type      string    `json:"relationType"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Trash represents the contents of the trash.
+type Trash struct {
+	Entities     []TrashedEntity      `json:"entities"`
+	Observations []TrashedObservation `json:"observations"`
+	Relations    []TrashedRelation    `json:"relations"`
+}
+
 // Request / Response
 
 // CreateEntitiesArgs represents the arguments for creating entities.
 type CreateEntitiesArgs struct {
 	Entities []Entity `json:"entities" jsonschema:"required,description=An array of observation contents associated with the entity"`
+	DryRun   bool     `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
 }
 
 // DeleteEntitiesArgs represents the arguments for deleting entities.
 type DeleteEntitiesArgs struct {
 	EntityNames []string `json:"entityNames" jsonschema:"required,description=An array of entity names to delete"`
+	DryRun      bool     `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
 }
 
 // ReadGraphArgs represents the arguments for reading the knowledge graph.
 type ReadGraphArgs struct {
+	AsOf string `json:"asOf,omitempty" jsonschema:"description=An optional RFC 3339 timestamp. When set the graph is reconstructed as it was at that time from the change journal"`
 }
 
 // OpenNodesArgs represents the arguments for opening nodes.
@@ -102,6 +213,7 @@ type SearchNodesArgs struct {
 // AddObservationsArgs represents the arguments for creating Observations.
 type AddObservationsArgs struct {
 	Observations []AddObservation `json:"observations" jsonschema:"required,description=An array of observation contents to add"`
+	DryRun       bool             `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
 }
 
 // AddObservation represents an observation associated with an entity.
@@ -119,6 +231,7 @@ type AddedObservationsResp struct {
 // DeleteObservationsArgs represents the arguments for deleting Observations.
 type DeleteObservationsArgs struct {
 	Deletions []DeleteObservation `json:"deletions" jsonschema:"required,description=An array of observations to delete"`
+	DryRun    bool                `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
 }
 
 // DeleteObservation represents an observation associated with an entity.
@@ -130,9 +243,199 @@ type DeleteObservation struct {
 // CreateRelationsArgs represents the arguments for creating Relationships.
 type CreateRelationsArgs struct {
 	Relations []Relation `json:"relations" jsonschema:"required,description=Create multiple new relations between entities in the knowledge graph. Relations should be in active voice"`
+	DryRun    bool       `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
 }
 
 // DeleteRelationsArgs represents the arguments for deleting Relationships.
 type DeleteRelationsArgs struct {
 	Relations []Relation `json:"relations" jsonschema:"required,description=Delete multiple relations from the knowledge graph"`
+	DryRun    bool       `json:"dryRun,omitempty" jsonschema:"description=When true the changes that would be made are returned without making them"`
+}
+
+// ListTrashArgs represents the arguments for listing the trash.
+type ListTrashArgs struct {
+}
+
+// RestoreEntitiesArgs represents the arguments for restoring entities from the trash.
+type RestoreEntitiesArgs struct {
+	EntityNames []string `json:"entityNames" jsonschema:"required,description=An array of deleted entity names to restore"`
+}
+
+// RestoreEntitiesResp represents the response for restoring entities from the trash.
+type RestoreEntitiesResp struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+	NotFound  []string   `json:"notFound,omitempty"`
+}
+
+// DryRunResp represents the response of a mutating tool called as a dry run.
+type DryRunResp struct {
+	DryRun         bool            `json:"dryRun"`
+	PlannedChanges []PlannedChange `json:"plannedChanges"`
+}
+
+// PlannedChange represents a single change a dry run would have made.
+type PlannedChange struct {
+	Action       string `json:"action"`
+	Kind         string `json:"kind"`
+	EntityName   string `json:"entityName,omitempty"`
+	EntityType   string `json:"entityType,omitempty"`
+	Contents     string `json:"contents,omitempty"`
+	From         string `json:"from,omitempty"`
+	To           string `json:"to,omitempty"`
+	RelationType string `json:"relationType,omitempty"`
+}
+
+// OpenNodesResp represents the response for opening nodes.
+type OpenNodesResp struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+	NotFound  []string   `json:"notFound,omitempty"`
+	// DidYouMean holds the entities similar to each name that wasn't found.
+	DidYouMean map[string][]Suggestion `json:"didYouMean,omitempty"`
+}
+
+// ResolvedNamesResp lists the names in a request that referred to an entity by something other than its exact name.
+type ResolvedNamesResp struct {
+	ResolvedNames []ResolvedName `json:"resolvedNames"`
+}
+
+// ResolvedName represents a name resolved to a canonical entity, via an alias or by ignoring case.
+type ResolvedName struct {
+	Name       string `json:"name"`
+	EntityName string `json:"entityName"`
+	Via        string `json:"via"`
+}
+
+// AddAliasesArgs represents the arguments for adding aliases.
+type AddAliasesArgs struct {
+	Aliases []EntityAliases `json:"aliases" jsonschema:"required,description=An array of aliases to add to entities"`
+}
+
+// EntityAliases represents aliases for an entity.
+type EntityAliases struct {
+	EntityName string   `json:"entityName" jsonschema:"required,description=The name of the entity to add the aliases to"`
+	Aliases    []string `json:"aliases"    jsonschema:"required,description=An array of alternative names for the entity"`
+}
+
+// EntityAliasesResp represents the aliases of an entity after adding to them.
+type EntityAliasesResp struct {
+	EntityName string          `json:"entityName"`
+	Aliases    []string        `json:"aliases"`
+	Rejected   []RejectedAlias `json:"rejected,omitempty"`
+}
+
+// RejectedAlias represents an alias that couldn't be added.
+type RejectedAlias struct {
+	Alias  string `json:"alias"`
+	Reason string `json:"reason"`
+}
+
+// RemoveAliasesArgs represents the arguments for removing aliases.
+type RemoveAliasesArgs struct {
+	Aliases []string `json:"aliases" jsonschema:"required,description=An array of aliases to remove"`
+}
+
+// RemoveAliasesResp represents the response for removing aliases.
+type RemoveAliasesResp struct {
+	Removed  []RemovedAlias `json:"removed"`
+	NotFound []string       `json:"notFound,omitempty"`
+}
+
+// RemovedAlias represents an alias that was removed and the entity it belonged to.
+type RemovedAlias struct {
+	Alias      string `json:"alias"`
+	EntityName string `json:"entityName"`
+}
+
+// SuggestEntitiesArgs represents the arguments for suggesting entities.
+type SuggestEntitiesArgs struct {
+	Name  string `json:"name"            jsonschema:"required,description=The name to find similar entities for"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=The maximum number of entities to return, 5 if not set"`
+}
+
+// DidYouMeanResp represents the entities suggested when a name wasn't found.
+type DidYouMeanResp struct {
+	DidYouMean []Suggestion `json:"didYouMean"`
+}
+
+// Suggestion represents an entity with a name or alias similar to the one looked up.
+type Suggestion struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"entityType"`
+	Alias string  `json:"alias,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// OntologyWarningsResp lists the ontology violations that were allowed through.
+type OntologyWarningsResp struct {
+	OntologyWarnings []OntologyWarning `json:"ontologyWarnings"`
+}
+
+// OntologyWarning represents an entity or relation that violates the ontology.
+type OntologyWarning struct {
+	Subject string `json:"subject"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// GetOntologyArgs represents the arguments for reading the ontology.
+type GetOntologyArgs struct {
+}
+
+// GetOntologyResp represents the response for reading the ontology.
+type GetOntologyResp struct {
+	Strictness string             `json:"strictness"`
+	Ontology   *ontology.Ontology `json:"ontology"`
+}
+
+// InferArgs represents the arguments for inferring relations.
+type InferArgs struct {
+	EntityName   string `json:"entityName,omitempty"   jsonschema:"description=Only return relations starting or ending at this entity"`
+	RelationType string `json:"relationType,omitempty" jsonschema:"description=Only return relations of this type"`
+	Persist      bool   `json:"persist,omitempty"      jsonschema:"description=When true the inferred relations are stored as regular relations"`
+	DryRun       bool   `json:"dryRun,omitempty"       jsonschema:"description=When true and persisting the changes that would be made are returned without making them"`
+}
+
+// InferResp represents the response for inferring relations.
+type InferResp struct {
+	Relations []InferredRelation `json:"relations"`
+	// Truncated is true if the rules were stopped at the depth limit, so there may be more relations.
+	Truncated bool `json:"truncated,omitempty"`
+	Persisted bool `json:"persisted,omitempty"`
+}
+
+// InferredRelation represents a relation derived by an inference rule.
+type InferredRelation struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Type     string `json:"relationType"`
+	Inferred bool   `json:"inferred"`
+	Rule     string `json:"rule"`
+	Depth    int    `json:"depth"`
+}
+
+// AnalyzeGraphArgs represents the arguments for analyzing the graph.
+type AnalyzeGraphArgs struct {
+	Top int `json:"top,omitempty" jsonschema:"description=How many entities, components and communities to list, 10 if not set"`
+}
+
+// LintGraphArgs represents the arguments for linting the graph.
+type LintGraphArgs struct {
+	Fix    bool `json:"fix,omitempty"    jsonschema:"description=When true the problems that can be fixed are fixed"`
+	DryRun bool `json:"dryRun,omitempty" jsonschema:"description=When true and fixing the changes that would be made are returned without making them"`
+}
+
+// LintGraphResp represents the consistency problems found in the graph.
+type LintGraphResp struct {
+	Issues []LintIssue `json:"issues"`
+	Fixed  int         `json:"fixed"`
+}
+
+// LintIssue represents a single consistency problem.
+type LintIssue struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed,omitempty"`
 }
@@ -1 +1,193 @@
 package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	mcp "github.com/metoro-io/mcp-golang"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"github.com/tyrm/mcp-dbmem/internal/util"
+	"go.uber.org/zap"
+)
+
+// notFoundSuggestions is how many entities are suggested when a name isn't found.
+const notFoundSuggestions = 3
+
+// resolveEntity resolves name to its canonical entity, recording it in resolved if the entity has a different name.
+// If name can't be resolved a tool response explaining why is returned instead of an entity.
+func resolveEntity(ctx context.Context, l logic.Logic, name string, resolved *[]ResolvedName) (*models.Entity, *mcp.ToolResponse, error) {
+	entity, err := l.ResolveEntity(ctx, name)
+	switch {
+	case errors.Is(err, logic.ErrNotFound):
+		notFound, err := notFoundResponse(ctx, l, name)
+		return nil, notFound, err
+	case errors.Is(err, logic.ErrAmbiguousName):
+		return nil, mcp.NewToolResponse(mcp.NewTextContent(fmt.Sprintf("The name %s matches more than one entity, use the exact name", name))), nil
+	case err != nil:
+		return nil, nil, err
+	}
+
+	if entity.Name != name {
+		via := "alias"
+		if strings.EqualFold(strings.TrimSpace(name), entity.Name) {
+			via = "case"
+		}
+		*resolved = append(*resolved, ResolvedName{
+			Name:       name,
+			EntityName: entity.Name,
+			Via:        via,
+		})
+	}
+
+	return entity, nil, nil
+}
+
+// notFoundResponse says name wasn't found, suggesting similar entities if there are any.
+func notFoundResponse(ctx context.Context, l logic.Logic, name string) (*mcp.ToolResponse, error) {
+	response := mcp.NewToolResponse(mcp.NewTextContent(fmt.Sprintf("The entity %s was not found", name)))
+
+	suggestions, err := suggestEntities(ctx, l, name, notFoundSuggestions)
+	if err != nil {
+		// suggestions are a nicety, don't fail the tool call over them
+		logging.L(ctx).Warn("Can't suggest entities", zap.Error(err), zap.String("entity_name", name))
+		return response, nil
+	}
+	if len(suggestions) == 0 {
+		return response, nil
+	}
+
+	suggestionsResponse, err := util.ToolJSONResponse(ctx, DidYouMeanResp{DidYouMean: suggestions})
+	if err != nil {
+		return nil, err
+	}
+	response.Content = append(response.Content, suggestionsResponse.Content...)
+
+	return response, nil
+}
+
+// suggestEntities returns up to limit entities similar to name in response format.
+func suggestEntities(ctx context.Context, l logic.Logic, name string, limit int) ([]Suggestion, error) {
+	suggestions, err := l.SuggestEntities(ctx, name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]Suggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		response = append(response, Suggestion{
+			Name:  suggestion.Entity.Name,
+			Type:  suggestion.Entity.Type,
+			Alias: suggestion.Alias,
+			Score: math.Round(suggestion.Score*1000) / 1000,
+		})
+	}
+
+	return response, nil
+}
+
+// withResolvedNames adds the names that were resolved to a different canonical entity to response.
+func withResolvedNames(ctx context.Context, response *mcp.ToolResponse, resolved []ResolvedName) (*mcp.ToolResponse, error) {
+	if len(resolved) == 0 {
+		return response, nil
+	}
+
+	resolvedResponse, err := util.ToolJSONResponse(ctx, ResolvedNamesResp{ResolvedNames: resolved})
+	if err != nil {
+		return nil, err
+	}
+	response.Content = append(response.Content, resolvedResponse.Content...)
+
+	return response, nil
+}
+
+// dedupeSymmetric drops the second direction of symmetric relations, they're stored both ways but mean the same.
+func dedupeSymmetric(l logic.Logic, relations []*models.Relation) []*models.Relation {
+	o, _ := l.ReadOntology()
+	if o == nil {
+		return relations
+	}
+
+	type edge struct {
+		a, b         int64
+		relationType string
+	}
+	seen := make(map[edge]bool)
+	deduped := make([]*models.Relation, 0, len(relations))
+	for _, relation := range relations {
+		if o.IsSymmetric(relation.Type) {
+			e := edge{a: min(relation.FromID, relation.ToID), b: max(relation.FromID, relation.ToID), relationType: relation.Type}
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+		}
+		deduped = append(deduped, relation)
+	}
+
+	return deduped
+}
+
+// appendOntologyWarnings adds the violations of subject, an entity name or relation, to warnings.
+func appendOntologyWarnings(warnings []OntologyWarning, subject string, violations []*ontology.Violation) []OntologyWarning {
+	for _, violation := range violations {
+		warnings = append(warnings, OntologyWarning{
+			Subject: subject,
+			Kind:    string(violation.Kind),
+			Message: violation.Message,
+		})
+	}
+
+	return warnings
+}
+
+// withOntologyWarnings adds the ontology violations that were allowed through to response.
+func withOntologyWarnings(ctx context.Context, response *mcp.ToolResponse, warnings []OntologyWarning) (*mcp.ToolResponse, error) {
+	if len(warnings) == 0 {
+		return response, nil
+	}
+
+	warningsResponse, err := util.ToolJSONResponse(ctx, OntologyWarningsResp{OntologyWarnings: warnings})
+	if err != nil {
+		return nil, err
+	}
+	response.Content = append(response.Content, warningsResponse.Content...)
+
+	return response, nil
+}
+
+// errorClass sorts err into a class few enough to label metrics with.
+func errorClass(err error) string {
+	var collision *logic.AliasCollisionError
+	var violation *logic.OntologyViolationError
+
+	switch {
+	case errors.Is(err, logic.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, logic.ErrAlreadyExists), errors.As(err, &collision):
+		return "already_exists"
+	case errors.Is(err, logic.ErrForeignKeyViolation),
+		errors.Is(err, logic.ErrNotNullViolation),
+		errors.Is(err, logic.ErrCheckViolation):
+		return "constraint"
+	case errors.Is(err, logic.ErrConflict):
+		return "conflict"
+	case errors.Is(err, logic.ErrUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.Is(err, logic.ErrAmbiguousName),
+		errors.Is(err, logic.ErrEmptyAlias),
+		errors.Is(err, logic.ErrNoRules),
+		errors.Is(err, logic.ErrNoOntology),
+		errors.As(err, &violation):
+		return "invalid"
+	default:
+		return "internal"
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	mcp "github.com/metoro-io/mcp-golang"
+	"github.com/tyrm/mcp-dbmem/internal/analytics"
 	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
 	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/metrics"
 	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
 	"github.com/tyrm/mcp-dbmem/internal/util"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
@@ -18,17 +23,33 @@ import (
 var directTracer = otel.Tracer("internal/adapter.DirectAdapter")
 var directTracerAttrs []trace.SpanStartOption
 
+// defaultSuggestions is how many entities suggest_entities returns if no limit is given.
+const defaultSuggestions = 5
+
 type DirectAdapter struct {
-	logic logic.Logic
+	logic   logic.Logic
+	dryRun  bool
+	metrics *metrics.Metrics
+}
+
+// DirectAdapterConfig configures a DirectAdapter.
+type DirectAdapterConfig struct {
+	Logic logic.Logic
+	// DryRun makes every mutating tool a dry run.
+	DryRun bool
+	// Metrics records the tool calls, nil disables it.
+	Metrics *metrics.Metrics
 }
 
 func (d *DirectAdapter) Apply(server *mcp.Server) error {
-	return apply(d, server)
+	return apply(d, server, d.metrics)
 }
 
-func NewDirectAdapter(logic logic.Logic) *DirectAdapter {
+func NewDirectAdapter(cfg DirectAdapterConfig) *DirectAdapter {
 	return &DirectAdapter{
-		logic: logic,
+		logic:   cfg.Logic,
+		dryRun:  cfg.DryRun,
+		metrics: cfg.Metrics,
 	}
 }
 
@@ -36,6 +57,15 @@ func (d *DirectAdapter) CreateEntities(ctx context.Context, args CreateEntitiesA
 	ctx, span := directTracer.Start(ctx, "CreateEntities", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.createEntities(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) createEntities(ctx context.Context, l logic.Logic, args CreateEntitiesArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	warnings := make([]OntologyWarning, 0)
 	response := make([]Entity, 0)
 	for _, entity := range args.Entities {
 		// Process each entity
@@ -43,8 +73,30 @@ func (d *DirectAdapter) CreateEntities(ctx context.Context, args CreateEntitiesA
 			Name: entity.Name,
 			Type: entity.Type,
 		}
-		if err := d.logic.CreateEntity(ctx, newEntity); err != nil {
-			zap.L().Error("Can't create entity from database", zap.Error(err), zap.Any("entity", newEntity))
+		if _, strictness := l.ReadOntology(); strictness == ontology.StrictnessWarn {
+			violations, err := l.ValidateEntity(ctx, newEntity)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			warnings = appendOntologyWarnings(warnings, entity.Name, violations)
+		}
+
+		err := l.CreateEntity(ctx, newEntity)
+		var collision *logic.AliasCollisionError
+		var violation *logic.OntologyViolationError
+		switch {
+		case errors.As(err, &collision):
+			return mcp.NewToolResponse(
+				mcp.NewTextContent(fmt.Sprintf("Can't create entity %s, %s", entity.Name, collision.Error())),
+			), nil
+		case errors.As(err, &violation):
+			return mcp.NewToolResponse(
+				mcp.NewTextContent(fmt.Sprintf("Can't create entity %s, %s", entity.Name, violation.Error())),
+			), nil
+		}
+		if err != nil {
+			logging.L(ctx).Error("Can't create entity from database", zap.Error(err), zap.String("entity_name", newEntity.Name))
 			span.RecordError(err)
 			return nil, err
 		}
@@ -59,8 +111,8 @@ func (d *DirectAdapter) CreateEntities(ctx context.Context, args CreateEntitiesA
 				EntityID: newEntity.ID,
 				Contents: observation,
 			}
-			if err := d.logic.CreateObservation(ctx, newObservation); err != nil {
-				zap.L().Error("Can't create observation in database", zap.Error(err), zap.Any("observation", newObservation))
+			if err := l.CreateObservation(ctx, newObservation); err != nil {
+				logging.L(ctx).Error("Can't create observation in database", zap.Error(err), zap.Int64("entity_id", newObservation.EntityID), logging.Contents("content", newObservation.Contents))
 				span.RecordError(err)
 				return nil, err
 			}
@@ -74,64 +126,86 @@ func (d *DirectAdapter) CreateEntities(ctx context.Context, args CreateEntitiesA
 	// convert response to json string
 	toolResponse, err := util.ToolJSONResponse(ctx, response)
 	if err != nil {
-		zap.L().Error("Can't marshal response json", zap.Error(err))
+		logging.L(ctx).Error("Can't marshal response json", zap.Error(err))
 		span.RecordError(err)
 		return nil, err
 	}
 
-	return toolResponse, nil
+	return withOntologyWarnings(ctx, toolResponse, warnings)
 }
 
 func (d *DirectAdapter) DeleteEntities(ctx context.Context, args DeleteEntitiesArgs) (*mcp.ToolResponse, error) {
 	ctx, span := directTracer.Start(ctx, "DeleteEntities", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.deleteEntities(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) deleteEntities(ctx context.Context, l logic.Logic, args DeleteEntitiesArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	resolved := make([]ResolvedName, 0)
 	for _, entityName := range args.EntityNames {
 		// Process each entity
-		entity, err := d.logic.ReadEntityByName(ctx, entityName)
+		entity, notFound, err := resolveEntity(ctx, l, entityName, &resolved)
 		if err != nil {
-			zap.L().Error("Can't read entity from database", zap.Error(err), zap.String("entityName", entityName))
+			logging.L(ctx).Error("Can't read entity from database", zap.Error(err), zap.String("entityName", entityName))
 			span.RecordError(err)
 			return nil, err
 		}
-		if entity == nil {
-			zap.L().Warn("Entity not found in database", zap.String("entityName", entityName))
+		if notFound != nil {
+			logging.L(ctx).Warn("Entity not found in database", zap.String("entityName", entityName))
 			continue
 		}
 
-		if err := d.logic.DeleteAllObservationsByEntityID(ctx, entity.ID); err != nil {
-			zap.L().Error("Can't delete observations", zap.Error(err), zap.String("entityName", entityName))
-			span.RecordError(err)
-			return nil, err
-		}
-
-		if err := d.logic.DeleteEntity(ctx, entity); err != nil {
-			zap.L().Error("Can't delete entity", zap.Error(err), zap.String("entityName", entityName))
+		// observations and relations are deleted along with the entity
+		if err := l.DeleteEntity(ctx, entity); err != nil {
+			logging.L(ctx).Error("Can't delete entity", zap.Error(err), zap.String("entityName", entityName))
 			span.RecordError(err)
 			return nil, err
 		}
 	}
 
-	return mcp.NewToolResponse(
+	return withResolvedNames(ctx, mcp.NewToolResponse(
 		mcp.NewTextContent("Entities deleted successfully"),
-	), nil
+	), resolved)
 }
 
-func (d *DirectAdapter) ReadGraph(ctx context.Context, _ ReadGraphArgs) (*mcp.ToolResponse, error) {
+func (d *DirectAdapter) ReadGraph(ctx context.Context, args ReadGraphArgs) (*mcp.ToolResponse, error) {
 	ctx, span := directTracer.Start(ctx, "ReadGraph", directTracerAttrs...)
 	defer span.End()
 
-	// Read entities
-	zap.L().Debug("Reading all entities from the database")
-	entities, err := d.logic.ReadAllEntities(ctx)
-	if err != nil && !errors.Is(err, logic.ErrNotFound) {
-		zap.L().Error("Can't read entities from the database", zap.Error(err))
-		span.RecordError(err)
-		return nil, err
+	var entities []*models.Entity
+	var relations []*models.Relation
+	if args.AsOf != "" {
+		asOf, err := time.Parse(time.RFC3339, args.AsOf)
+		if err != nil {
+			return mcp.NewToolResponse(
+				mcp.NewTextContent(fmt.Sprintf("asOf %s is not a RFC 3339 timestamp", args.AsOf)),
+			), nil
+		}
+
+		// Reconstruct the graph from the change journal
+		logging.L(ctx).Debug("Reading graph as of", zap.Time("as_of", asOf))
+		entities, relations, err = d.logic.ReadGraphAsOf(ctx, asOf)
+		if err != nil {
+			logging.L(ctx).Error("Can't reconstruct graph", zap.Error(err))
+			span.RecordError(err)
+			return nil, err
+		}
+	} else {
+		var err error
+		entities, relations, err = d.readGraph(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
 	}
 
 	// Convert entities to response format
-	zap.L().Debug("Converting entities to response format", zap.Any("entities", entities))
+	logging.L(ctx).Debug("Converting entities to response format", zap.Int("entities", len(entities)))
 	entitiesResponse := make([]Entity, 0)
 	for _, entity := range entities {
 		newEntity := Entity{
@@ -145,16 +219,9 @@ func (d *DirectAdapter) ReadGraph(ctx context.Context, _ ReadGraphArgs) (*mcp.To
 		entitiesResponse = append(entitiesResponse, newEntity)
 	}
 
-	// Read relations
-	zap.L().Debug("Reading all relations from the database")
-	relations, err := d.logic.ReadAllRelations(ctx)
-	if err != nil && !errors.Is(err, logic.ErrNotFound) {
-		span.RecordError(err)
-		return nil, err
-	}
-
 	// Convert relations to response format
-	zap.L().Debug("Converting relations to response format", zap.Any("relations", relations))
+	relations = dedupeSymmetric(d.logic, relations)
+	logging.L(ctx).Debug("Converting relations to response format", zap.Int("relations", len(relations)))
 	relationsResponse := make([]Relation, 0)
 	for _, relation := range relations {
 		newRelation := Relation{
@@ -166,14 +233,13 @@ func (d *DirectAdapter) ReadGraph(ctx context.Context, _ ReadGraphArgs) (*mcp.To
 	}
 
 	// Create the knowledge graph
-	zap.L().Debug("Creating knowledge graph", zap.Int("entities", len(entitiesResponse)), zap.Int("relations", len(relationsResponse)))
+	logging.L(ctx).Debug("Creating knowledge graph", zap.Int("entities", len(entitiesResponse)), zap.Int("relations", len(relationsResponse)))
 	graph := KnowledgeGraph{
 		Entities:  entitiesResponse,
 		Relations: relationsResponse,
 	}
 
 	// Convert response to json string
-	zap.L().Debug("Converting knowledge graph to JSON", zap.Any("knowledge_graph", graph))
 	jsonResponse, err := util.ToolJSONResponse(ctx, graph)
 	if err != nil {
 		span.RecordError(err)
@@ -182,13 +248,98 @@ func (d *DirectAdapter) ReadGraph(ctx context.Context, _ ReadGraphArgs) (*mcp.To
 	return jsonResponse, nil
 }
 
+func (d *DirectAdapter) readGraph(ctx context.Context) ([]*models.Entity, []*models.Relation, error) {
+	// Read entities
+	logging.L(ctx).Debug("Reading all entities from the database")
+	entities, err := d.logic.ReadAllEntities(ctx)
+	if err != nil && !errors.Is(err, logic.ErrNotFound) {
+		logging.L(ctx).Error("Can't read entities from the database", zap.Error(err))
+		return nil, nil, err
+	}
+
+	// Read relations
+	logging.L(ctx).Debug("Reading all relations from the database")
+	relations, err := d.logic.ReadAllRelations(ctx)
+	if err != nil && !errors.Is(err, logic.ErrNotFound) {
+		logging.L(ctx).Error("Can't read relations from the database", zap.Error(err))
+		return nil, nil, err
+	}
+
+	return entities, relations, nil
+}
+
 func (d *DirectAdapter) OpenNodes(ctx context.Context, args OpenNodesArgs) (*mcp.ToolResponse, error) {
-	_, span := directTracer.Start(ctx, "OpenNodes", directTracerAttrs...)
+	ctx, span := directTracer.Start(ctx, "OpenNodes", directTracerAttrs...)
 	defer span.End()
 
-	return mcp.NewToolResponse(
-		mcp.NewTextContent("Open Nodes not implemented yet"),
-	), nil
+	resolved := make([]ResolvedName, 0)
+	response := OpenNodesResp{
+		Entities:  make([]Entity, 0, len(args.Names)),
+		Relations: make([]Relation, 0),
+	}
+	opened := make(map[int64]bool, len(args.Names))
+	for _, name := range args.Names {
+		entity, notFound, err := resolveEntity(ctx, d.logic, name, &resolved)
+		switch {
+		case err != nil:
+			logging.L(ctx).Error("Can't read entity from database", zap.Error(err), zap.String("entity_name", name))
+			span.RecordError(err)
+			return nil, err
+		case notFound != nil:
+			response.NotFound = append(response.NotFound, name)
+			suggestions, err := suggestEntities(ctx, d.logic, name, notFoundSuggestions)
+			if err != nil {
+				logging.L(ctx).Warn("Can't suggest entities", zap.Error(err), zap.String("entity_name", name))
+			}
+			if len(suggestions) > 0 {
+				if response.DidYouMean == nil {
+					response.DidYouMean = make(map[string][]Suggestion)
+				}
+				response.DidYouMean[name] = suggestions
+			}
+			continue
+		case opened[entity.ID]:
+			continue
+		}
+		opened[entity.ID] = true
+
+		openedEntity := Entity{
+			Name:         entity.Name,
+			Type:         entity.Type,
+			Observations: make([]string, 0, len(entity.Observations)),
+		}
+		for _, observation := range entity.Observations {
+			openedEntity.Observations = append(openedEntity.Observations, observation.Contents)
+		}
+		response.Entities = append(response.Entities, openedEntity)
+	}
+
+	// only relations between the opened entities are included
+	relations, err := d.logic.ReadAllRelations(ctx)
+	if err != nil && !errors.Is(err, logic.ErrNotFound) {
+		logging.L(ctx).Error("Can't read relations from the database", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+	for _, relation := range dedupeSymmetric(d.logic, relations) {
+		if opened[relation.FromID] && opened[relation.ToID] {
+			response.Relations = append(response.Relations, Relation{
+				From: relation.From.Name,
+				To:   relation.To.Name,
+				Type: relation.Type,
+			})
+		}
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return withResolvedNames(ctx, toolResponse, resolved)
 }
 
 func (d *DirectAdapter) SearchNodes(ctx context.Context, args SearchNodesArgs) (*mcp.ToolResponse, error) {
@@ -204,19 +355,27 @@ func (d *DirectAdapter) AddObservations(ctx context.Context, args AddObservation
 	ctx, span := directTracer.Start(ctx, "AddObservations", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.addObservations(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) addObservations(ctx context.Context, l logic.Logic, args AddObservationsArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	resolved := make([]ResolvedName, 0)
 	response := make([]AddedObservationsResp, 0, len(args.Observations))
 	for _, observation := range args.Observations {
-		entity, err := d.logic.ReadEntityByName(ctx, observation.EntityName)
-		switch {
-		case err != nil && !errors.Is(err, db.ErrNoEntries):
-			zap.L().Error("Failed to read entity by name", zap.Error(err), zap.String("entity_name", observation.EntityName))
-			span.RecordError(err)
-			return nil, err
-		case errors.Is(err, db.ErrNoEntries):
-			return mcp.NewToolResponse(mcp.NewTextContent(fmt.Sprintf("The entity %s was not found", observation.EntityName))), nil
+		entity, notFound, err := resolveEntity(ctx, l, observation.EntityName, &resolved)
+		if entity == nil {
+			if err != nil {
+				logging.L(ctx).Error("Failed to read entity by name", zap.Error(err), zap.String("entity_name", observation.EntityName))
+				span.RecordError(err)
+			}
+			return notFound, err
 		}
 		newResponse := AddedObservationsResp{
-			EntityName: observation.EntityName,
+			EntityName: entity.Name,
 		}
 
 		for _, content := range observation.Contents {
@@ -225,8 +384,8 @@ func (d *DirectAdapter) AddObservations(ctx context.Context, args AddObservation
 				Contents: content,
 			}
 
-			if err := d.logic.CreateObservation(ctx, newObservation); err != nil {
-				zap.L().Error("Failed to create observation", zap.Error(err), zap.String("entity_name", observation.EntityName), zap.String("content", content))
+			if err := l.CreateObservation(ctx, newObservation); err != nil {
+				logging.L(ctx).Error("Failed to create observation", zap.Error(err), zap.String("entity_name", observation.EntityName), logging.Contents("content", content))
 				span.RecordError(err)
 				return nil, err
 			}
@@ -239,82 +398,116 @@ func (d *DirectAdapter) AddObservations(ctx context.Context, args AddObservation
 	// convert response to json string
 	toolResponse, err := util.ToolJSONResponse(ctx, response)
 	if err != nil {
-		zap.L().Error("json marshal error", zap.Error(err))
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
 		span.RecordError(err)
 		return nil, err
 	}
 
-	return toolResponse, nil
+	return withResolvedNames(ctx, toolResponse, resolved)
 }
 
 func (d *DirectAdapter) DeleteObservations(ctx context.Context, args DeleteObservationsArgs) (*mcp.ToolResponse, error) {
 	ctx, span := directTracer.Start(ctx, "DeleteObservations", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.deleteObservations(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) deleteObservations(ctx context.Context, l logic.Logic, args DeleteObservationsArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	resolved := make([]ResolvedName, 0)
 	for _, observation := range args.Deletions {
-		entity, err := d.logic.ReadEntityByName(ctx, observation.EntityName)
-		switch {
-		case err != nil && !errors.Is(err, db.ErrNoEntries):
-			zap.L().Error("Failed to read entity by name", zap.Error(err), zap.String("entity_name", observation.EntityName))
-			span.RecordError(err)
-			return nil, err
-		case errors.Is(err, db.ErrNoEntries):
-			return mcp.NewToolResponse(mcp.NewTextContent(fmt.Sprintf("The entity %s was not found", observation.EntityName))), nil
+		entity, notFound, err := resolveEntity(ctx, l, observation.EntityName, &resolved)
+		if entity == nil {
+			if err != nil {
+				logging.L(ctx).Error("Failed to read entity by name", zap.Error(err), zap.String("entity_name", observation.EntityName))
+				span.RecordError(err)
+			}
+			return notFound, err
 		}
 
 		for _, content := range observation.Observations {
 			// Read the observation by text
-			observationToDelete, err := d.logic.ReadObservationByTextForEntityID(ctx, entity.ID, content)
+			observationToDelete, err := l.ReadObservationByTextForEntityID(ctx, entity.ID, content)
 			if err != nil {
 				if errors.Is(err, db.ErrNoEntries) {
 					// Observation not found, continue to the next one
-					zap.L().Debug("Observation not found, skipping deletion", zap.String("entity_name", observation.EntityName), zap.String("content", content))
+					logging.L(ctx).Debug("Observation not found, skipping deletion", zap.String("entity_name", observation.EntityName), logging.Contents("content", content))
 					continue
 				}
-				zap.L().Error("Failed to read observation by text", zap.Error(err), zap.String("entity_name", observation.EntityName), zap.String("content", content))
+				logging.L(ctx).Error("Failed to read observation by text", zap.Error(err), zap.String("entity_name", observation.EntityName), logging.Contents("content", content))
 				span.RecordError(err)
 				return nil, err
 			}
 
 			// Delete the observation
-			zap.L().Debug("Deleting observation", zap.Int64("id", observationToDelete.ID), zap.String("content", content))
-			if err := d.logic.DeleteObservation(ctx, observationToDelete); err != nil {
-				zap.L().Error("Failed to delete observation", zap.Error(err), zap.Int64("id", observationToDelete.ID), zap.String("content", content))
+			logging.L(ctx).Debug("Deleting observation", zap.Int64("id", observationToDelete.ID), logging.Contents("content", content))
+			if err := l.DeleteObservation(ctx, observationToDelete); err != nil {
+				logging.L(ctx).Error("Failed to delete observation", zap.Error(err), zap.Int64("id", observationToDelete.ID), logging.Contents("content", content))
 				span.RecordError(err)
 				return nil, err
 			}
 		}
 	}
 
-	return mcp.NewToolResponse(
+	return withResolvedNames(ctx, mcp.NewToolResponse(
 		mcp.NewTextContent("Observations deleted successfully"),
-	), nil
+	), resolved)
 }
 
 func (d *DirectAdapter) CreateRelations(ctx context.Context, args CreateRelationsArgs) (*mcp.ToolResponse, error) {
 	ctx, span := directTracer.Start(ctx, "CreateRelations", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.createRelations(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) createRelations(ctx context.Context, l logic.Logic, args CreateRelationsArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	warnings := make([]OntologyWarning, 0)
+	resolved := make([]ResolvedName, 0)
 	response := make([]Relation, 0, len(args.Relations))
 	for _, relation := range args.Relations {
-		entityFrom, err := d.logic.ReadEntityByName(ctx, relation.From)
-		if err != nil {
-			return nil, err
+		entityFrom, notFound, err := resolveEntity(ctx, l, relation.From, &resolved)
+		if entityFrom == nil {
+			return notFound, err
 		}
-		zap.L().Debug("got from entity", zap.Any("entity", entityFrom))
+		logging.L(ctx).Debug("got from entity", zap.Int64("entity_id", entityFrom.ID), zap.String("entity_name", entityFrom.Name))
 
-		entityTo, err := d.logic.ReadEntityByName(ctx, relation.To)
-		if err != nil {
-			return nil, err
+		entityTo, notFound, err := resolveEntity(ctx, l, relation.To, &resolved)
+		if entityTo == nil {
+			return notFound, err
 		}
-		zap.L().Debug("got to entity", zap.Any("entity", entityTo))
+		logging.L(ctx).Debug("got to entity", zap.Int64("entity_id", entityTo.ID), zap.String("entity_name", entityTo.Name))
 
 		newRelation := &models.Relation{
 			FromID: entityFrom.ID,
 			ToID:   entityTo.ID,
 			Type:   relation.Type,
 		}
-		if err := d.logic.CreateRelation(ctx, newRelation); err != nil {
+		if _, strictness := l.ReadOntology(); strictness == ontology.StrictnessWarn {
+			violations, err := l.ValidateRelation(ctx, newRelation)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			warnings = appendOntologyWarnings(warnings, fmt.Sprintf("%s %s %s", entityFrom.Name, relation.Type, entityTo.Name), violations)
+		}
+
+		err = l.CreateRelation(ctx, newRelation)
+		var violation *logic.OntologyViolationError
+		if errors.As(err, &violation) {
+			return mcp.NewToolResponse(
+				mcp.NewTextContent(fmt.Sprintf("Can't create relation %s %s %s, %s", entityFrom.Name, relation.Type, entityTo.Name, violation.Error())),
+			), nil
+		}
+		if err != nil {
 			return nil, err
 		}
 
@@ -328,64 +521,562 @@ func (d *DirectAdapter) CreateRelations(ctx context.Context, args CreateRelation
 	// convert response to json string
 	toolResponse, err := util.ToolJSONResponse(ctx, response)
 	if err != nil {
-		zap.L().Error("json marshal error", zap.Error(err))
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
 		span.RecordError(err)
 		return nil, err
 	}
 
-	return toolResponse, err
+	toolResponse, err = withOntologyWarnings(ctx, toolResponse, warnings)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 
+	return withResolvedNames(ctx, toolResponse, resolved)
 }
 
 func (d *DirectAdapter) DeleteRelations(ctx context.Context, args DeleteRelationsArgs) (*mcp.ToolResponse, error) {
 	ctx, span := directTracer.Start(ctx, "DeleteRelations", directTracerAttrs...)
 	defer span.End()
 
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.deleteRelations(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) deleteRelations(ctx context.Context, l logic.Logic, args DeleteRelationsArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	resolved := make([]ResolvedName, 0)
 	for _, relation := range args.Relations {
-		entityFrom, err := d.logic.ReadEntityByName(ctx, relation.From)
+		entityFrom, notFound, err := resolveEntity(ctx, l, relation.From, &resolved)
 		switch {
-		case errors.Is(err, db.ErrNoEntries):
-			zap.L().Debug("entity not found", zap.String("entity", relation.From), zap.String("position", "from"))
-			return mcp.NewToolResponse(
-				mcp.NewTextContent(fmt.Sprintf("Entity %s was not found", relation.From)),
-			), nil
 		case err != nil:
-			zap.L().Error("read from entity error", zap.Error(err))
+			logging.L(ctx).Error("read from entity error", zap.Error(err))
 			span.RecordError(err)
 			return nil, err
+		case notFound != nil:
+			logging.L(ctx).Debug("entity not found", zap.String("entity", relation.From), zap.String("position", "from"))
+			return notFound, nil
 		default:
-			zap.L().Debug("got from entity", zap.Any("entity", entityFrom))
+			logging.L(ctx).Debug("got from entity", zap.Int64("entity_id", entityFrom.ID), zap.String("entity_name", entityFrom.Name))
 		}
 
-		entityTo, err := d.logic.ReadEntityByName(ctx, relation.To)
+		entityTo, notFound, err := resolveEntity(ctx, l, relation.To, &resolved)
 		switch {
-		case errors.Is(err, db.ErrNoEntries):
-			zap.L().Debug("entity not found", zap.String("entity", relation.To), zap.String("position", "to"))
-			return mcp.NewToolResponse(
-				mcp.NewTextContent(fmt.Sprintf("Entity %s was not found", relation.To)),
-			), nil
 		case err != nil:
-			zap.L().Error("read to entity error", zap.Error(err))
+			logging.L(ctx).Error("read to entity error", zap.Error(err))
 			span.RecordError(err)
 			return nil, err
+		case notFound != nil:
+			logging.L(ctx).Debug("entity not found", zap.String("entity", relation.To), zap.String("position", "to"))
+			return notFound, nil
 		default:
-			zap.L().Debug("got to entity", zap.Any("entity", entityFrom))
+			logging.L(ctx).Debug("got to entity", zap.Int64("entity_id", entityTo.ID), zap.String("entity_name", entityTo.Name))
 		}
 
 		// find the relation
-		existingRelation, err := d.logic.ReadExactRelation(ctx, entityFrom.ID, entityTo.ID, relation.Type)
+		existingRelation, err := l.ReadExactRelation(ctx, entityFrom.ID, entityTo.ID, relation.Type)
 		if err != nil {
 			return nil, err
 		}
 
-		if err := d.logic.DeleteRelation(ctx, existingRelation); err != nil {
+		if err := l.DeleteRelation(ctx, existingRelation); err != nil {
 			return nil, err
 		}
 	}
 
-	return mcp.NewToolResponse(
+	return withResolvedNames(ctx, mcp.NewToolResponse(
 		mcp.NewTextContent("Relations deleted successfully"),
-	), nil
+	), resolved)
+}
+
+func (d *DirectAdapter) ListTrash(ctx context.Context, _ ListTrashArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "ListTrash", directTracerAttrs...)
+	defer span.End()
+
+	entities, observations, relations, err := d.logic.ReadTrash(ctx)
+	if err != nil {
+		logging.L(ctx).Error("Can't read trash", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	response := Trash{
+		Entities:     make([]TrashedEntity, 0, len(entities)),
+		Observations: make([]TrashedObservation, 0, len(observations)),
+		Relations:    make([]TrashedRelation, 0, len(relations)),
+	}
+	for _, entity := range entities {
+		trashedEntity := TrashedEntity{
+			Name:         entity.Name,
+			Type:         entity.Type,
+			Observations: make([]string, 0, len(entity.Observations)),
+			DeletedAt:    entity.DeletedAt,
+		}
+		for _, observation := range entity.Observations {
+			trashedEntity.Observations = append(trashedEntity.Observations, observation.Contents)
+		}
+		response.Entities = append(response.Entities, trashedEntity)
+	}
+	for _, observation := range observations {
+		response.Observations = append(response.Observations, TrashedObservation{
+			EntityName: observation.Entity.Name,
+			Contents:   observation.Contents,
+			DeletedAt:  observation.DeletedAt,
+		})
+	}
+	for _, relation := range relations {
+		response.Relations = append(response.Relations, TrashedRelation{
+			From:      relation.From.Name,
+			To:        relation.To.Name,
+			Type:      relation.Type,
+			DeletedAt: relation.DeletedAt,
+		})
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) RestoreEntities(ctx context.Context, args RestoreEntitiesArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "RestoreEntities", directTracerAttrs...)
+	defer span.End()
+
+	response := RestoreEntitiesResp{
+		Entities:  make([]Entity, 0, len(args.EntityNames)),
+		Relations: make([]Relation, 0),
+	}
+	for _, entityName := range args.EntityNames {
+		entity, relations, err := d.logic.RestoreEntityByName(ctx, entityName)
+		switch {
+		case errors.Is(err, logic.ErrNotFound):
+			logging.L(ctx).Debug("entity not found in trash", zap.String("entity_name", entityName))
+			response.NotFound = append(response.NotFound, entityName)
+			continue
+		case err != nil:
+			logging.L(ctx).Error("Can't restore entity", zap.Error(err), zap.String("entity_name", entityName))
+			span.RecordError(err)
+			return nil, err
+		}
+
+		restoredEntity := Entity{
+			Name:         entity.Name,
+			Type:         entity.Type,
+			Observations: make([]string, 0, len(entity.Observations)),
+		}
+		for _, observation := range entity.Observations {
+			restoredEntity.Observations = append(restoredEntity.Observations, observation.Contents)
+		}
+		response.Entities = append(response.Entities, restoredEntity)
+
+		for _, relation := range relations {
+			response.Relations = append(response.Relations, Relation{
+				From: relation.From.Name,
+				To:   relation.To.Name,
+				Type: relation.Type,
+			})
+		}
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) AddAliases(ctx context.Context, args AddAliasesArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "AddAliases", directTracerAttrs...)
+	defer span.End()
+
+	resolved := make([]ResolvedName, 0)
+	response := make([]EntityAliasesResp, 0, len(args.Aliases))
+	for _, entityAliases := range args.Aliases {
+		entity, notFound, err := resolveEntity(ctx, d.logic, entityAliases.EntityName, &resolved)
+		if entity == nil {
+			if err != nil {
+				logging.L(ctx).Error("Failed to read entity by name", zap.Error(err), zap.String("entity_name", entityAliases.EntityName))
+				span.RecordError(err)
+			}
+			return notFound, err
+		}
+
+		newResponse := EntityAliasesResp{
+			EntityName: entity.Name,
+		}
+		for _, alias := range entityAliases.Aliases {
+			_, err := d.logic.AddEntityAlias(ctx, entity, alias)
+			var collision *logic.AliasCollisionError
+			switch {
+			case errors.As(err, &collision), errors.Is(err, logic.ErrEmptyAlias):
+				logging.L(ctx).Debug("alias rejected", zap.Error(err), zap.String("entity_name", entity.Name), zap.String("alias", alias))
+				newResponse.Rejected = append(newResponse.Rejected, RejectedAlias{
+					Alias:  alias,
+					Reason: err.Error(),
+				})
+			case err != nil:
+				logging.L(ctx).Error("Failed to add alias", zap.Error(err), zap.String("entity_name", entity.Name), zap.String("alias", alias))
+				span.RecordError(err)
+				return nil, err
+			}
+		}
+
+		aliases, err := d.logic.ReadEntityAliases(ctx, entity.ID)
+		if err != nil {
+			logging.L(ctx).Error("Failed to read aliases", zap.Error(err), zap.String("entity_name", entity.Name))
+			span.RecordError(err)
+			return nil, err
+		}
+		newResponse.Aliases = make([]string, 0, len(aliases))
+		for _, alias := range aliases {
+			newResponse.Aliases = append(newResponse.Aliases, alias.Alias)
+		}
+
+		response = append(response, newResponse)
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return withResolvedNames(ctx, toolResponse, resolved)
+}
+
+func (d *DirectAdapter) RemoveAliases(ctx context.Context, args RemoveAliasesArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "RemoveAliases", directTracerAttrs...)
+	defer span.End()
+
+	response := RemoveAliasesResp{
+		Removed: make([]RemovedAlias, 0, len(args.Aliases)),
+	}
+	for _, alias := range args.Aliases {
+		removed, err := d.logic.RemoveEntityAlias(ctx, alias)
+		switch {
+		case errors.Is(err, logic.ErrNotFound):
+			logging.L(ctx).Debug("alias not found", zap.String("alias", alias))
+			response.NotFound = append(response.NotFound, alias)
+			continue
+		case err != nil:
+			logging.L(ctx).Error("Failed to remove alias", zap.Error(err), zap.String("alias", alias))
+			span.RecordError(err)
+			return nil, err
+		}
+
+		response.Removed = append(response.Removed, RemovedAlias{
+			Alias:      removed.Alias,
+			EntityName: removed.Entity.Name,
+		})
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) SuggestEntities(ctx context.Context, args SuggestEntitiesArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "SuggestEntities", directTracerAttrs...)
+	defer span.End()
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+
+	response, err := suggestEntities(ctx, d.logic, args.Name, limit)
+	if err != nil {
+		logging.L(ctx).Error("Can't suggest entities", zap.Error(err), zap.String("name", args.Name))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) GetOntology(ctx context.Context, _ GetOntologyArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "GetOntology", directTracerAttrs...)
+	defer span.End()
+
+	o, strictness := d.logic.ReadOntology()
+	if o == nil {
+		return mcp.NewToolResponse(
+			mcp.NewTextContent("No ontology is configured, any entity and relation type is allowed"),
+		), nil
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, GetOntologyResp{
+		Strictness: string(strictness),
+		Ontology:   o,
+	})
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) Infer(ctx context.Context, args InferArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "Infer", directTracerAttrs...)
+	defer span.End()
+
+	if !args.Persist {
+		return d.infer(ctx, d.logic, args)
+	}
+
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.infer(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) infer(ctx context.Context, l logic.Logic, args InferArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	resolved := make([]ResolvedName, 0)
+	query := logic.InferenceQuery{
+		RelationType: args.RelationType,
+	}
+	if args.EntityName != "" {
+		entity, notFound, err := resolveEntity(ctx, l, args.EntityName, &resolved)
+		if entity == nil {
+			return notFound, err
+		}
+		query.EntityID = entity.ID
+	}
+
+	result, err := l.Infer(ctx, query)
+	if errors.Is(err, logic.ErrNoRules) {
+		return mcp.NewToolResponse(
+			mcp.NewTextContent("No inference rules are configured"),
+		), nil
+	}
+	if err != nil {
+		logging.L(ctx).Error("Can't infer relations", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	response := InferResp{
+		Relations: make([]InferredRelation, 0, len(result.Relations)),
+		Truncated: result.Truncated,
+		Persisted: args.Persist,
+	}
+	for _, inferred := range result.Relations {
+		response.Relations = append(response.Relations, InferredRelation{
+			From:     inferred.Relation.From.Name,
+			To:       inferred.Relation.To.Name,
+			Type:     inferred.Relation.Type,
+			Inferred: true,
+			Rule:     inferred.Rule,
+			Depth:    inferred.Depth,
+		})
+
+		if !args.Persist {
+			continue
+		}
+		// the inverse of an earlier relation may already have been created
+		_, err := l.ReadExactRelation(ctx, inferred.Relation.FromID, inferred.Relation.ToID, inferred.Relation.Type)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, logic.ErrNotFound) {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		err = l.CreateRelation(ctx, inferred.Relation)
+		var violation *logic.OntologyViolationError
+		if errors.As(err, &violation) {
+			return mcp.NewToolResponse(
+				mcp.NewTextContent(fmt.Sprintf("Can't persist relation %s %s %s, %s", inferred.Relation.From.Name, inferred.Relation.Type, inferred.Relation.To.Name, violation.Error())),
+			), nil
+		}
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return withResolvedNames(ctx, toolResponse, resolved)
+}
+
+func (d *DirectAdapter) AnalyzeGraph(ctx context.Context, args AnalyzeGraphArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "AnalyzeGraph", directTracerAttrs...)
+	defer span.End()
+
+	report, err := d.logic.AnalyzeGraph(ctx, analytics.Options{Top: args.Top})
+	if err != nil {
+		logging.L(ctx).Error("Can't analyze graph", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, report)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
+}
+
+func (d *DirectAdapter) LintGraph(ctx context.Context, args LintGraphArgs) (*mcp.ToolResponse, error) {
+	ctx, span := directTracer.Start(ctx, "LintGraph", directTracerAttrs...)
+	defer span.End()
+
+	if !args.Fix {
+		return d.lintGraph(ctx, d.logic, args)
+	}
+
+	return d.mutate(ctx, args.DryRun, func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error) {
+		return d.lintGraph(ctx, l, args)
+	})
+}
+
+func (d *DirectAdapter) lintGraph(ctx context.Context, l logic.Logic, args LintGraphArgs) (*mcp.ToolResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	report, err := l.LintGraph(ctx, args.Fix)
+	if err != nil {
+		logging.L(ctx).Error("Can't lint graph", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	response := LintGraphResp{
+		Issues: make([]LintIssue, 0, len(report.Issues)),
+	}
+	for _, issue := range report.Issues {
+		response.Issues = append(response.Issues, LintIssue{
+			Kind:    string(issue.Kind),
+			Message: issue.Message,
+			Fixable: issue.Fixable,
+			Fixed:   issue.Fixed,
+		})
+		if issue.Fixed {
+			response.Fixed++
+		}
+	}
+
+	// convert response to json string
+	toolResponse, err := util.ToolJSONResponse(ctx, response)
+	if err != nil {
+		logging.L(ctx).Error("json marshal error", zap.Error(err))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return toolResponse, nil
 }
 
 var _ Adapter = (*DirectAdapter)(nil)
+
+// mutate runs fn against the logic, or as a dry run when requested by the tool call or the server. A dry run responds
+// with the planned changes followed by the response fn would have given.
+func (d *DirectAdapter) mutate(ctx context.Context, dryRun bool, fn func(ctx context.Context, l logic.Logic) (*mcp.ToolResponse, error)) (*mcp.ToolResponse, error) {
+	if !dryRun && !d.dryRun {
+		return fn(ctx, d.logic)
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	var response *mcp.ToolResponse
+	planned, err := d.logic.DryRun(ctx, func(ctx context.Context, l logic.Logic) error {
+		var err error
+		response, err = fn(ctx, l)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	resp := DryRunResp{
+		DryRun:         true,
+		PlannedChanges: make([]PlannedChange, len(planned)),
+	}
+	for i, change := range planned {
+		resp.PlannedChanges[i] = newPlannedChange(change)
+	}
+
+	dryRunResponse, err := util.ToolJSONResponse(ctx, resp)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if response != nil {
+		dryRunResponse.Content = append(dryRunResponse.Content, response.Content...)
+	}
+
+	return dryRunResponse, nil
+}
+
+func newPlannedChange(change *logic.PlannedChange) PlannedChange {
+	planned := PlannedChange{
+		Action: string(change.Action),
+	}
+	switch {
+	case change.Entity != nil:
+		planned.Kind = string(models.ChangeKindEntity)
+		planned.EntityName = change.Entity.Name
+		planned.EntityType = change.Entity.Type
+	case change.Observation != nil:
+		planned.Kind = string(models.ChangeKindObservation)
+		planned.Contents = change.Observation.Contents
+		if change.Observation.Entity != nil {
+			planned.EntityName = change.Observation.Entity.Name
+		}
+	case change.Relation != nil:
+		planned.Kind = string(models.ChangeKindRelation)
+		planned.RelationType = change.Relation.Type
+		if change.Relation.From != nil {
+			planned.From = change.Relation.From.Name
+		}
+		if change.Relation.To != nil {
+			planned.To = change.Relation.To.Name
+		}
+	}
+
+	return planned
+}
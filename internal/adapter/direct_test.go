@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestDirectAdapter_DeleteEntities_dryRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := bun.New(ctx, bun.ClientConfig{
+		Type:    "sqlite",
+		Address: filepath.Join(t.TempDir(), "test.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	require.NoError(t, client.DoMigration(ctx))
+
+	l := v1.NewLogic(v1.LogicConfig{DB: client})
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	require.NoError(t, l.CreateEntity(ctx, alice))
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, l.CreateEntity(ctx, bob))
+	require.NoError(t, l.CreateObservation(ctx, &models.Observation{EntityID: alice.ID, Contents: "likes tea"}))
+	require.NoError(t, l.CreateRelation(ctx, &models.Relation{FromID: bob.ID, ToID: alice.ID, Type: "knows"}))
+
+	d := NewDirectAdapter(DirectAdapterConfig{Logic: l})
+	response, err := d.DeleteEntities(ctx, DeleteEntitiesArgs{EntityNames: []string{"alice"}, DryRun: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, response.Content)
+
+	var resp DryRunResp
+	require.NoError(t, json.Unmarshal([]byte(response.Content[0].TextContent.Text), &resp))
+	assert.True(t, resp.DryRun)
+	assert.ElementsMatch(t, []PlannedChange{
+		{Action: "delete", Kind: "entity", EntityName: "alice", EntityType: "person"},
+		{Action: "delete", Kind: "observation", EntityName: "alice", Contents: "likes tea"},
+		{Action: "delete", Kind: "relation", From: "bob", To: "alice", RelationType: "knows"},
+	}, resp.PlannedChanges)
+
+	entities, err := client.ReadAllEntities(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entities, 2)
+	observations, err := client.ReadObservationsByEntityID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Len(t, observations, 1)
+	relations, err := client.ReadAllRelations(ctx)
+	require.NoError(t, err)
+	assert.Len(t, relations, 1)
+	changes, err := client.ReadChangesAfterID(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 4, "nothing was journaled")
+}
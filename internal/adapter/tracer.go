@@ -0,0 +1,46 @@
+package adapter
+
+import "go.opentelemetry.io/otel"
+
+// toolTracer traces every tool call, the adapters add spans of their own below it.
+var toolTracer = otel.Tracer("internal/adapter")
+
+// itemCounter is implemented by the tool arguments holding a list of items, the count is added to the span of the
+// tool call.
+type itemCounter interface {
+	itemCount() int
+}
+
+func (a CreateEntitiesArgs) itemCount() int  { return len(a.Entities) }
+func (a DeleteEntitiesArgs) itemCount() int  { return len(a.EntityNames) }
+func (a OpenNodesArgs) itemCount() int       { return len(a.Names) }
+func (a CreateRelationsArgs) itemCount() int { return len(a.Relations) }
+func (a DeleteRelationsArgs) itemCount() int { return len(a.Relations) }
+func (a RemoveAliasesArgs) itemCount() int   { return len(a.Aliases) }
+
+func (a AddObservationsArgs) itemCount() int {
+	count := 0
+	for _, observation := range a.Observations {
+		count += len(observation.Contents)
+	}
+
+	return count
+}
+
+func (a DeleteObservationsArgs) itemCount() int {
+	count := 0
+	for _, deletion := range a.Deletions {
+		count += len(deletion.Observations)
+	}
+
+	return count
+}
+
+func (a AddAliasesArgs) itemCount() int {
+	count := 0
+	for _, aliases := range a.Aliases {
+		count += len(aliases.Aliases)
+	}
+
+	return count
+}
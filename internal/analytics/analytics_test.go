@@ -0,0 +1,66 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGraph is two triangles joined by an edge from 3 to 4, and a separate pair.
+func testGraph() *Graph {
+	g := NewGraph()
+	for _, edge := range [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}, {3, 4}, {7, 8}} {
+		g.AddEdge(edge[0], edge[1])
+	}
+
+	return g
+}
+
+func TestGraph_Degrees(t *testing.T) {
+	g := testGraph()
+	degrees := g.Degrees()
+
+	// node 2 is entity 3
+	assert.Equal(t, Degree{In: 1, Out: 2}, degrees[2])
+	assert.Equal(t, 3, degrees[2].Total())
+}
+
+func TestGraph_PageRank(t *testing.T) {
+	ranks := testGraph().PageRank()
+
+	var sum float64
+	for _, rank := range ranks {
+		sum += rank
+	}
+	assert.InDelta(t, 1, sum, 1e-6)
+	// entity 4 is linked to from both triangles, entity 7 from nothing
+	assert.Greater(t, ranks[3], ranks[0])
+	assert.Less(t, ranks[6], ranks[7])
+}
+
+func TestGraph_WeaklyConnectedComponents(t *testing.T) {
+	assert.Equal(t, []int{0, 0, 0, 0, 0, 0, 1, 1}, testGraph().WeaklyConnectedComponents())
+}
+
+func TestGraph_LabelPropagation(t *testing.T) {
+	communities := testGraph().LabelPropagation()
+
+	assert.Equal(t, communities[0], communities[1])
+	assert.Equal(t, communities[0], communities[2])
+	assert.Equal(t, communities[3], communities[4])
+	assert.Equal(t, communities[3], communities[5])
+	assert.Equal(t, communities[6], communities[7])
+	assert.NotEqual(t, communities[0], communities[6])
+}
+
+func TestAnalyze(t *testing.T) {
+	report := Analyze(testGraph(), Options{Top: 2})
+
+	assert.Equal(t, 8, report.Entities)
+	assert.Equal(t, 8, report.Relations)
+	assert.Len(t, report.Degree, 2)
+	assert.Equal(t, int64(3), report.Degree[0].EntityID)
+	assert.Equal(t, 2, report.ComponentCount)
+	assert.Equal(t, 6, report.Components[0].Size)
+	assert.Len(t, report.Components[0].MemberIDs, 2)
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import "math"
+
+const (
+	// pageRankDamping is the probability of following an edge rather than jumping to a random node.
+	pageRankDamping = 0.85
+	// pageRankTolerance stops PageRank once the ranks change less than this in total.
+	pageRankTolerance  = 1e-6
+	pageRankIterations = 100
+)
+
+// Degree counts the edges of a node.
+type Degree struct {
+	In  int
+	Out int
+}
+
+// Total returns the number of edges starting or ending at the node.
+func (d Degree) Total() int {
+	return d.In + d.Out
+}
+
+// Degrees returns the degree of every node.
+func (g *Graph) Degrees() []Degree {
+	degrees := make([]Degree, g.Nodes())
+	for n := range degrees {
+		degrees[n] = Degree{
+			In:  len(g.in[n]),
+			Out: len(g.out[n]),
+		}
+	}
+
+	return degrees
+}
+
+// PageRank returns the PageRank of every node. The ranks sum to one.
+func (g *Graph) PageRank() []float64 {
+	n := g.Nodes()
+	if n == 0 {
+		return nil
+	}
+
+	ranks := make([]float64, n)
+	for i := range ranks {
+		ranks[i] = 1 / float64(n)
+	}
+
+	next := make([]float64, n)
+	for iteration := 0; iteration < pageRankIterations; iteration++ {
+		// nodes without edges out share their rank with every node
+		var dangling float64
+		for i, out := range g.out {
+			if len(out) == 0 {
+				dangling += ranks[i]
+			}
+		}
+
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, out := range g.out {
+			if len(out) == 0 {
+				continue
+			}
+			share := pageRankDamping * ranks[i] / float64(len(out))
+			for _, to := range out {
+				next[to] += share
+			}
+		}
+
+		var change float64
+		for i := range ranks {
+			change += math.Abs(next[i] - ranks[i])
+		}
+		ranks, next = next, ranks
+		if change < pageRankTolerance {
+			break
+		}
+	}
+
+	return ranks
+}
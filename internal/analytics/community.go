@@ -0,0 +1,101 @@
+package analytics
+
+import "math/rand/v2"
+
+// labelPropagationIterations bounds label propagation, which usually settles in a handful of rounds.
+const labelPropagationIterations = 20
+
+// WeaklyConnectedComponents returns the component of every node, ignoring the direction of edges. Components are
+// numbered from zero in order of their first node.
+func (g *Graph) WeaklyConnectedComponents() []int {
+	parents := make([]int32, g.Nodes())
+	for i := range parents {
+		parents[i] = int32(i)
+	}
+
+	var find func(n int32) int32
+	find = func(n int32) int32 {
+		for parents[n] != n {
+			parents[n] = parents[parents[n]]
+			n = parents[n]
+		}
+		return n
+	}
+	for from, out := range g.out {
+		for _, to := range out {
+			a, b := find(int32(from)), find(to)
+			if a != b {
+				parents[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	return renumber(g.Nodes(), func(n int) int32 { return find(int32(n)) })
+}
+
+// LabelPropagation returns the community of every node found by label propagation, ignoring the direction of edges.
+// Communities are numbered from zero in order of their first node. Nodes are visited in a fixed pseudo random order
+// so the result is the same every time.
+func (g *Graph) LabelPropagation() []int {
+	n := g.Nodes()
+	labels := make([]int32, n)
+	order := make([]int32, n)
+	for i := range labels {
+		labels[i] = int32(i)
+		order[i] = int32(i)
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	counts := make(map[int32]int)
+	for iteration := 0; iteration < labelPropagationIterations; iteration++ {
+		random.Shuffle(n, func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		changed := false
+		for _, node := range order {
+			clear(counts)
+			for _, neighbour := range g.out[node] {
+				counts[labels[neighbour]]++
+			}
+			for _, neighbour := range g.in[node] {
+				counts[labels[neighbour]]++
+			}
+			if len(counts) == 0 {
+				continue
+			}
+
+			// the most common label wins, keeping the current one or else the lowest on a tie
+			best, bestCount := labels[node], counts[labels[node]]
+			for label, count := range counts {
+				if count > bestCount || (count == bestCount && best != labels[node] && label < best) {
+					best, bestCount = label, count
+				}
+			}
+			if best != labels[node] {
+				labels[node] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return renumber(n, func(n int) int32 { return labels[n] })
+}
+
+// renumber numbers the groups label puts nodes in from zero in order of their first node.
+func renumber(nodes int, label func(n int) int32) []int {
+	numbers := make(map[int32]int)
+	groups := make([]int, nodes)
+	for n := range groups {
+		l := label(n)
+		number, ok := numbers[l]
+		if !ok {
+			number = len(numbers)
+			numbers[l] = number
+		}
+		groups[n] = number
+	}
+
+	return groups
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+)
+
+// DefaultBatchSize is how many relations are read at a time if no batch size is given.
+const DefaultBatchSize = 1000
+
+// Graph is a compact directed multigraph of the entities that have relations. Nodes are numbered from zero in the
+// order they were first seen.
+type Graph struct {
+	// EntityIDs maps a node to its entity.
+	EntityIDs []int64
+	nodes     map[int64]int32
+	out       [][]int32
+	in        [][]int32
+	edges     int
+}
+
+// NewGraph returns an empty graph.
+func NewGraph() *Graph {
+	return &Graph{
+		nodes: make(map[int64]int32),
+	}
+}
+
+// Load reads every relation batchSize at a time into a graph. Only the entity ids are kept, so the graph stays small
+// however many relations there are.
+func Load(ctx context.Context, relations db.Relations, batchSize int) (*Graph, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	g := NewGraph()
+	var afterID int64
+	for {
+		batch, err := relations.ReadRelationsAfterID(ctx, afterID, batchSize)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return nil, err
+		}
+		for _, relation := range batch {
+			g.AddEdge(relation.FromID, relation.ToID)
+			afterID = relation.ID
+		}
+		if len(batch) < batchSize {
+			return g, nil
+		}
+	}
+}
+
+// AddEdge adds an edge from the entity fromID to the entity toID.
+func (g *Graph) AddEdge(fromID, toID int64) {
+	from, to := g.node(fromID), g.node(toID)
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+	g.edges++
+}
+
+// Nodes returns the number of nodes.
+func (g *Graph) Nodes() int {
+	return len(g.EntityIDs)
+}
+
+// Edges returns the number of edges.
+func (g *Graph) Edges() int {
+	return g.edges
+}
+
+func (g *Graph) node(entityID int64) int32 {
+	if n, ok := g.nodes[entityID]; ok {
+		return n
+	}
+
+	n := int32(len(g.EntityIDs))
+	g.nodes[entityID] = n
+	g.EntityIDs = append(g.EntityIDs, entityID)
+	g.out = append(g.out, nil)
+	g.in = append(g.in, nil)
+
+	return n
+}
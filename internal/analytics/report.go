@@ -0,0 +1,181 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+)
+
+// Options control what is reported.
+type Options struct {
+	// Top is how many entities and groups are listed, 10 if not set.
+	Top int
+	// BatchSize is how many relations and entities are read at a time, DefaultBatchSize if not set.
+	BatchSize int
+}
+
+// Report summarizes the graph. Only entities with at least one relation are part of it.
+type Report struct {
+	Entities       int            `json:"entities"`
+	Relations      int            `json:"relations"`
+	Degree         []*DegreeScore `json:"degree"`
+	PageRank       []*Score       `json:"pageRank"`
+	ComponentCount int            `json:"componentCount"`
+	// Components are the largest weakly connected components.
+	Components     []*Group `json:"components"`
+	CommunityCount int      `json:"communityCount"`
+	// Communities are the largest label propagation communities.
+	Communities []*Group `json:"communities"`
+}
+
+// DegreeScore is the degree of an entity.
+type DegreeScore struct {
+	EntityID int64  `json:"-"`
+	Name     string `json:"name"`
+	In       int    `json:"in"`
+	Out      int    `json:"out"`
+	Total    int    `json:"total"`
+}
+
+// Score is the centrality of an entity.
+type Score struct {
+	EntityID int64   `json:"-"`
+	Name     string  `json:"name"`
+	Score    float64 `json:"score"`
+}
+
+// Group is a component or community.
+type Group struct {
+	ID   int `json:"id"`
+	Size int `json:"size"`
+	// Members are the group's entities with the highest degree.
+	MemberIDs []int64  `json:"-"`
+	Members   []string `json:"members"`
+}
+
+// Analyze computes the centrality, components and communities of g.
+func Analyze(g *Graph, opts Options) *Report {
+	top := opts.Top
+	if top <= 0 {
+		top = 10
+	}
+
+	degrees := g.Degrees()
+	// nodes in order of degree, highest first, used to pick the top entities and group members
+	byDegree := make([]int, g.Nodes())
+	for n := range byDegree {
+		byDegree[n] = n
+	}
+	sort.SliceStable(byDegree, func(i, j int) bool {
+		return degrees[byDegree[i]].Total() > degrees[byDegree[j]].Total()
+	})
+
+	report := &Report{
+		Entities:  g.Nodes(),
+		Relations: g.Edges(),
+		Degree:    make([]*DegreeScore, 0, min(top, g.Nodes())),
+		PageRank:  make([]*Score, 0, min(top, g.Nodes())),
+	}
+	for _, n := range byDegree[:min(top, len(byDegree))] {
+		report.Degree = append(report.Degree, &DegreeScore{
+			EntityID: g.EntityIDs[n],
+			In:       degrees[n].In,
+			Out:      degrees[n].Out,
+			Total:    degrees[n].Total(),
+		})
+	}
+
+	ranks := g.PageRank()
+	byRank := make([]int, len(ranks))
+	for n := range byRank {
+		byRank[n] = n
+	}
+	sort.SliceStable(byRank, func(i, j int) bool {
+		return ranks[byRank[i]] > ranks[byRank[j]]
+	})
+	for _, n := range byRank[:min(top, len(byRank))] {
+		report.PageRank = append(report.PageRank, &Score{
+			EntityID: g.EntityIDs[n],
+			Score:    ranks[n],
+		})
+	}
+
+	report.ComponentCount, report.Components = groups(g, g.WeaklyConnectedComponents(), byDegree, top)
+	report.CommunityCount, report.Communities = groups(g, g.LabelPropagation(), byDegree, top)
+
+	return report
+}
+
+// groups returns the number of groups in membership and the top largest, each with its top members by degree.
+func groups(g *Graph, membership []int, byDegree []int, top int) (int, []*Group) {
+	all := make([]*Group, 0)
+	for _, id := range membership {
+		for id >= len(all) {
+			all = append(all, &Group{ID: len(all), MemberIDs: make([]int64, 0)})
+		}
+		all[id].Size++
+	}
+	for _, n := range byDegree {
+		group := all[membership[n]]
+		if len(group.MemberIDs) < top {
+			group.MemberIDs = append(group.MemberIDs, g.EntityIDs[n])
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Size > all[j].Size
+	})
+
+	return len(all), all[:min(top, len(all))]
+}
+
+// Name fills in the names of the entities in the report, reading them batchSize at a time.
+func (r *Report) Name(ctx context.Context, entities db.Entities, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	names := make(map[int64]string)
+	for _, score := range r.Degree {
+		names[score.EntityID] = ""
+	}
+	for _, score := range r.PageRank {
+		names[score.EntityID] = ""
+	}
+	for _, group := range append(append([]*Group{}, r.Components...), r.Communities...) {
+		for _, id := range group.MemberIDs {
+			names[id] = ""
+		}
+	}
+
+	ids := make([]int64, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		batch, err := entities.ReadEntitiesByIDs(ctx, ids[start:min(start+batchSize, len(ids))])
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+		for _, entity := range batch {
+			names[entity.ID] = entity.Name
+		}
+	}
+
+	for _, score := range r.Degree {
+		score.Name = names[score.EntityID]
+	}
+	for _, score := range r.PageRank {
+		score.Name = names[score.EntityID]
+	}
+	for _, group := range append(append([]*Group{}, r.Components...), r.Communities...) {
+		group.Members = make([]string, 0, len(group.MemberIDs))
+		for _, id := range group.MemberIDs {
+			group.Members = append(group.Members, names[id])
+		}
+	}
+
+	return nil
+}
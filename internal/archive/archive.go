@@ -0,0 +1,292 @@
+package archive
+
+import (
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// Format identifies backup archives.
+const Format = "mcp-dbmem-backup"
+
+// Version is the version of the archive format written. Archives of a newer version can't be read.
+const Version = 1
+
+// Tables of the archive, in the order they're written and restored so rows come after the rows they refer to.
+const (
+	TableEntities      = "entities"
+	TableEntityAliases = "entity_aliases"
+	TableObservations  = "observations"
+	TableRelations     = "relations"
+	TableOntologies    = "ontologies"
+	TableChanges       = "changes"
+)
+
+// Tables lists the tables of the archive in order.
+var Tables = []string{
+	TableEntities,
+	TableEntityAliases,
+	TableObservations,
+	TableRelations,
+	TableOntologies,
+	TableChanges,
+}
+
+// Manifest is the first line of an archive.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// SchemaVersion is the last migration applied to the database backed up.
+	SchemaVersion string `json:"schema_version"`
+	// Tables are the row counts and checksums of every table.
+	Tables []TableSum `json:"tables"`
+}
+
+// Table returns the count and checksum of the table called name, nil if the archive doesn't have it.
+func (m *Manifest) Table(name string) *TableSum {
+	for i := range m.Tables {
+		if m.Tables[i].Name == name {
+			return &m.Tables[i]
+		}
+	}
+
+	return nil
+}
+
+// TableSum is the row count of a table and the SHA-256 checksum of its lines.
+type TableSum struct {
+	Name   string `json:"name"`
+	Count  int64  `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// Entity is an entity row.
+type Entity struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	GlobalID  string     `json:"global_id,omitempty"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+}
+
+// FromEntity returns the row of entity.
+func FromEntity(entity *models.Entity) Entity {
+	return Entity{
+		ID:        entity.ID,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+		DeletedAt: deletedAt(entity.DeletedAt),
+		GlobalID:  entity.GlobalID,
+		Name:      entity.Name,
+		Type:      entity.Type,
+	}
+}
+
+// Model returns the entity of the row.
+func (e Entity) Model() *models.Entity {
+	return &models.Entity{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+		DeletedAt: deletedAtTime(e.DeletedAt),
+		GlobalID:  e.GlobalID,
+		Name:      e.Name,
+		Type:      e.Type,
+	}
+}
+
+// EntityAlias is an entity alias row.
+type EntityAlias struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Alias      string    `json:"alias"`
+	Normalized string    `json:"normalized"`
+	EntityID   int64     `json:"entity_id"`
+}
+
+// FromEntityAlias returns the row of alias.
+func FromEntityAlias(alias *models.EntityAlias) EntityAlias {
+	return EntityAlias{
+		ID:         alias.ID,
+		CreatedAt:  alias.CreatedAt,
+		UpdatedAt:  alias.UpdatedAt,
+		Alias:      alias.Alias,
+		Normalized: alias.Normalized,
+		EntityID:   alias.EntityID,
+	}
+}
+
+// Model returns the entity alias of the row.
+func (a EntityAlias) Model() *models.EntityAlias {
+	return &models.EntityAlias{
+		ID:         a.ID,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+		Alias:      a.Alias,
+		Normalized: a.Normalized,
+		EntityID:   a.EntityID,
+	}
+}
+
+// Observation is an observation row.
+type Observation struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	GlobalID  string     `json:"global_id,omitempty"`
+	Contents  string     `json:"contents"`
+	EntityID  int64      `json:"entity_id"`
+}
+
+// FromObservation returns the row of observation.
+func FromObservation(observation *models.Observation) Observation {
+	return Observation{
+		ID:        observation.ID,
+		CreatedAt: observation.CreatedAt,
+		UpdatedAt: observation.UpdatedAt,
+		DeletedAt: deletedAt(observation.DeletedAt),
+		GlobalID:  observation.GlobalID,
+		Contents:  observation.Contents,
+		EntityID:  observation.EntityID,
+	}
+}
+
+// Model returns the observation of the row.
+func (o Observation) Model() *models.Observation {
+	return &models.Observation{
+		ID:        o.ID,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+		DeletedAt: deletedAtTime(o.DeletedAt),
+		GlobalID:  o.GlobalID,
+		Contents:  o.Contents,
+		EntityID:  o.EntityID,
+	}
+}
+
+// Relation is a relation row.
+type Relation struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	GlobalID  string     `json:"global_id,omitempty"`
+	Type      string     `json:"type"`
+	FromID    int64      `json:"from_id"`
+	ToID      int64      `json:"to_id"`
+}
+
+// FromRelation returns the row of relation.
+func FromRelation(relation *models.Relation) Relation {
+	return Relation{
+		ID:        relation.ID,
+		CreatedAt: relation.CreatedAt,
+		UpdatedAt: relation.UpdatedAt,
+		DeletedAt: deletedAt(relation.DeletedAt),
+		GlobalID:  relation.GlobalID,
+		Type:      relation.Type,
+		FromID:    relation.FromID,
+		ToID:      relation.ToID,
+	}
+}
+
+// Model returns the relation of the row.
+func (r Relation) Model() *models.Relation {
+	return &models.Relation{
+		ID:        r.ID,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+		DeletedAt: deletedAtTime(r.DeletedAt),
+		GlobalID:  r.GlobalID,
+		Type:      r.Type,
+		FromID:    r.FromID,
+		ToID:      r.ToID,
+	}
+}
+
+// Ontology is an ontology row.
+type Ontology struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Document  string    `json:"document"`
+}
+
+// FromOntology returns the row of ontology.
+func FromOntology(ontology *models.Ontology) Ontology {
+	return Ontology{
+		ID:        ontology.ID,
+		CreatedAt: ontology.CreatedAt,
+		UpdatedAt: ontology.UpdatedAt,
+		Document:  ontology.Document,
+	}
+}
+
+// Model returns the ontology of the row.
+func (o Ontology) Model() *models.Ontology {
+	return &models.Ontology{
+		ID:        o.ID,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+		Document:  o.Document,
+	}
+}
+
+// Change is a change journal row.
+type Change struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	SessionID string    `json:"session_id"`
+	Action    string    `json:"action"`
+	Kind      string    `json:"kind"`
+	RecordID  int64     `json:"record_id"`
+	Data      string    `json:"data"`
+}
+
+// FromChange returns the row of change.
+func FromChange(change *models.Change) Change {
+	return Change{
+		ID:        change.ID,
+		CreatedAt: change.CreatedAt,
+		SessionID: change.SessionID,
+		Action:    string(change.Action),
+		Kind:      string(change.Kind),
+		RecordID:  change.RecordID,
+		Data:      change.Data,
+	}
+}
+
+// Model returns the change of the row.
+func (c Change) Model() *models.Change {
+	return &models.Change{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		SessionID: c.SessionID,
+		Action:    models.ChangeAction(c.Action),
+		Kind:      models.ChangeKind(c.Kind),
+		RecordID:  c.RecordID,
+		Data:      c.Data,
+	}
+}
+
+// deletedAt returns nil for rows that aren't in the trash.
+func deletedAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func deletedAtTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
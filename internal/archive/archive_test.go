@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchive(t *testing.T, manifest *Manifest, rows map[string][]any) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, manifest)
+	require.NoError(t, err)
+	for _, table := range Tables {
+		for _, row := range rows[table] {
+			require.NoError(t, w.Write(table, row))
+		}
+	}
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+func readArchive(data []byte) (map[string]int, error) {
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for {
+		table, _, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return counts, nil
+		}
+		if err != nil {
+			return counts, err
+		}
+		counts[table]++
+	}
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	rows := map[string][]any{
+		TableEntities: {
+			Entity{ID: 1, CreatedAt: now, UpdatedAt: now, Name: "alice", Type: "person"},
+			Entity{ID: 2, CreatedAt: now, UpdatedAt: now, DeletedAt: &now, Name: "bob", Type: "person"},
+		},
+		TableObservations: {Observation{ID: 1, CreatedAt: now, UpdatedAt: now, Contents: "likes tea", EntityID: 1}},
+	}
+	summer := NewSummer()
+	for _, table := range Tables {
+		for _, row := range rows[table] {
+			require.NoError(t, summer.Add(table, row))
+		}
+	}
+	manifest := &Manifest{Format: Format, Version: Version, CreatedAt: now, SchemaVersion: "1", Tables: summer.Sums()}
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		counts, err := readArchive(writeArchive(t, manifest, rows))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{TableEntities: 2, TableObservations: 1}, counts)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		changed := map[string][]any{
+			TableEntities:     rows[TableEntities],
+			TableObservations: {Observation{ID: 1, CreatedAt: now, UpdatedAt: now, Contents: "likes coffee", EntityID: 1}},
+		}
+		_, err := readArchive(writeArchive(t, manifest, changed))
+		assert.ErrorContains(t, err, "checksum of table observations")
+	})
+
+	t.Run("count mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := readArchive(writeArchive(t, manifest, map[string][]any{TableEntities: rows[TableEntities]}))
+		assert.ErrorContains(t, err, "observations has 0 rows")
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		t.Parallel()
+
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		require.NoError(t, json.NewEncoder(gz).Encode(&Manifest{Format: Format, Version: Version + 1}))
+		require.NoError(t, gz.Close())
+		_, err := readArchive(buf.Bytes())
+		assert.ErrorContains(t, err, "not supported")
+
+		_, err = readArchive([]byte("not gzip"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Reader reads an archive, checking its rows against the manifest.
+type Reader struct {
+	br       *bufio.Reader
+	manifest *Manifest
+	summer   *Summer
+}
+
+// NewReader reads the manifest of the archive in r.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	br := bufio.NewReader(gz)
+
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("can't read manifest: %w", err)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(line, manifest); err != nil {
+		return nil, fmt.Errorf("can't read manifest: %w", err)
+	}
+	if manifest.Format != Format {
+		return nil, errors.New("not a backup archive")
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("archive version %d not supported, this version reads up to %d", manifest.Version, Version)
+	}
+
+	return &Reader{br: br, manifest: manifest, summer: NewSummer()}, nil
+}
+
+// Manifest returns the manifest of the archive.
+func (r *Reader) Manifest() *Manifest {
+	return r.manifest
+}
+
+// Next returns the table and row of the next line. It returns io.EOF after the last line once it has checked the
+// rows match the manifest.
+func (r *Reader) Next() (string, json.RawMessage, error) {
+	line, err := r.br.ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		if len(line) > 0 {
+			return "", nil, errors.New("archive is truncated")
+		}
+		if err := verify(r.manifest, r.summer); err != nil {
+			return "", nil, err
+		}
+
+		return "", nil, io.EOF
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	rec := record{}
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return "", nil, fmt.Errorf("can't read row: %w", err)
+	}
+	if !slices.Contains(Tables, rec.Table) {
+		return "", nil, fmt.Errorf("unknown table %s", rec.Table)
+	}
+	r.summer.addLine(rec.Table, line)
+
+	return rec.Table, rec.Row, nil
+}
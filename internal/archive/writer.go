@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+)
+
+// record is a line of an archive after the manifest.
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// encodeRecord returns the line of row in table, newline included.
+func encodeRecord(table string, row any) ([]byte, error) {
+	if !slices.Contains(Tables, table) {
+		return nil, fmt.Errorf("unknown table %s", table)
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(record{Table: table, Row: data})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(line, '\n'), nil
+}
+
+// Summer counts and checksums the lines of every table.
+type Summer struct {
+	counts map[string]int64
+	hashes map[string]hash.Hash
+}
+
+// NewSummer returns a Summer with every table empty.
+func NewSummer() *Summer {
+	s := &Summer{
+		counts: make(map[string]int64, len(Tables)),
+		hashes: make(map[string]hash.Hash, len(Tables)),
+	}
+	for _, table := range Tables {
+		s.hashes[table] = sha256.New()
+	}
+
+	return s
+}
+
+// Add adds row to table the way a Writer writes it.
+func (s *Summer) Add(table string, row any) error {
+	line, err := encodeRecord(table, row)
+	if err != nil {
+		return err
+	}
+	s.addLine(table, line)
+
+	return nil
+}
+
+func (s *Summer) addLine(table string, line []byte) {
+	s.counts[table]++
+	_, _ = s.hashes[table].Write(line)
+}
+
+// Sums returns the count and checksum of every table.
+func (s *Summer) Sums() []TableSum {
+	sums := make([]TableSum, 0, len(Tables))
+	for _, table := range Tables {
+		sums = append(sums, TableSum{
+			Name:   table,
+			Count:  s.counts[table],
+			SHA256: hex.EncodeToString(s.hashes[table].Sum(nil)),
+		})
+	}
+
+	return sums
+}
+
+// Writer writes an archive.
+type Writer struct {
+	gz       *gzip.Writer
+	manifest *Manifest
+	summer   *Summer
+}
+
+// NewWriter writes manifest to w and returns a Writer for the rows it describes.
+func NewWriter(w io.Writer, manifest *Manifest) (*Writer, error) {
+	gz := gzip.NewWriter(w)
+	line, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gz.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	return &Writer{gz: gz, manifest: manifest, summer: NewSummer()}, nil
+}
+
+// Write writes row to table. Rows of a table must be written together, in the order of Tables.
+func (w *Writer) Write(table string, row any) error {
+	line, err := encodeRecord(table, row)
+	if err != nil {
+		return err
+	}
+	w.summer.addLine(table, line)
+	_, err = w.gz.Write(line)
+
+	return err
+}
+
+// Close flushes the archive, failing if the rows written don't match the manifest. It doesn't close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+
+	return verify(w.manifest, w.summer)
+}
+
+// verify checks the rows summed by s match the counts and checksums of manifest.
+func verify(manifest *Manifest, s *Summer) error {
+	for _, sum := range s.Sums() {
+		want := manifest.Table(sum.Name)
+		if want == nil {
+			if sum.Count == 0 {
+				continue
+			}
+			return fmt.Errorf("table %s isn't in the manifest", sum.Name)
+		}
+		if want.Count != sum.Count {
+			return fmt.Errorf("table %s has %d rows, the manifest says %d", sum.Name, sum.Count, want.Count)
+		}
+		if want.SHA256 != sum.SHA256 {
+			return fmt.Errorf("checksum of table %s doesn't match the manifest", sum.Name)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/pflag"
@@ -10,7 +15,27 @@ import (
 // ApplicationName contains the application identifier.
 const ApplicationName = "mcp-dbmem"
 
-// Init starts config collection.
+// Redacted replaces secrets when the config is shown.
+const Redacted = "[REDACTED]"
+
+// secretFiles maps the keys of secrets to the keys of the files they can be read from instead, so they don't have to
+// be passed in plain text.
+var secretFiles = map[string]string{
+	Keys.DBPassword: Keys.DBPasswordFile,
+	Keys.DBURL:      Keys.DBURLFile,
+	Keys.UptraceDSN: Keys.UptraceDSNFile,
+}
+
+// urlKeys are the keys holding connection urls, their passwords are redacted.
+var urlKeys = map[string]bool{
+	Keys.DBURL:      true,
+	Keys.CopyFrom:   true,
+	Keys.CopyTo:     true,
+	Keys.SyncRemote: true,
+}
+
+// Init starts config collection. Values are taken from flags, then the environment, then the config file, then the
+// defaults.
 func Init(flags *pflag.FlagSet) error {
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
@@ -19,5 +44,154 @@ func Init(flags *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := readConfigFile(); err != nil {
+		return err
+	}
+
+	return readSecretFiles()
+}
+
+// readConfigFile reads the config file given with the config key, or the first one found in the XDG config
+// directories.
+func readConfigFile() error {
+	path := viper.GetString(Keys.ConfigFile)
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.SetConfigName("config")
+		for _, dir := range configDirs() {
+			viper.AddConfigPath(dir)
+		}
+	}
+
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	if path == "" && errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read config file: %w", err)
+	}
+
 	return nil
 }
+
+// configDirs returns the directories config files are looked for in, most important first.
+// (https://specifications.freedesktop.org/basedir-spec/latest/)
+func configDirs() []string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+
+	var dirs []string
+	if configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, ApplicationName))
+	}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, ApplicationName))
+		}
+	}
+
+	return dirs
+}
+
+// readSecretFiles sets the secrets whose file is configured from the file.
+func readSecretFiles() error {
+	for key, fileKey := range secretFiles {
+		path := viper.GetString(fileKey)
+		if path == "" {
+			continue
+		}
+		if viper.IsSet(key) {
+			return fmt.Errorf("%s and %s are both set, use one of them", key, fileKey)
+		}
+
+		/* #nosec G304 */
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", fileKey, err)
+		}
+		viper.Set(key, strings.TrimRight(string(secret), "\r\n"))
+	}
+
+	return nil
+}
+
+// Redact returns value with any secret in it replaced if key holds a secret. Urls keep everything but the password,
+// headers keep their names.
+func Redact(key string, value any) any {
+	if key == Keys.TraceOTLPHeaders {
+		return redactHeaders(value)
+	}
+	_, secret := secretFiles[key]
+	if !secret && !urlKeys[key] {
+		return value
+	}
+
+	s, ok := value.(string)
+	switch {
+	case !ok:
+		return Redacted
+	case s == "":
+		return s
+	}
+	if urlKeys[key] {
+		if redacted, ok := redactURL(s); ok {
+			return redacted
+		}
+	}
+	if secret {
+		return Redacted
+	}
+
+	// the path of a sqlite file has no password
+	return s
+}
+
+// redactURL returns s with the password masked if it's a url. Opaque urls like user:password aren't taken for one.
+func redactURL(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return "", false
+	}
+	query := u.Query()
+	if query.Has("password") {
+		query.Set("password", "xxxxx")
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted(), true
+}
+
+// redactHeaders replaces the values of key=value headers, they often carry tokens.
+func redactHeaders(value any) any {
+	var headers []string
+	switch v := value.(type) {
+	case string:
+		headers = strings.Split(v, ",")
+	case []string:
+		headers = v
+	case []any:
+		for _, header := range v {
+			headers = append(headers, fmt.Sprint(header))
+		}
+	default:
+		return Redacted
+	}
+
+	redacted := make([]string, len(headers))
+	for i, header := range headers {
+		name, _, _ := strings.Cut(header, "=")
+		redacted[i] = name + "=" + Redacted
+	}
+
+	return redacted
+}
@@ -2,38 +2,231 @@ package config
 
 // KeyNames is a struct that contains the names of keys.
 type KeyNames struct {
+	ConfigFile      string
 	LogLevel        string
 	SoftwareVersion string
+	SoftwareCommit  string
+
+	// logging
+	LogFormat         string
+	LogFile           string
+	LogFileMaxSize    string
+	LogFileMaxBackups string
+	LogRedactContents string
+
+	// tracing
+	TraceExporter           string
+	TraceOTLPProtocol       string
+	TraceOTLPEndpoint       string
+	TraceOTLPHeaders        string
+	TraceOTLPInsecure       string
+	TraceSamplerRatio       string
+	TraceResourceAttributes string
 
 	// uptrace
-	UptraceDSN string
+	UptraceDSN     string
+	UptraceDSNFile string
 
 	// database
-	DBType      string
-	DBAddress   string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBDatabase  string
-	DBTLSMode   string
-	DBTLSCACert string
+	DBURL              string
+	DBURLFile          string
+	DBType             string
+	DBAddress          string
+	DBPort             string
+	DBUser             string
+	DBPassword         string
+	DBPasswordFile     string
+	DBDatabase         string
+	DBTLSMode          string
+	DBTLSCACert        string
+	DBTLSClientCert    string
+	DBTLSClientKey     string
+	DBTLSServerName    string
+	DBTLSMinVersion    string
+	DBWait             string
+	DBRetryMaxElapsed  string
+	DBMaxOpenConns     string
+	DBMaxIdleConns     string
+	DBConnMaxLifetime  string
+	DBConnMaxIdleTime  string
+	DBStatementTimeout string
+
+	// sqlite
+	DBSQLiteForeignKeys string
+	DBSQLiteJournalMode string
+	DBSQLiteBusyTimeout string
+	DBSQLiteSynchronous string
+
+	// direct
+	DryRun      string
+	AutoMigrate string
+
+	// http
+	HTTPAddress            string
+	MetricsRefreshInterval string
+	// MetricsAddress is the name HTTPAddress had before it served more than metrics. It's deprecated.
+	MetricsAddress string
+
+	// migrate
+	MigrateLockTimeout string
+	MigrationsDir      string
+
+	// healthcheck
+	HealthcheckTimeout string
+
+	// ontology
+	OntologyFile       string
+	OntologyStrictness string
+
+	// inference
+	RulesFile         string
+	InferenceMaxDepth string
+
+	// analyze
+	AnalyzeFormat    string
+	AnalyzeTop       string
+	AnalyzeBatchSize string
+
+	// doctor
+	DoctorFix string
+
+	// rollback
+	RollbackTo      string
+	RollbackSession string
+	RollbackApply   string
+
+	// purge
+	PurgeOlderThan string
+
+	// restore
+	RestoreMerge         string
+	RestoreIntoEmptyOnly string
+
+	// copy
+	CopyFrom      string
+	CopyTo        string
+	CopyNamespace string
+	CopyBatchSize string
+
+	// sync
+	SyncRemote   string
+	SyncStrategy string
+	SyncFull     string
+	SyncDryRun   string
+	SyncFormat   string
 }
 
 // Keys contains the names of config keys.
 var Keys = KeyNames{
+	ConfigFile:      "config",
 	LogLevel:        "log-level",
 	SoftwareVersion: "software-version", // Set at build
+	SoftwareCommit:  "software-commit",  // Set at build
+
+	// logging
+	LogFormat:         "log-format",
+	LogFile:           "log-file",
+	LogFileMaxSize:    "log-file-max-size",
+	LogFileMaxBackups: "log-file-max-backups",
+	LogRedactContents: "log-redact-contents",
+
+	// tracing
+	TraceExporter:           "trace-exporter",
+	TraceOTLPProtocol:       "trace-otlp-protocol",
+	TraceOTLPEndpoint:       "trace-otlp-endpoint",
+	TraceOTLPHeaders:        "trace-otlp-headers",
+	TraceOTLPInsecure:       "trace-otlp-insecure",
+	TraceSamplerRatio:       "trace-sampler-ratio",
+	TraceResourceAttributes: "trace-resource-attributes",
 
 	// uptrace
-	UptraceDSN: "uptrace-dsn",
+	UptraceDSN:     "uptrace-dsn",
+	UptraceDSNFile: "uptrace-dsn-file",
 
 	// database
-	DBType:      "db-type",
-	DBAddress:   "db-address",
-	DBPort:      "db-port",
-	DBUser:      "db-user",
-	DBPassword:  "db-password",
-	DBDatabase:  "db-database",
-	DBTLSMode:   "db-tls-mode",
-	DBTLSCACert: "db-tls-ca-cert",
+	DBURL:              "db-url",
+	DBURLFile:          "db-url-file",
+	DBType:             "db-type",
+	DBAddress:          "db-address",
+	DBPort:             "db-port",
+	DBUser:             "db-user",
+	DBPassword:         "db-password",
+	DBPasswordFile:     "db-password-file",
+	DBDatabase:         "db-database",
+	DBTLSMode:          "db-tls-mode",
+	DBTLSCACert:        "db-tls-ca-cert",
+	DBTLSClientCert:    "db-tls-client-cert",
+	DBTLSClientKey:     "db-tls-client-key",
+	DBTLSServerName:    "db-tls-server-name",
+	DBTLSMinVersion:    "db-tls-min-version",
+	DBWait:             "db-wait",
+	DBRetryMaxElapsed:  "db-retry-max-elapsed",
+	DBMaxOpenConns:     "db-max-open-conns",
+	DBMaxIdleConns:     "db-max-idle-conns",
+	DBConnMaxLifetime:  "db-conn-max-lifetime",
+	DBConnMaxIdleTime:  "db-conn-max-idle-time",
+	DBStatementTimeout: "db-statement-timeout",
+
+	// sqlite
+	DBSQLiteForeignKeys: "db-sqlite-foreign-keys",
+	DBSQLiteJournalMode: "db-sqlite-journal-mode",
+	DBSQLiteBusyTimeout: "db-sqlite-busy-timeout",
+	DBSQLiteSynchronous: "db-sqlite-synchronous",
+
+	// direct
+	DryRun:      "dry-run",
+	AutoMigrate: "auto-migrate",
+
+	// http
+	HTTPAddress:            "http-address",
+	MetricsRefreshInterval: "metrics-refresh-interval",
+	MetricsAddress:         "metrics-address",
+
+	// migrate
+	MigrateLockTimeout: "migrate-lock-timeout",
+	MigrationsDir:      "migrations-dir",
+
+	// healthcheck
+	HealthcheckTimeout: "healthcheck-timeout",
+
+	// ontology
+	OntologyFile:       "ontology-file",
+	OntologyStrictness: "ontology-strictness",
+
+	// inference
+	RulesFile:         "rules-file",
+	InferenceMaxDepth: "inference-max-depth",
+
+	// analyze
+	AnalyzeFormat:    "format",
+	AnalyzeTop:       "top",
+	AnalyzeBatchSize: "batch-size",
+
+	// doctor
+	DoctorFix: "fix",
+
+	// rollback
+	RollbackTo:      "to",
+	RollbackSession: "session",
+	RollbackApply:   "apply",
+
+	// purge
+	PurgeOlderThan: "older-than",
+
+	// restore
+	RestoreMerge:         "merge",
+	RestoreIntoEmptyOnly: "into-empty-only",
+
+	// copy
+	CopyFrom:      "copy-from",
+	CopyTo:        "copy-to",
+	CopyNamespace: "namespace",
+	CopyBatchSize: "copy-batch-size",
+
+	// sync
+	SyncRemote:   "remote",
+	SyncStrategy: "strategy",
+	SyncFull:     "full",
+	SyncDryRun:   "sync-dry-run",
+	SyncFormat:   "sync-format",
 }
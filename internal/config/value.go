@@ -1,35 +1,192 @@
 package config
 
+import "time"
+
 // Values contains the type of each value.
 type Values struct {
+	ConfigFile      string
 	LogLevel        string
 	SoftwareVersion string
+	SoftwareCommit  string
+
+	// logging
+	LogFormat         string
+	LogFile           string
+	LogFileMaxSize    int
+	LogFileMaxBackups int
+	LogRedactContents bool
+
+	// tracing
+	TraceExporter           string
+	TraceOTLPProtocol       string
+	TraceOTLPEndpoint       string
+	TraceOTLPHeaders        []string
+	TraceOTLPInsecure       bool
+	TraceSamplerRatio       float64
+	TraceResourceAttributes []string
 
 	// uptrace
-	UptraceDSN string
+	UptraceDSN     string
+	UptraceDSNFile string
 
 	// database
-	DBType      string
-	DBAddress   string
-	DBPort      uint16
-	DBUser      string
-	DBPassword  string
-	DBDatabase  string
-	DBTLSMode   string
-	DBTLSCACert string
+	DBURL              string
+	DBURLFile          string
+	DBType             string
+	DBAddress          string
+	DBPort             uint16
+	DBUser             string
+	DBPassword         string
+	DBPasswordFile     string
+	DBDatabase         string
+	DBTLSMode          string
+	DBTLSCACert        string
+	DBTLSClientCert    string
+	DBTLSClientKey     string
+	DBTLSServerName    string
+	DBTLSMinVersion    string
+	DBWait             time.Duration
+	DBRetryMaxElapsed  time.Duration
+	DBMaxOpenConns     int
+	DBMaxIdleConns     int
+	DBConnMaxLifetime  time.Duration
+	DBConnMaxIdleTime  time.Duration
+	DBStatementTimeout time.Duration
+
+	// sqlite
+	DBSQLiteForeignKeys bool
+	DBSQLiteJournalMode string
+	DBSQLiteBusyTimeout time.Duration
+	DBSQLiteSynchronous string
+
+	// direct
+	DryRun      bool
+	AutoMigrate bool
+
+	// http
+	HTTPAddress            string
+	MetricsRefreshInterval time.Duration
+	MetricsAddress         string
+
+	// migrate
+	MigrateLockTimeout time.Duration
+	MigrationsDir      string
+
+	// healthcheck
+	HealthcheckTimeout time.Duration
+
+	// ontology
+	OntologyFile       string
+	OntologyStrictness string
+
+	// inference
+	RulesFile         string
+	InferenceMaxDepth int
+
+	// analyze
+	AnalyzeFormat    string
+	AnalyzeTop       int
+	AnalyzeBatchSize int
+
+	// doctor
+	DoctorFix bool
+
+	// rollback
+	RollbackTo      string
+	RollbackSession string
+	RollbackApply   bool
+
+	// purge
+	PurgeOlderThan time.Duration
+
+	// restore
+	RestoreMerge         bool
+	RestoreIntoEmptyOnly bool
+
+	// copy
+	CopyFrom      string
+	CopyTo        string
+	CopyNamespace string
+	CopyBatchSize int
+
+	// sync
+	SyncRemote   string
+	SyncStrategy string
+	SyncFull     bool
+	SyncDryRun   bool
+	SyncFormat   string
 }
 
 // Defaults contains the default values.
 var Defaults = Values{
 	LogLevel: "info",
 
+	// logging
+	LogFormat:         "console",
+	LogFile:           "",
+	LogFileMaxSize:    100,
+	LogFileMaxBackups: 3,
+	LogRedactContents: true,
+
+	// tracing
+	TraceSamplerRatio: 1,
+
 	// database
-	DBType:      "postgres",
-	DBAddress:   "localhost",
-	DBPort:      5432,
-	DBUser:      "mcp-dbmem",
-	DBPassword:  "mcp-dbmem",
-	DBDatabase:  "mcp-dbmem",
-	DBTLSMode:   "disable",
-	DBTLSCACert: "",
+	DBURL:              "",
+	DBType:             "postgres",
+	DBAddress:          "localhost",
+	DBPort:             5432,
+	DBUser:             "mcp-dbmem",
+	DBPassword:         "mcp-dbmem",
+	DBDatabase:         "mcp-dbmem",
+	DBTLSMode:          "disable",
+	DBTLSCACert:        "",
+	DBTLSClientCert:    "",
+	DBTLSClientKey:     "",
+	DBTLSServerName:    "",
+	DBTLSMinVersion:    "1.2",
+	DBWait:             0,
+	DBRetryMaxElapsed:  15 * time.Second,
+	DBMaxOpenConns:     0,
+	DBMaxIdleConns:     0,
+	DBConnMaxLifetime:  0,
+	DBConnMaxIdleTime:  0,
+	DBStatementTimeout: 0,
+
+	// sqlite
+	DBSQLiteForeignKeys: true,
+	DBSQLiteJournalMode: "WAL",
+	DBSQLiteBusyTimeout: 5 * time.Second,
+	DBSQLiteSynchronous: "NORMAL",
+
+	// http
+	MetricsRefreshInterval: time.Minute,
+
+	// migrate
+	MigrateLockTimeout: time.Minute,
+	MigrationsDir:      "internal/db/bun/migrations",
+
+	// healthcheck
+	HealthcheckTimeout: 5 * time.Second,
+
+	// ontology
+	OntologyStrictness: "warn",
+
+	// inference
+	InferenceMaxDepth: 10,
+
+	// analyze
+	AnalyzeFormat:    "table",
+	AnalyzeTop:       10,
+	AnalyzeBatchSize: 1000,
+
+	// purge
+	PurgeOlderThan: 30 * 24 * time.Hour,
+
+	// copy
+	CopyBatchSize: 500,
+
+	// sync
+	SyncStrategy: "lww",
+	SyncFormat:   "table",
 }
@@ -0,0 +1,448 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/archive"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun/migrations"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// backupBatchSize is how many rows are read or inserted at a time.
+const backupBatchSize = 500
+
+// ErrNotEmpty is returned when restoring into a database that has rows without merging.
+var ErrNotEmpty = errors.New("database isn't empty")
+
+// ErrMergeConflict is returned when merging an archive whose rows are different rows in the database, which happens
+// when the databases diverged. Those are copied instead, which gives the rows new ids.
+var ErrMergeConflict = errors.New("archive conflicts with the database")
+
+// RestoreResult counts the rows restored and skipped of every table.
+type RestoreResult struct {
+	Manifest *archive.Manifest
+	Restored map[string]int64
+	// Skipped counts the rows not merged because they're already there.
+	Skipped map[string]int64
+}
+
+// Backup writes every row, the trash and change journal included, as an archive to w. The schema must match the
+// registered migrations. The rows are read in one transaction so the archive is consistent.
+func (c *Client) Backup(ctx context.Context, w io.Writer) (*archive.Manifest, error) {
+	ctx, span := tracer.Start(ctx, "Backup", tracerAttrs...)
+	defer span.End()
+
+	if err := c.CheckSchema(ctx); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	schemaVersion, err := c.schemaVersion(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	manifest := &archive.Manifest{
+		Format:        archive.Format,
+		Version:       archive.Version,
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: schemaVersion,
+	}
+
+	// the rows are read twice, first to write the manifest with their counts and checksums, then to write them
+	err = c.conn.RunInTx(ctx, c.snapshotTxOptions(), func(ctx context.Context, tx bun.Tx) error {
+		summer := archive.NewSummer()
+		if err := exportRows(ctx, tx, summer.Add); err != nil {
+			return err
+		}
+		manifest.Tables = summer.Sums()
+
+		writer, err := archive.NewWriter(w, manifest)
+		if err != nil {
+			return err
+		}
+		if err := exportRows(ctx, tx, writer.Write); err != nil {
+			return err
+		}
+
+		return writer.Close()
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+
+	return manifest, nil
+}
+
+// Restore inserts the rows of the archive in r with their ids and timestamps in one transaction, which is rolled back
+// if the archive doesn't match its manifest. Unless merge is set the database must be empty, with merge set rows already
+// there are skipped. Merging fails with ErrMergeConflict if an archived row's id, global id or alias is taken by a
+// different row, the archive is from a database that diverged.
+func (c *Client) Restore(ctx context.Context, r io.Reader, merge bool) (*RestoreResult, error) {
+	ctx, span := tracer.Start(ctx, "Restore", tracerAttrs...)
+	defer span.End()
+
+	reader, err := archive.NewReader(r)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	result := &RestoreResult{
+		Manifest: reader.Manifest(),
+		Restored: make(map[string]int64, len(archive.Tables)),
+		Skipped:  make(map[string]int64, len(archive.Tables)),
+	}
+	if !knownMigration(result.Manifest.SchemaVersion) {
+		err := fmt.Errorf("archive has schema version %s, which this version doesn't know", result.Manifest.SchemaVersion)
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := c.CheckSchema(ctx); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	err = c.conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if !merge {
+			if err := checkEmpty(ctx, tx); err != nil {
+				return err
+			}
+		}
+
+		table := ""
+		var batch []json.RawMessage
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			restored, err := importRows(ctx, tx, table, batch, merge)
+			if err != nil {
+				return fmt.Errorf("can't restore %s: %w", table, err)
+			}
+			result.Restored[table] += restored
+			result.Skipped[table] += int64(len(batch)) - restored
+			batch = batch[:0]
+
+			return nil
+		}
+
+		for {
+			next, row, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if next != table || len(batch) == backupBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+				table = next
+			}
+			batch = append(batch, row)
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		return c.resetSequences(ctx, tx)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+
+	return result, nil
+}
+
+// snapshotTxOptions returns the options of a transaction that reads a consistent snapshot. SQLite transactions are
+// always serializable.
+func (c *Client) snapshotTxOptions() *sql.TxOptions {
+	switch c.conn.Dialect().Name() {
+	case dialect.PG, dialect.MySQL:
+		return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	default:
+		return nil
+	}
+}
+
+// schemaVersion returns the name of the last migration applied.
+func (c *Client) schemaVersion(ctx context.Context) (string, error) {
+	ms, err := c.newMigrator().MigrationsWithStatus(ctx)
+	if err != nil {
+		return "", fmt.Errorf("can't read migration status: %w", c.ProcessError(err))
+	}
+
+	version := ""
+	for _, m := range ms.Applied() {
+		version = max(version, m.Name)
+	}
+
+	return version, nil
+}
+
+func knownMigration(name string) bool {
+	for _, m := range migrations.Migrations.Sorted() {
+		if m.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exportRows calls write with the archive row of every row of every table, in the order of archive.Tables.
+func exportRows(ctx context.Context, idb bun.IDB, write func(table string, row any) error) error {
+	if err := eachRow(ctx, idb, func(e *models.Entity) int64 { return e.ID }, func(e *models.Entity) error {
+		return write(archive.TableEntities, archive.FromEntity(e))
+	}); err != nil {
+		return err
+	}
+	if err := eachRow(ctx, idb, func(a *models.EntityAlias) int64 { return a.ID }, func(a *models.EntityAlias) error {
+		return write(archive.TableEntityAliases, archive.FromEntityAlias(a))
+	}); err != nil {
+		return err
+	}
+	if err := eachRow(ctx, idb, func(o *models.Observation) int64 { return o.ID }, func(o *models.Observation) error {
+		return write(archive.TableObservations, archive.FromObservation(o))
+	}); err != nil {
+		return err
+	}
+	if err := eachRow(ctx, idb, func(r *models.Relation) int64 { return r.ID }, func(r *models.Relation) error {
+		return write(archive.TableRelations, archive.FromRelation(r))
+	}); err != nil {
+		return err
+	}
+	if err := eachRow(ctx, idb, func(o *models.Ontology) int64 { return o.ID }, func(o *models.Ontology) error {
+		return write(archive.TableOntologies, archive.FromOntology(o))
+	}); err != nil {
+		return err
+	}
+
+	return eachRow(ctx, idb, func(c *models.Change) int64 { return c.ID }, func(c *models.Change) error {
+		return write(archive.TableChanges, archive.FromChange(c))
+	})
+}
+
+// eachRow calls fn with every row of the table of T in id order, soft deleted rows included, reading them in batches.
+func eachRow[T any](ctx context.Context, idb bun.IDB, id func(*T) int64, fn func(*T) error) error {
+	after := int64(0)
+	for {
+		var rows []*T
+		query := idb.NewSelect().
+			Model(&rows).
+			Where("? > ?", bun.Ident("id"), after).
+			Order("id").
+			Limit(backupBatchSize)
+		err := withTrash(idb, query, reflect.TypeFor[T]()).Scan(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < backupBatchSize {
+			return nil
+		}
+		after = id(rows[len(rows)-1])
+	}
+}
+
+// withTrash makes query select soft deleted rows too if the model of type typ is soft deleted.
+func withTrash(idb bun.IDB, query *bun.SelectQuery, typ reflect.Type) *bun.SelectQuery {
+	if idb.Dialect().Tables().Get(typ).SoftDeleteField == nil {
+		return query
+	}
+
+	return query.WhereAllWithDeleted()
+}
+
+// importRows inserts the rows of an archive table and returns how many were inserted.
+func importRows(ctx context.Context, tx bun.Tx, table string, rows []json.RawMessage, merge bool) (int64, error) {
+	switch table {
+	case archive.TableEntities:
+		return insertRows(ctx, tx, table, rows, merge, archive.Entity.Model, func(e *models.Entity) int64 { return e.ID }, globalIDKey(func(e *models.Entity) string { return e.GlobalID }))
+	case archive.TableEntityAliases:
+		return insertRows(ctx, tx, table, rows, merge, archive.EntityAlias.Model, func(a *models.EntityAlias) int64 { return a.ID }, &mergeKey[models.EntityAlias]{
+			column: "normalized",
+			value:  func(a *models.EntityAlias) string { return a.Normalized },
+			same: func(local, archived *models.EntityAlias) bool {
+				return local.EntityID == archived.EntityID && local.Normalized == archived.Normalized
+			},
+		})
+	case archive.TableObservations:
+		return insertRows(ctx, tx, table, rows, merge, archive.Observation.Model, func(o *models.Observation) int64 { return o.ID }, globalIDKey(func(o *models.Observation) string { return o.GlobalID }))
+	case archive.TableRelations:
+		return insertRows(ctx, tx, table, rows, merge, archive.Relation.Model, func(r *models.Relation) int64 { return r.ID }, globalIDKey(func(r *models.Relation) string { return r.GlobalID }))
+	case archive.TableOntologies:
+		return insertRows(ctx, tx, table, rows, merge, archive.Ontology.Model, func(o *models.Ontology) int64 { return o.ID }, &mergeKey[models.Ontology]{
+			same: func(local, archived *models.Ontology) bool { return local.Document == archived.Document },
+		})
+	case archive.TableChanges:
+		return insertRows(ctx, tx, table, rows, merge, archive.Change.Model, func(c *models.Change) int64 { return c.ID }, &mergeKey[models.Change]{
+			same: func(local, archived *models.Change) bool {
+				return local.SessionID == archived.SessionID && local.Action == archived.Action &&
+					local.Kind == archived.Kind && local.RecordID == archived.RecordID
+			},
+		})
+	default:
+		return 0, fmt.Errorf("unknown table %s", table)
+	}
+}
+
+// mergeKey tells if an archived row merged is already in the database.
+type mergeKey[T any] struct {
+	// column is unique across the rows of the table and value returns it, rows without one leave it empty.
+	column string
+	value  func(*T) string
+	// same tells if the row with the archived row's id is the archived row.
+	same func(local, archived *T) bool
+}
+
+// globalIDKey returns the merge key of rows identified by their global id.
+func globalIDKey[T any](globalID func(*T) string) *mergeKey[T] {
+	return &mergeKey[T]{
+		column: "global_id",
+		value:  globalID,
+		same:   func(local, archived *T) bool { return globalID(local) == globalID(archived) },
+	}
+}
+
+// insertRows decodes rows as R and inserts their models. If merge is set, rows already in the database are skipped
+// and rows whose id or key is taken by a different row fail with ErrMergeConflict.
+func insertRows[R any, T any](ctx context.Context, tx bun.Tx, table string, rows []json.RawMessage, merge bool, model func(R) *T, id func(*T) int64, key *mergeKey[T]) (int64, error) {
+	inserts := make([]*T, 0, len(rows))
+	for _, raw := range rows {
+		var row R
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return 0, err
+		}
+		inserts = append(inserts, model(row))
+	}
+
+	if merge {
+		var err error
+		if inserts, err = mergeRows(ctx, tx, table, inserts, id, key); err != nil {
+			return 0, err
+		}
+	}
+	if len(inserts) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.NewInsert().Model(&inserts).Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return int64(len(inserts)), nil
+}
+
+// mergeRows returns the archived rows not in the database yet. A row whose id is taken must be the row there, and the
+// key of the rest mustn't be taken.
+func mergeRows[T any](ctx context.Context, tx bun.Tx, table string, inserts []*T, id func(*T) int64, key *mergeKey[T]) ([]*T, error) {
+	ids := make([]int64, 0, len(inserts))
+	for _, m := range inserts {
+		ids = append(ids, id(m))
+	}
+	var taken []*T
+	query := tx.NewSelect().
+		Model(&taken).
+		Where("? IN (?)", bun.Ident("id"), bun.In(ids))
+	err := withTrash(tx, query, reflect.TypeFor[T]()).Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	local := make(map[int64]*T, len(taken))
+	for _, t := range taken {
+		local[id(t)] = t
+	}
+
+	kept := inserts[:0]
+	values := make([]string, 0, len(inserts))
+	for _, m := range inserts {
+		existing, ok := local[id(m)]
+		switch {
+		case !ok:
+			kept = append(kept, m)
+			if key.column != "" {
+				values = append(values, key.value(m))
+			}
+		case !key.same(existing, m):
+			return nil, fmt.Errorf("%w: row %d of %s is a different row here", ErrMergeConflict, id(m), table)
+		}
+	}
+
+	if len(values) > 0 {
+		var clashes []string
+		query := tx.NewSelect().
+			Model((*T)(nil)).
+			Column(key.column).
+			Where("? IN (?)", bun.Ident(key.column), bun.In(values)).
+			Limit(1)
+		err := withTrash(tx, query, reflect.TypeFor[T]()).Scan(ctx, &clashes)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if len(clashes) > 0 {
+			return nil, fmt.Errorf("%w: %s %s of %s is taken by a different row here", ErrMergeConflict, key.column, clashes[0], table)
+		}
+	}
+
+	return kept, nil
+}
+
+// checkEmpty fails if any table of the archive has rows, the trash included.
+func checkEmpty(ctx context.Context, tx bun.Tx) error {
+	for _, model := range []any{
+		(*models.Entity)(nil),
+		(*models.EntityAlias)(nil),
+		(*models.Observation)(nil),
+		(*models.Relation)(nil),
+		(*models.Ontology)(nil),
+		(*models.Change)(nil),
+	} {
+		query := tx.NewSelect().Model(model)
+		exists, err := withTrash(tx, query, reflect.TypeOf(model).Elem()).Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrNotEmpty
+		}
+	}
+
+	return nil
+}
+
+// resetSequences moves the Postgres id sequences past the restored ids, which were inserted explicitly. MySQL and
+// SQLite do this on their own.
+func (c *Client) resetSequences(ctx context.Context, tx bun.Tx) error {
+	if c.conn.Dialect().Name() != dialect.PG {
+		return nil
+	}
+
+	for _, table := range archive.Tables {
+		_, err := tx.ExecContext(ctx,
+			"SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE(MAX(id), 0) + 1, false) FROM ?",
+			table, bun.Ident(table),
+		)
+		if err != nil {
+			return fmt.Errorf("can't reset the id sequence of %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
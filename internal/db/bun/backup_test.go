@@ -0,0 +1,166 @@
+package bun
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/archive"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func newMigratedClient(t *testing.T, name string) *Client {
+	t.Helper()
+
+	ctx := context.Background()
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: filepath.Join(t.TempDir(), name)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	require.NoError(t, client.DoMigration(ctx))
+
+	return client
+}
+
+func TestClient_BackupRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source := newMigratedClient(t, "source.db")
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, alice))
+	require.NoError(t, source.CreateEntity(ctx, bob))
+	require.NoError(t, source.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: alice.ID, Alias: "Al", Normalized: "al"}))
+	require.NoError(t, source.CreateObservation(ctx, &models.Observation{EntityID: alice.ID, Contents: "likes tea"}))
+	require.NoError(t, source.CreateRelation(ctx, &models.Relation{FromID: alice.ID, ToID: bob.ID, Type: "knows"}))
+	require.NoError(t, source.CreateOntology(ctx, &models.Ontology{Document: "entityTypes: []"}))
+	require.NoError(t, source.DeleteEntity(ctx, bob))
+
+	buf := &bytes.Buffer{}
+	manifest, err := source.Backup(ctx, buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), manifest.Table(archive.TableEntities).Count, "trash included")
+	archived := buf.Bytes()
+
+	target := newMigratedClient(t, "target.db")
+	result, err := target.Restore(ctx, bytes.NewReader(archived), false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Restored[archive.TableEntities])
+	assert.Equal(t, int64(1), result.Restored[archive.TableEntityAliases])
+
+	original, err := source.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	restored, err := target.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, restored.ID)
+	assert.Equal(t, original.GlobalID, restored.GlobalID)
+	assert.True(t, original.CreatedAt.Equal(restored.CreatedAt), "%s != %s", original.CreatedAt, restored.CreatedAt)
+	deleted, err := target.ReadDeletedEntityByName(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, deleted.ID)
+	counts, err := target.CountGraph(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.GraphCounts{Entities: 1, Observations: 1, Relations: 1}, counts)
+
+	// new rows get ids after the restored ones
+	carol := &models.Entity{Name: "carol", Type: "person"}
+	require.NoError(t, target.CreateEntity(ctx, carol))
+	assert.Greater(t, carol.ID, bob.ID)
+
+	_, err = target.Restore(ctx, bytes.NewReader(archived), false)
+	assert.ErrorContains(t, err, "isn't empty")
+
+	result, err = target.Restore(ctx, bytes.NewReader(archived), true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Restored[archive.TableEntities])
+	assert.Equal(t, int64(2), result.Skipped[archive.TableEntities])
+
+	// a corrupted archive is rolled back
+	corrupted := newMigratedClient(t, "corrupted.db")
+	_, err = corrupted.Restore(ctx, bytes.NewReader(archived[:len(archived)-20]), false)
+	assert.Error(t, err)
+	counts, err = corrupted.CountGraph(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.GraphCounts{}, counts)
+}
+
+func TestClient_RestoreMerge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source := newMigratedClient(t, "source.db")
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, alice))
+	buf := &bytes.Buffer{}
+	_, err := source.Backup(ctx, buf)
+	require.NoError(t, err)
+	base := buf.Bytes()
+
+	target := newMigratedClient(t, "target.db")
+	_, err = target.Restore(ctx, bytes.NewReader(base), false)
+	require.NoError(t, err)
+
+	// rows added to the source since are merged
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, bob))
+	require.NoError(t, source.CreateObservation(ctx, &models.Observation{EntityID: bob.ID, Contents: "likes coffee"}))
+	buf = &bytes.Buffer{}
+	_, err = source.Backup(ctx, buf)
+	require.NoError(t, err)
+	result, err := target.Restore(ctx, bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Restored[archive.TableEntities])
+	assert.Equal(t, int64(1), result.Skipped[archive.TableEntities])
+	assert.Equal(t, int64(1), result.Restored[archive.TableObservations])
+
+	t.Run("diverged ids", func(t *testing.T) {
+		t.Parallel()
+
+		diverged := newMigratedClient(t, "diverged.db")
+		_, err := diverged.Restore(ctx, bytes.NewReader(base), false)
+		require.NoError(t, err)
+		// carol takes the id bob has in the archive
+		carol := &models.Entity{Name: "carol", Type: "person"}
+		require.NoError(t, diverged.CreateEntity(ctx, carol))
+		require.Equal(t, bob.ID, carol.ID)
+
+		_, err = diverged.Restore(ctx, bytes.NewReader(buf.Bytes()), true)
+		require.ErrorIs(t, err, ErrMergeConflict)
+
+		// nothing was merged
+		_, err = diverged.ReadEntityByName(ctx, "bob")
+		assert.Error(t, err)
+		counts, err := diverged.CountGraph(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &models.GraphCounts{Entities: 2}, counts)
+	})
+
+	t.Run("diverged aliases", func(t *testing.T) {
+		t.Parallel()
+
+		diverged := newMigratedClient(t, "diverged.db")
+		aliased := newMigratedClient(t, "aliased.db")
+		for _, client := range []*Client{diverged, aliased} {
+			_, err := client.Restore(ctx, bytes.NewReader(base), false)
+			require.NoError(t, err)
+		}
+		// the same alias is given to alice in one database and a new entity in the other, under another id
+		ally := &models.EntityAlias{EntityID: alice.ID, Alias: "Ally", Normalized: "ally"}
+		require.NoError(t, aliased.CreateEntityAlias(ctx, ally))
+		require.NoError(t, aliased.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: alice.ID, Alias: "Al", Normalized: "al"}))
+		require.NoError(t, aliased.DeleteEntityAlias(ctx, ally))
+		al := &models.Entity{Name: "al", Type: "person"}
+		require.NoError(t, diverged.CreateEntity(ctx, al))
+		require.NoError(t, diverged.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: al.ID, Alias: "Al", Normalized: "al"}))
+
+		archived := &bytes.Buffer{}
+		_, err := aliased.Backup(ctx, archived)
+		require.NoError(t, err)
+		_, err = diverged.Restore(ctx, bytes.NewReader(archived.Bytes()), true)
+		assert.ErrorIs(t, err, ErrMergeConflict)
+		assert.ErrorContains(t, err, "normalized al")
+	})
+}
@@ -0,0 +1,73 @@
+package bun
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+)
+
+func (c *Client) CreateChange(ctx context.Context, change *models.Change) db.Error {
+	ctx, span := tracer.Start(ctx, "CreateChange", tracerAttrs...)
+	defer span.End()
+
+	c.remember(change)
+
+	query := c.db.NewInsert().
+		Model(change)
+
+	if _, err := query.Exec(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) ReadChangesAfterID(ctx context.Context, id int64) ([]*models.Change, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadChangesAfterID", tracerAttrs...)
+	defer span.End()
+
+	var changes []*models.Change
+	query := newChangesQ(c.db, &changes).
+		Where("id > ?", id)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (c *Client) ReadChangesSince(ctx context.Context, since time.Time) ([]*models.Change, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadChangesSince", tracerAttrs...)
+	defer span.End()
+
+	var changes []*models.Change
+	query := newChangesQ(c.db, &changes).
+		Where("created_at > ?", since.UTC())
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func newChangesQ(c bun.IDB, i *[]*models.Change) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model(i).
+		Order("id ASC")
+}
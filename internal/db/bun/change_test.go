@@ -0,0 +1,40 @@
+package bun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestClient_ReadChangesSince(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newMigratedClient(t, "changes.db")
+	newChange := func(recordID int64) *models.Change {
+		change := &models.Change{SessionID: "session", Action: models.ChangeActionCreate, Kind: models.ChangeKindEntity, RecordID: recordID, Data: "{}"}
+		require.NoError(t, client.CreateChange(ctx, change))
+		return change
+	}
+
+	before := newChange(1)
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	after := newChange(2)
+
+	// both changes are usually made within the same second
+	changes, err := client.ReadChangesSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, after.ID, changes[0].ID)
+	assert.True(t, changes[0].CreatedAt.After(since))
+
+	changes, err = client.ReadChangesSince(ctx, before.CreatedAt.Add(-time.Millisecond))
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+}
@@ -2,30 +2,27 @@ package bun
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"database/sql"
-	"encoding/pem"
 	"errors"
 	"fmt"
-	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tyrm/mcp-dbmem/internal/config"
 	"github.com/tyrm/mcp-dbmem/internal/db"
-	"github.com/tyrm/mcp-dbmem/internal/db/bun/migrations"
+	"github.com/tyrm/mcp-dbmem/internal/models"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/mysqldialect"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/extra/bunotel"
-	"github.com/uptrace/bun/migrate"
 	"go.uber.org/zap"
 	"modernc.org/sqlite"
 )
@@ -35,31 +32,76 @@ const (
 	dbTypePostgres = "postgres"
 	dbTypeSqlite   = "sqlite"
 
-	dbTLSModeDisable = "disable"
-	dbTLSModeEnable  = "enable"
-	dbTLSModeRequire = "require"
-	dbTLSModeUnset   = ""
-
 	openConnectionsPerCore = 4
 )
 
 // Client is a DB interface compatible client for Bun.
 type Client struct {
-	db      *bun.DB
+	conn    *bun.DB
+	db      bun.IDB
 	errProc func(error) db.Error
+	// tx is set on clients bound to a transaction.
+	tx *txState
+
+	retryConfig          RetryConfig
+	migrationLockTimeout time.Duration
+	// retries counts the operations retried by operation and reason, nil if metrics aren't recorded.
+	retries *prometheus.CounterVec
 }
 
 var _ db.DB = (*Client)(nil)
 
 type ClientConfig struct {
-	Type      string
-	Address   string
-	Port      uint16
-	User      string
-	Password  string
-	Database  string
+	// URL is a postgres://, mysql://, sqlite:// or file: connection url. It overrides the type, connection and TLS
+	// fields if it's set.
+	URL      string
+	Type     string
+	Address  string
+	Port     uint16
+	User     string
+	Password string
+	Database string
+	// TLSMode is one of disable, enable (encrypt without verifying the server), verify-ca (verify the server
+	// certificate was issued by a trusted CA), or require and verify-full (also verify it's for the server name).
 	TLSMode   string
 	TLSCACert string
+	// TLSClientCert and TLSClientKey are the certificate and key presented to servers requiring mutual TLS.
+	TLSClientCert string
+	TLSClientKey  string
+	// TLSServerName is the name the server certificate is verified for, the address if it's empty.
+	TLSServerName string
+	// TLSMinVersion is the lowest TLS version accepted, 1.2 if it's empty.
+	TLSMinVersion string
+	// SQLite configures SQLite connections, DefaultSQLiteConfig is used if it's nil.
+	SQLite *SQLiteConfig
+	// MaxOpenConns and MaxIdleConns limit the connection pool, 0 allows 4 connections per CPU.
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime and ConnMaxIdleTime close connections after they've been open or idle this long, 0 keeps them.
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout makes the server cancel statements running longer, 0 is no limit. SQLite doesn't support it.
+	StatementTimeout time.Duration
+	// Wait is how long to wait for the database to come up when connecting.
+	Wait time.Duration
+	// Retry says how reads and transactions failing with a transient error are retried, DefaultRetryConfig is used if
+	// it's nil.
+	Retry *RetryConfig
+	// MigrationLockTimeout is how long migrating waits for another migrator to finish, DefaultMigrationLockTimeout
+	// if it's 0.
+	MigrationLockTimeout time.Duration
+	// Metrics is the registry the connection pool stats, query latencies and retries are added to, they aren't
+	// recorded if it's nil.
+	Metrics prometheus.Registerer
+}
+
+// retryConfig returns the retry policy of the client.
+func (cfg ClientConfig) retryConfig() RetryConfig {
+	if cfg.Retry == nil {
+		return DefaultRetryConfig
+	}
+
+	return *cfg.Retry
 }
 
 // New creates a new bun database client.
@@ -67,6 +109,12 @@ func New(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	var newBun *Client
 	var err error
 	dbType := cfg.Type
+	if cfg.URL != "" {
+		dbType, err = typeFromURL(cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	switch dbType {
 	case dbTypeMysql:
@@ -88,7 +136,18 @@ func New(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("database type %s not supported for bundb", dbType)
 	}
 
-	newBun.db.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName(cfg.Database)))
+	newBun.migrationLockTimeout = cfg.MigrationLockTimeout
+	newBun.conn.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName(cfg.Database)))
+	if cfg.Metrics != nil {
+		dbName := cfg.Database
+		if dbName == "" {
+			dbName = dbType
+		}
+		if err := newBun.registerMetrics(cfg.Metrics, dbName); err != nil {
+			_ = newBun.Close()
+			return nil, fmt.Errorf("can't register database metrics: %w", err)
+		}
+	}
 
 	// Add a query hook to log all queries (debug)
 	// newBun.db.AddQueryHook(bunzap.NewQueryHook(bunzap.QueryHookOptions{
@@ -104,16 +163,40 @@ func New(ctx context.Context, cfg ClientConfig) (*Client, error) {
 func sqliteConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	// validate bun address has actually been set
 	dbAddress := cfg.Address
+	var params string
+	if cfg.URL != "" {
+		dbAddress, params = sqliteURL(cfg.URL)
+	}
 	if dbAddress == "" {
-		return nil, fmt.Errorf("'%s' was not set when attempting to start sqlite", cfg.Address)
+		return nil, errors.New("no sqlite database path set")
+	}
+
+	if cfg.URL == "" {
+		// Drop anything fancy from DB address
+		dbAddress = strings.Split(dbAddress, "?")[0]
+		dbAddress = strings.TrimPrefix(dbAddress, "file:")
 	}
 
-	// Drop anything fancy from DB address
-	dbAddress = strings.Split(dbAddress, "?")[0]
-	dbAddress = strings.TrimPrefix(dbAddress, "file:")
+	inMemory := dbAddress == ":memory:"
+
+	sqliteConfig := DefaultSQLiteConfig
+	if cfg.SQLite != nil {
+		sqliteConfig = *cfg.SQLite
+	}
+	pragmas, err := sqliteConfig.pragmas()
+	if err != nil {
+		return nil, err
+	}
 
 	// Append our own SQLite preferences
-	dbAddress = "file:" + dbAddress + "?cache=shared"
+	dbAddress = "file:" + dbAddress + "?cache=shared&" + pragmas
+	if params != "" {
+		dbAddress += "&" + params
+	}
+
+	if cfg.StatementTimeout > 0 {
+		zap.L().Warn("sqlite doesn't support a statement timeout, ignoring it")
+	}
 
 	// Open new DB instance
 	sqldb, err := sql.Open("sqlite", dbAddress)
@@ -125,20 +208,21 @@ func sqliteConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("could not open sqlite bun: %w", err)
 	}
 
-	setConnectionValues(sqldb)
+	setConnectionValues(sqldb, cfg)
 
-	if dbAddress == "file::memory:?cache=shared" {
+	if inMemory {
 		zap.L().Warn("sqlite in-memory database should only be used for debugging")
 		// don't close connections on disconnect -- otherwise
 		// the SQLite database will be deleted when there
 		// are no active connections
 		sqldb.SetConnMaxLifetime(0)
+		sqldb.SetConnMaxIdleTime(0)
 	}
 
-	conn := getErrConn(bun.NewDB(sqldb, sqlitedialect.New()))
+	conn := getErrConn(bun.NewDB(sqldb, sqlitedialect.New()), cfg.retryConfig())
 
 	// ping to check the bun is there and listening
-	if err := conn.db.PingContext(ctx); err != nil {
+	if err := conn.ping(ctx, cfg.Wait); err != nil {
 		errWithCode := &sqlite.Error{}
 		if errors.As(err, &errWithCode) {
 			err = errors.New(sqlite.ErrorCodeString[errWithCode.Code()])
@@ -146,6 +230,10 @@ func sqliteConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("sqlite ping: %w", err)
 	}
 
+	if err := verifySQLitePragmas(ctx, conn.conn, sqliteConfig, inMemory); err != nil {
+		return nil, err
+	}
+
 	zap.L().Info("Connected to database", zap.String("db_type", "sqlite"))
 	return conn, nil
 }
@@ -156,21 +244,47 @@ func myConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("could not create bundb mysql options: %w", err)
 	}
 
-	sqldb, err := sql.Open("mysql", opts)
+	conn, err := openMySQL(ctx, opts, cfg, cfg.Wait)
+	if err != nil {
+		return nil, err
+	}
+
+	// the statement timeout is a different system variable on MariaDB and MySQL, and MySQL rejects the MariaDB one
+	if cfg.StatementTimeout > 0 {
+		var version string
+		if err := conn.conn.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+			_ = conn.conn.Close()
+			return nil, fmt.Errorf("mysql version: %w", err)
+		}
+		_ = conn.conn.Close()
+
+		conn, err = openMySQL(ctx, withMySQLStatementTimeout(opts, cfg.StatementTimeout, isMariaDB(version)), cfg, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	zap.L().Info("Connected to database", zap.String("db_type", "mysql"))
+	return conn, nil
+}
+
+// openMySQL opens a connection pool to dsn and pings it, waiting up to wait for the server to come up.
+func openMySQL(ctx context.Context, dsn string, cfg ClientConfig, wait time.Duration) (*Client, error) {
+	sqldb, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("could not open mysql connection: %w", err)
 	}
 
-	setConnectionValues(sqldb)
+	setConnectionValues(sqldb, cfg)
 
-	conn := getErrConn(bun.NewDB(sqldb, mysqldialect.New()))
+	conn := getErrConn(bun.NewDB(sqldb, mysqldialect.New()), cfg.retryConfig())
 
 	// ping to check the bun is there and listening
-	if err := conn.db.PingContext(ctx); err != nil {
+	if err := conn.ping(ctx, wait); err != nil {
+		_ = sqldb.Close()
 		return nil, fmt.Errorf("mysql ping: %w", err)
 	}
 
-	zap.L().Info("Connected to database", zap.String("db_type", "mysql"))
 	return conn, nil
 }
 
@@ -182,12 +296,12 @@ func pgConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 
 	sqldb := stdlib.OpenDB(*opts)
 
-	setConnectionValues(sqldb)
+	setConnectionValues(sqldb, cfg)
 
-	conn := getErrConn(bun.NewDB(sqldb, pgdialect.New()))
+	conn := getErrConn(bun.NewDB(sqldb, pgdialect.New()), cfg.retryConfig())
 
 	// ping to check the bun is there and listening
-	if err := conn.db.PingContext(ctx); err != nil {
+	if err := conn.ping(ctx, cfg.Wait); err != nil {
 		return nil, fmt.Errorf("postgres ping: %w", err)
 	}
 
@@ -196,6 +310,15 @@ func pgConn(ctx context.Context, cfg ClientConfig) (*Client, error) {
 }
 
 func deriveBunDBMyOptions(cfg ClientConfig) (string, error) {
+	if cfg.URL != "" {
+		dsn, err := mysqlDSN(cfg.URL)
+		if err != nil {
+			return "", err
+		}
+
+		return dsn, nil
+	}
+
 	// these are all optional, the bun adapter figures out defaults
 	port := cfg.Port
 	address := cfg.Address
@@ -243,7 +366,46 @@ func deriveBunDBMyOptions(cfg ClientConfig) (string, error) {
 	return mysqlOptions, nil
 }
 
+// withMySQLStatementTimeout sets the system variable cancelling long statements in dsn. MariaDB's max_statement_time
+// is in seconds and covers every statement, MySQL's max_execution_time is in milliseconds and only covers SELECTs.
+func withMySQLStatementTimeout(dsn string, timeout time.Duration, mariaDB bool) string {
+	if timeout <= 0 {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	if mariaDB {
+		return dsn + separator + "max_statement_time=" + strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64)
+	}
+
+	return dsn + separator + "max_execution_time=" + strconv.FormatInt(max(timeout.Milliseconds(), 1), 10)
+}
+
+// isMariaDB returns true if version, as returned by VERSION(), is a MariaDB server's.
+func isMariaDB(version string) bool {
+	return strings.Contains(strings.ToLower(version), "mariadb")
+}
+
 func deriveBunDBPGOptions(cfg ClientConfig) (*pgx.ConnConfig, error) {
+	if cfg.URL != "" {
+		// pgx understands Unix sockets and any extra params itself
+		postgresConfig, err := pgx.ParseConfig(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid postgres url: %w", err)
+		}
+		if _, ok := postgresConfig.RuntimeParams["application_name"]; !ok {
+			postgresConfig.RuntimeParams["application_name"] = config.ApplicationName
+		}
+		postgresConfig.PreferSimpleProtocol = true
+		setPGStatementTimeout(postgresConfig, cfg.StatementTimeout)
+
+		return postgresConfig, nil
+	}
+
 	// these are all optional, the bun adapter figures out defaults
 	port := cfg.Port
 	address := cfg.Address
@@ -276,91 +438,45 @@ func deriveBunDBPGOptions(cfg ClientConfig) (*pgx.ConnConfig, error) {
 	if password != "" {
 		postgresConfig.Password = password
 	}
-	if tlsConfig != nil {
-		postgresConfig.TLSConfig = tlsConfig
-	}
+	// the parsed defaults are for the default host with sslmode=prefer, falling back to plaintext if the handshake
+	// fails, only the configured tls mode is used
+	postgresConfig.TLSConfig = tlsConfig
+	postgresConfig.Fallbacks = nil
 	postgresConfig.Database = database
 	postgresConfig.PreferSimpleProtocol = true
 	postgresConfig.RuntimeParams["application_name"] = config.ApplicationName
+	setPGStatementTimeout(postgresConfig, cfg.StatementTimeout)
 
 	return postgresConfig, nil
 }
 
-// https://bun.uptrace.dev/postgres/running-bun-in-production.html#database-sql
-func setConnectionValues(sqldb *sql.DB) {
-	maxOpenConns := openConnectionsPerCore * runtime.GOMAXPROCS(0)
-	sqldb.SetMaxOpenConns(maxOpenConns)
-	sqldb.SetMaxIdleConns(maxOpenConns)
-}
-
-func makeTLSConfig(cfg ClientConfig) (*tls.Config, error) {
-	var tlsConfig *tls.Config
-	tlsMode := cfg.TLSMode
-	switch tlsMode {
-	case dbTLSModeDisable, dbTLSModeUnset:
-		break // nothing to do
-	case dbTLSModeEnable:
-		/* #nosec G402 */
-		tlsConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-	case dbTLSModeRequire:
-		tlsConfig = &tls.Config{
-			InsecureSkipVerify: false,
-			ServerName:         cfg.Address,
-			MinVersion:         tls.VersionTLS12,
-		}
-	}
-
-	caCertPath := cfg.TLSCACert
-	if tlsConfig != nil && caCertPath != "" {
-		// load the system cert pool first -- we'll append the given CA cert to this
-		certPool, err := x509.SystemCertPool()
-		if err != nil {
-			return nil, fmt.Errorf("error fetching system CA cert pool: %w", err)
-		}
-
-		// read the CA cert from the file
-		caCert, err := readCertFile(caCertPath)
-		if err != nil {
-			return nil, fmt.Errorf("error reading CA cert file %s: %w", caCertPath, err)
-		}
-
-		// we're happy, add it to the existing pool and then use this pool in our tls config
-		certPool.AddCert(caCert)
-		tlsConfig.RootCAs = certPool
+// setPGStatementTimeout sets the statement_timeout run-time parameter if there is a timeout.
+func setPGStatementTimeout(postgresConfig *pgx.ConnConfig, timeout time.Duration) {
+	if timeout <= 0 {
+		return
 	}
 
-	return tlsConfig, nil
+	postgresConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
 }
 
-func readCertFile(caCertPath string) (*x509.Certificate, error) {
-	// open the file itself and make sure there's something in it
-	/* #nosec G304 */
-	caCertBytes, err := os.ReadFile(caCertPath)
-	if err != nil {
-		return nil, fmt.Errorf("error opening CA certificate at %s: %w", caCertPath, err)
-	}
-	if len(caCertBytes) == 0 {
-		return nil, fmt.Errorf("ca cert at %s was empty", caCertPath)
-	}
-
-	// make sure we have a PEM block
-	caPem, _ := pem.Decode(caCertBytes)
-	if caPem == nil {
-		return nil, fmt.Errorf("could not parse cert at %s into PEM", caCertPath)
+// https://bun.uptrace.dev/postgres/running-bun-in-production.html#database-sql
+func setConnectionValues(sqldb *sql.DB, cfg ClientConfig) {
+	maxOpenConns := cfg.MaxOpenConns
+	if maxOpenConns <= 0 {
+		maxOpenConns = openConnectionsPerCore * runtime.GOMAXPROCS(0)
 	}
-
-	// parse the PEM block into the certificate
-	caCert, err := x509.ParseCertificate(caPem.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("could not parse cert at %s into x509 certificate: %w", caCertPath, err)
+	maxIdleConns := cfg.MaxIdleConns
+	if maxIdleConns <= 0 || maxIdleConns > maxOpenConns {
+		maxIdleConns = maxOpenConns
 	}
 
-	return caCert, nil
+	sqldb.SetMaxOpenConns(maxOpenConns)
+	sqldb.SetMaxIdleConns(maxIdleConns)
+	sqldb.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqldb.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 }
 
-func getErrConn(dbConn *bun.DB) *Client {
+func getErrConn(dbConn *bun.DB, retryConfig RetryConfig) *Client {
 	var errProc func(error) db.Error
 	switch dbConn.Dialect().Name() {
 	case dialect.PG:
@@ -379,7 +495,10 @@ func getErrConn(dbConn *bun.DB) *Client {
 	}
 	return &Client{
 		errProc: errProc,
+		conn:    dbConn,
 		db:      dbConn,
+
+		retryConfig: retryConfig,
 	}
 }
 
@@ -387,32 +506,56 @@ func getErrConn(dbConn *bun.DB) *Client {
 func (c *Client) Close() db.Error {
 	zap.L().Info("Closing db connection", zap.String("db_dialect", c.db.Dialect().Name().String()))
 
-	return c.db.Close()
+	return c.conn.Close()
 }
 
-// DoMigration runs schema migrations on the database.
+// DoMigration runs schema migrations on the database and checks it enforces their foreign key cascades.
 func (c *Client) DoMigration(ctx context.Context) db.Error {
-	migrator := migrate.NewMigrator(c.db, migrations.Migrations)
-
-	if err := migrator.Init(ctx); err != nil {
+	if err := c.migrate(ctx); err != nil {
 		return err
 	}
 
-	group, err := migrator.Migrate(ctx)
-	if err != nil {
-		if err.Error() == "migrate: there are no any migrations" {
-			zap.L().Info("No migrations to run", zap.String("db_dialect", c.db.Dialect().Name().String()))
-			return nil
+	return c.checkCascades(ctx)
+}
+
+// errCascadeCheck rolls back the rows created to check cascades.
+var errCascadeCheck = errors.New("cascade check")
+
+// checkCascades hard deletes an entity with an observation in a transaction that is rolled back, and fails if the
+// observation is left behind.
+func (c *Client) checkCascades(ctx context.Context) error {
+	cascaded := false
+	err := c.conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		entity := &models.Entity{Name: "cascade check", Type: "cascade check"}
+		if _, err := tx.NewInsert().Model(entity).Exec(ctx); err != nil {
+			return err
+		}
+		observation := &models.Observation{EntityID: entity.ID, Contents: "cascade check"}
+		if _, err := tx.NewInsert().Model(observation).Exec(ctx); err != nil {
+			return err
 		}
 
-		return err
+		if _, err := tx.NewDelete().Model(entity).WherePK().ForceDelete().Exec(ctx); err != nil {
+			return err
+		}
+		left, err := tx.NewSelect().Model((*models.Observation)(nil)).WhereAllWithDeleted().Where("id = ?", observation.ID).Count(ctx)
+		if err != nil {
+			return err
+		}
+		cascaded = left == 0
+
+		return errCascadeCheck
+	})
+	if !errors.Is(err, errCascadeCheck) {
+		return fmt.Errorf("can't check foreign key cascades: %w", err)
 	}
 
-	if group.ID == 0 {
-		zap.L().Info("No migrations to run", zap.String("db_dialect", c.db.Dialect().Name().String()))
-		return nil
+	if !cascaded {
+		if c.db.Dialect().Name() == dialect.SQLite {
+			return fmt.Errorf("foreign key cascades aren't enforced, enable --%s", config.Keys.DBSQLiteForeignKeys)
+		}
+		return errors.New("foreign key cascades aren't enforced by the database")
 	}
-	zap.L().Info("Migration successful", zap.String("db_dialect", c.db.Dialect().Name().String()), zap.String("group", group.String()))
 
 	return nil
 }
@@ -2,14 +2,19 @@ package bun
 
 import (
 	"context"
+	"time"
 
 	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
 )
 
 func (c *Client) create(ctx context.Context, model any) db.Error {
 	ctx, span := tracer.Start(ctx, "create", tracerAttrs...)
 	defer span.End()
 
+	c.remember(model)
+
 	query := c.db.NewInsert().
 		Model(model).
 		ExcludeColumn("created_at", "updated_at")
@@ -22,13 +27,17 @@ func (c *Client) create(ctx context.Context, model any) db.Error {
 	return nil
 }
 
+// delete moves model to the trash using the deleted_at value already set on it.
 func (c *Client) delete(ctx context.Context, model any) db.Error {
 	ctx, span := tracer.Start(ctx, "delete", tracerAttrs...)
 	defer span.End()
 
+	c.remember(model)
+
 	query := c.db.
-		NewDelete().
+		NewUpdate().
 		Model(model).
+		Column("deleted_at").
 		WherePK()
 
 	if _, err := query.Exec(ctx); err != nil {
@@ -38,3 +47,73 @@ func (c *Client) delete(ctx context.Context, model any) db.Error {
 
 	return nil
 }
+
+// restore takes model out of the trash. If it was purged it is inserted again keeping its primary key and timestamps.
+func (c *Client) restore(ctx context.Context, model any) db.Error {
+	ctx, span := tracer.Start(ctx, "restore", tracerAttrs...)
+	defer span.End()
+
+	c.remember(model)
+
+	result, err := c.db.NewUpdate().
+		Model(model).
+		Set("deleted_at = NULL").
+		WherePK().
+		WhereDeleted().
+		Exec(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+	if restored, err := result.RowsAffected(); err == nil && restored > 0 {
+		return nil
+	}
+
+	query := c.db.NewInsert().
+		Model(model)
+
+	if _, err := query.Exec(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+// purge runs a query permanently removing rows from the trash and returns how many were removed.
+func (c *Client) purge(ctx context.Context, query *bun.DeleteQuery) (int64, db.Error) {
+	ctx, span := tracer.Start(ctx, "purge", tracerAttrs...)
+	defer span.End()
+
+	result, err := query.ForceDelete().Exec(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return 0, c.ProcessError(err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		span.RecordError(err)
+		return 0, c.ProcessError(err)
+	}
+
+	return purged, nil
+}
+
+// newLiveEntityIDsQ selects the ids of entities that aren't in the trash.
+func newLiveEntityIDsQ(c bun.IDB) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model((*models.Entity)(nil)).
+		Column("id")
+}
+
+// newDeletedEntityIDsQ selects the ids of entities moved to the trash before deletedBefore.
+func newDeletedEntityIDsQ(c bun.IDB, deletedBefore time.Time) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model((*models.Entity)(nil)).
+		Column("id").
+		WhereDeleted().
+		Where("deleted_at < ?", deletedBefore)
+}
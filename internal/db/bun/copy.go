@@ -0,0 +1,482 @@
+package bun
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tyrm/mcp-dbmem/internal/archive"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+)
+
+// NamespaceSeparator separates the namespace from the names and aliases of entities copied into one.
+const NamespaceSeparator = "/"
+
+// CopyTables are the tables copied, in the order they're copied.
+var CopyTables = []string{
+	archive.TableEntities,
+	archive.TableEntityAliases,
+	archive.TableObservations,
+	archive.TableRelations,
+}
+
+// CopyConfig configures copying a database into another.
+type CopyConfig struct {
+	// Source identifies the source database, copying the same source again resumes where the last copy stopped. It
+	// shouldn't hold secrets, only a hash of it is stored.
+	Source string
+	// Namespace prefixes the names and aliases of the copied entities with the namespace and NamespaceSeparator if
+	// it's set.
+	Namespace string
+	// BatchSize is how many rows are copied per transaction, 500 if it's 0.
+	BatchSize int
+}
+
+// sourceKey returns the key the mappings of the copy are stored under.
+func (cfg CopyConfig) sourceKey() string {
+	sum := sha256.Sum256([]byte(cfg.Source + "\x00" + cfg.Namespace))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CopyResult counts the rows of every table copied by this copy, and skipped because the entity they refer to is
+// missing.
+type CopyResult struct {
+	Copied  map[string]int64
+	Skipped map[string]int64
+}
+
+// CopyTo copies the entities, their aliases, observations and relations, the trash included, into target with new
+// ids and their timestamps. Target is migrated first. Every batch is copied in a transaction along with the mapping of
+// its ids, so an interrupted copy resumes after the last batch copied. The change journal and ontologies aren't
+// copied.
+func (c *Client) CopyTo(ctx context.Context, target *Client, cfg CopyConfig) (*CopyResult, error) {
+	ctx, span := tracer.Start(ctx, "CopyTo", tracerAttrs...)
+	defer span.End()
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = backupBatchSize
+	}
+	if err := c.CheckSchema(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if err := target.DoMigration(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("can't migrate target: %w", err)
+	}
+
+	cp := &copier{
+		source: c,
+		target: target,
+		cfg:    cfg,
+		key:    cfg.sourceKey(),
+		result: &CopyResult{
+			Copied:  make(map[string]int64),
+			Skipped: make(map[string]int64),
+		},
+	}
+	for _, step := range []func(ctx context.Context) error{
+		cp.copyEntities,
+		cp.copyEntityAliases,
+		cp.copyObservations,
+		cp.copyRelations,
+	} {
+		if err := step(ctx); err != nil {
+			span.RecordError(err)
+			return cp.result, target.ProcessError(err)
+		}
+	}
+
+	return cp.result, nil
+}
+
+// VerifyCopy checks every row of the copied tables has been copied into target, and its copy is still there.
+func (c *Client) VerifyCopy(ctx context.Context, target *Client, cfg CopyConfig) error {
+	ctx, span := tracer.Start(ctx, "VerifyCopy", tracerAttrs...)
+	defer span.End()
+
+	key := cfg.sourceKey()
+	for _, copied := range []struct {
+		table string
+		model any
+	}{
+		{table: archive.TableEntities, model: (*models.Entity)(nil)},
+		{table: archive.TableEntityAliases, model: (*models.EntityAlias)(nil)},
+		{table: archive.TableObservations, model: (*models.Observation)(nil)},
+		{table: archive.TableRelations, model: (*models.Relation)(nil)},
+	} {
+		table, model := copied.table, copied.model
+		typ := reflect.TypeOf(model).Elem()
+		rows, err := withTrash(c.conn, c.conn.NewSelect().Model(model), typ).Count(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return c.ProcessError(err)
+		}
+		mapped, err := target.conn.NewSelect().
+			Model((*models.CopyMapping)(nil)).
+			Where("source = ? AND source_table = ?", key, table).
+			Count(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return target.ProcessError(err)
+		}
+		if rows != mapped {
+			return fmt.Errorf("source has %d %s, %d are copied", rows, table, mapped)
+		}
+
+		copies := target.conn.NewSelect().
+			Model((*models.CopyMapping)(nil)).
+			Column("target_id").
+			Where("source = ? AND source_table = ? AND target_id <> 0", key, table)
+		wantCopies, err := copies.Count(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return target.ProcessError(err)
+		}
+		query := target.conn.NewSelect().Model(model).Where("? IN (?)", bun.Ident("id"), copies)
+		gotCopies, err := withTrash(target.conn, query, typ).Count(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return target.ProcessError(err)
+		}
+		if gotCopies != wantCopies {
+			return fmt.Errorf("%d of %d copied %s are missing from the target", wantCopies-gotCopies, wantCopies, table)
+		}
+	}
+
+	return nil
+}
+
+// copier copies the rows of one source into a target.
+type copier struct {
+	source *Client
+	target *Client
+	cfg    CopyConfig
+	key    string
+	result *CopyResult
+}
+
+func (cp *copier) copyEntities(ctx context.Context) error {
+	return copyTable(ctx, cp, archive.TableEntities, func(e *models.Entity) int64 { return e.ID },
+		func(ctx context.Context, tx bun.Tx, entities []*models.Entity) ([]bool, error) {
+			keep := make([]bool, len(entities))
+			for i, entity := range entities {
+				entity.ID = 0
+				entity.GlobalID = cp.globalID(entity.GlobalID)
+				entity.Name = cp.namespaced(entity.Name)
+				keep[i] = true
+			}
+			if cp.cfg.Namespace == "" {
+				if err := checkNameConflicts(ctx, tx, entities); err != nil {
+					return nil, err
+				}
+			}
+
+			return keep, nil
+		})
+}
+
+func (cp *copier) copyEntityAliases(ctx context.Context) error {
+	return copyTable(ctx, cp, archive.TableEntityAliases, func(a *models.EntityAlias) int64 { return a.ID },
+		func(ctx context.Context, tx bun.Tx, aliases []*models.EntityAlias) ([]bool, error) {
+			ids := make([]int64, len(aliases))
+			for i, alias := range aliases {
+				ids[i] = alias.EntityID
+			}
+			entityIDs, err := cp.targetIDs(ctx, tx, archive.TableEntities, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			keep := make([]bool, len(aliases))
+			for i, alias := range aliases {
+				alias.ID = 0
+				alias.EntityID, keep[i] = entityIDs[alias.EntityID]
+				alias.Alias = cp.namespaced(alias.Alias)
+				alias.Normalized = strings.ToLower(cp.namespaced(alias.Normalized))
+			}
+			if cp.cfg.Namespace == "" {
+				if err := checkAliasConflicts(ctx, tx, aliases, keep); err != nil {
+					return nil, err
+				}
+			}
+
+			return keep, nil
+		})
+}
+
+func (cp *copier) copyObservations(ctx context.Context) error {
+	return copyTable(ctx, cp, archive.TableObservations, func(o *models.Observation) int64 { return o.ID },
+		func(ctx context.Context, tx bun.Tx, observations []*models.Observation) ([]bool, error) {
+			ids := make([]int64, len(observations))
+			for i, observation := range observations {
+				ids[i] = observation.EntityID
+			}
+			entityIDs, err := cp.targetIDs(ctx, tx, archive.TableEntities, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			keep := make([]bool, len(observations))
+			for i, observation := range observations {
+				observation.ID = 0
+				observation.GlobalID = cp.globalID(observation.GlobalID)
+				observation.EntityID, keep[i] = entityIDs[observation.EntityID]
+			}
+
+			return keep, nil
+		})
+}
+
+func (cp *copier) copyRelations(ctx context.Context) error {
+	return copyTable(ctx, cp, archive.TableRelations, func(r *models.Relation) int64 { return r.ID },
+		func(ctx context.Context, tx bun.Tx, relations []*models.Relation) ([]bool, error) {
+			ids := make([]int64, 0, 2*len(relations))
+			for _, relation := range relations {
+				ids = append(ids, relation.FromID, relation.ToID)
+			}
+			entityIDs, err := cp.targetIDs(ctx, tx, archive.TableEntities, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			keep := make([]bool, len(relations))
+			for i, relation := range relations {
+				relation.ID = 0
+				relation.GlobalID = cp.globalID(relation.GlobalID)
+				fromID, fromOK := entityIDs[relation.FromID]
+				toID, toOK := entityIDs[relation.ToID]
+				relation.FromID, relation.ToID, keep[i] = fromID, toID, fromOK && toOK
+			}
+
+			return keep, nil
+		})
+}
+
+// namespaced prefixes name with the namespace of the copy.
+func (cp *copier) namespaced(name string) string {
+	if cp.cfg.Namespace == "" {
+		return name
+	}
+
+	return cp.cfg.Namespace + NamespaceSeparator + name
+}
+
+// globalID returns the global id of a copy of the row with globalID. Rows copied into a namespace are new rows and
+// get a new one when they're inserted.
+func (cp *copier) globalID(globalID string) string {
+	if cp.cfg.Namespace != "" {
+		return ""
+	}
+
+	return globalID
+}
+
+// targetIDs returns the ids of the copies of the rows of table with sourceIDs, leaving out skipped rows.
+func (cp *copier) targetIDs(ctx context.Context, tx bun.Tx, table string, sourceIDs []int64) (map[int64]int64, error) {
+	var mappings []*models.CopyMapping
+	err := tx.NewSelect().
+		Model(&mappings).
+		Where("source = ? AND source_table = ? AND target_id <> 0", cp.key, table).
+		Where("source_id IN (?)", bun.In(sourceIDs)).
+		Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	ids := make(map[int64]int64, len(mappings))
+	for _, mapping := range mappings {
+		ids[mapping.SourceID] = mapping.TargetID
+	}
+
+	return ids, nil
+}
+
+// copyTable copies the rows of the table of T after the last one copied, a batch per target transaction. remap
+// prepares the rows for the target and says which of them are copied, the others are skipped.
+func copyTable[T any](
+	ctx context.Context,
+	cp *copier,
+	table string,
+	id func(*T) int64,
+	remap func(ctx context.Context, tx bun.Tx, rows []*T) ([]bool, error),
+) error {
+	var after sql.NullInt64
+	err := cp.target.conn.NewSelect().
+		Model((*models.CopyMapping)(nil)).
+		ColumnExpr("MAX(source_id)").
+		Where("source = ? AND source_table = ?", cp.key, table).
+		Scan(ctx, &after)
+	if err != nil {
+		return err
+	}
+	if after.Valid {
+		zap.L().Info("Resuming copy", zap.String("table", table), zap.Int64("after_id", after.Int64))
+	}
+
+	for {
+		var rows []*T
+		query := cp.source.conn.NewSelect().
+			Model(&rows).
+			Where("? > ?", bun.Ident("id"), after.Int64).
+			Order("id").
+			Limit(cp.cfg.BatchSize)
+		if err := withTrash(cp.source.conn, query, reflect.TypeFor[T]()).Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("can't read %s: %w", table, cp.source.ProcessError(err))
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		after.Int64 = id(rows[len(rows)-1])
+
+		copied, skipped, err := copyBatch(ctx, cp, table, rows, id, remap)
+		if err != nil {
+			return fmt.Errorf("can't copy %s: %w", table, err)
+		}
+		cp.result.Copied[table] += copied
+		cp.result.Skipped[table] += skipped
+		zap.L().Debug("Copied batch", zap.String("table", table), zap.Int64("copied", copied), zap.Int64("skipped", skipped))
+
+		if len(rows) < cp.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// copyBatch inserts the rows remap keeps into the target with the mappings of all rows in one transaction.
+func copyBatch[T any](
+	ctx context.Context,
+	cp *copier,
+	table string,
+	rows []*T,
+	id func(*T) int64,
+	remap func(ctx context.Context, tx bun.Tx, rows []*T) ([]bool, error),
+) (int64, int64, error) {
+	var copied, skipped int64
+	err := cp.target.conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		copied, skipped = 0, 0
+		mappings := make([]*models.CopyMapping, len(rows))
+		for i, row := range rows {
+			mappings[i] = &models.CopyMapping{Source: cp.key, SourceTable: table, SourceID: id(row)}
+		}
+
+		keep, err := remap(ctx, tx, rows)
+		if err != nil {
+			return err
+		}
+		kept := make([]*T, 0, len(rows))
+		keptMappings := make([]*models.CopyMapping, 0, len(rows))
+		for i, row := range rows {
+			if keep[i] {
+				kept = append(kept, row)
+				keptMappings = append(keptMappings, mappings[i])
+			}
+		}
+
+		if len(kept) > 0 {
+			if _, err := tx.NewInsert().Model(&kept).Exec(ctx); err != nil {
+				return err
+			}
+			for i, row := range kept {
+				keptMappings[i].TargetID = id(row)
+			}
+		}
+		if _, err := tx.NewInsert().Model(&mappings).Exec(ctx); err != nil {
+			return err
+		}
+
+		copied = int64(len(kept))
+		skipped = int64(len(rows) - len(kept))
+
+		return nil
+	})
+
+	return copied, skipped, err
+}
+
+// checkNameConflicts fails if an entity in the target that isn't in the trash has the name of one of the entities
+// that aren't, or if one of their names is an alias in the target.
+func checkNameConflicts(ctx context.Context, tx bun.Tx, entities []*models.Entity) error {
+	names := make([]string, 0, len(entities))
+	byNormalized := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		if entity.DeletedAt.IsZero() {
+			names = append(names, entity.Name)
+			byNormalized[strings.ToLower(strings.TrimSpace(entity.Name))] = entity.Name
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	var existing []*models.Entity
+	err := tx.NewSelect().Model(&existing).Where("name IN (?)", bun.In(names)).Limit(1).Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("entity %q already exists in the target, copy into a namespace", existing[0].Name)
+	}
+
+	normalized := make([]string, 0, len(byNormalized))
+	for name := range byNormalized {
+		normalized = append(normalized, name)
+	}
+	var aliases []*models.EntityAlias
+	err = tx.NewSelect().Model(&aliases).Where("normalized IN (?)", bun.In(normalized)).Limit(1).Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if len(aliases) > 0 {
+		return fmt.Errorf("entity %q is an alias in the target, copy into a namespace", byNormalized[aliases[0].Normalized])
+	}
+
+	return nil
+}
+
+// checkAliasConflicts fails if one of the aliases kept is an alias in the target already, or the name of an entity
+// other than its own that isn't in the trash.
+func checkAliasConflicts(ctx context.Context, tx bun.Tx, aliases []*models.EntityAlias, keep []bool) error {
+	normalized := make([]string, 0, len(aliases))
+	byNormalized := make(map[string]*models.EntityAlias, len(aliases))
+	for i, alias := range aliases {
+		if keep[i] {
+			normalized = append(normalized, alias.Normalized)
+			byNormalized[alias.Normalized] = alias
+		}
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	var existing []*models.EntityAlias
+	err := tx.NewSelect().Model(&existing).Where("normalized IN (?)", bun.In(normalized)).Limit(1).Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("alias %q already exists in the target, copy into a namespace", byNormalized[existing[0].Normalized].Alias)
+	}
+
+	var entities []*models.Entity
+	err = tx.NewSelect().Model(&entities).Where("LOWER(?) IN (?)", bun.Ident("name"), bun.In(normalized)).Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	for _, entity := range entities {
+		alias := byNormalized[strings.ToLower(entity.Name)]
+		if alias != nil && alias.EntityID != entity.ID {
+			return fmt.Errorf("alias %q is the name of entity %q in the target, copy into a namespace", alias.Alias, entity.Name)
+		}
+	}
+
+	return nil
+}
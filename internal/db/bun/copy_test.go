@@ -0,0 +1,116 @@
+package bun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/archive"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestClient_CopyTo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source := newMigratedClient(t, "source.db")
+	target := newMigratedClient(t, "target.db")
+
+	// ids in the target are taken already
+	require.NoError(t, target.CreateEntity(ctx, &models.Entity{Name: "carol", Type: "person"}))
+
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, alice))
+	require.NoError(t, source.CreateEntity(ctx, bob))
+	require.NoError(t, source.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: alice.ID, Alias: "Al", Normalized: "al"}))
+	require.NoError(t, source.CreateObservation(ctx, &models.Observation{EntityID: alice.ID, Contents: "likes tea"}))
+	require.NoError(t, source.CreateObservation(ctx, &models.Observation{EntityID: bob.ID, Contents: "likes coffee"}))
+	require.NoError(t, source.CreateRelation(ctx, &models.Relation{FromID: alice.ID, ToID: bob.ID, Type: "knows"}))
+	require.NoError(t, source.DeleteEntity(ctx, bob))
+
+	cfg := CopyConfig{Source: "source.db", Namespace: "laptop", BatchSize: 1}
+	result, err := source.CopyTo(ctx, target, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Copied[archive.TableEntities])
+	assert.Equal(t, int64(2), result.Copied[archive.TableObservations])
+	require.NoError(t, source.VerifyCopy(ctx, target, cfg))
+
+	copied, err := target.ReadEntityByName(ctx, "laptop/alice")
+	require.NoError(t, err)
+	assert.NotEqual(t, alice.ID, copied.ID, "ids are remapped")
+	assert.NotEqual(t, alice.GlobalID, copied.GlobalID, "copies into a namespace are new rows")
+	observations, err := target.ReadObservationsByEntityID(ctx, copied.ID)
+	require.NoError(t, err)
+	require.Len(t, observations, 1)
+	assert.Equal(t, "likes tea", observations[0].Contents)
+	alias, err := target.ReadEntityAliasByNormalized(ctx, "laptop/al")
+	require.NoError(t, err)
+	assert.Equal(t, copied.ID, alias.EntityID)
+	trashed, err := target.ReadDeletedEntityByName(ctx, "laptop/bob")
+	require.NoError(t, err)
+	var relations []*models.Relation
+	require.NoError(t, target.conn.NewSelect().Model(&relations).Where("from_id = ?", copied.ID).Scan(ctx))
+	require.Len(t, relations, 1)
+	assert.Equal(t, trashed.ID, relations[0].ToID)
+
+	// copying again resumes after the rows copied
+	dave := &models.Entity{Name: "dave", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, dave))
+	assert.Error(t, source.VerifyCopy(ctx, target, cfg), "dave isn't copied")
+	result, err = source.CopyTo(ctx, target, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{archive.TableEntities: 1}, result.Copied)
+	require.NoError(t, source.VerifyCopy(ctx, target, cfg))
+
+	// without a namespace the names may not collide
+	other := newMigratedClient(t, "other.db")
+	require.NoError(t, other.CreateEntity(ctx, &models.Entity{Name: "carol", Type: "person"}))
+	_, err = other.CopyTo(ctx, target, CopyConfig{Source: "other.db"})
+	assert.ErrorContains(t, err, `"carol" already exists`)
+}
+
+func TestClient_CopyTo_aliasConflicts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	target := newMigratedClient(t, "target.db")
+	carol := &models.Entity{Name: "carol", Type: "person"}
+	require.NoError(t, target.CreateEntity(ctx, carol))
+	require.NoError(t, target.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: carol.ID, Alias: "Caz", Normalized: "caz"}))
+
+	tests := []struct {
+		name    string
+		entity  string
+		alias   string
+		wantErr string
+	}{
+		{name: "alias is an alias in the target", entity: "alice", alias: "CAZ", wantErr: `alias "CAZ" already exists`},
+		{name: "alias is a name in the target", entity: "bob", alias: "Carol", wantErr: `alias "Carol" is the name of entity "carol"`},
+		{name: "name is an alias in the target", entity: "Caz", wantErr: `entity "Caz" is an alias`},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newMigratedClient(t, "source.db")
+			entity := &models.Entity{Name: tt.entity, Type: "person"}
+			require.NoError(t, source.CreateEntity(ctx, entity))
+			if tt.alias != "" {
+				require.NoError(t, source.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: entity.ID, Alias: tt.alias, Normalized: strings.ToLower(tt.alias)}))
+			}
+
+			_, err := source.CopyTo(ctx, target, CopyConfig{Source: fmt.Sprintf("source-%d.db", i)})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// an entity's own name is fine as its alias
+	source := newMigratedClient(t, "source.db")
+	dave := &models.Entity{Name: "dave", Type: "person"}
+	require.NoError(t, source.CreateEntity(ctx, dave))
+	require.NoError(t, source.CreateEntityAlias(ctx, &models.EntityAlias{EntityID: dave.ID, Alias: "DAVE", Normalized: "dave"}))
+	_, err := source.CopyTo(ctx, target, CopyConfig{Source: "dave.db"})
+	assert.NoError(t, err)
+}
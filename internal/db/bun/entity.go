@@ -3,6 +3,7 @@ package bun
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/tyrm/mcp-dbmem/internal/db"
 	"github.com/tyrm/mcp-dbmem/internal/models"
@@ -22,11 +23,31 @@ func (c *Client) DeleteEntity(ctx context.Context, entity *models.Entity) db.Err
 	ctx, span := tracer.Start(ctx, "DeleteEntity", tracerAttrs...)
 	defer span.End()
 
+	c.remember(entity)
+	if entity.DeletedAt.IsZero() {
+		entity.DeletedAt = time.Now()
+	}
+
 	err := c.delete(ctx, entity)
 	span.RecordError(err)
 	return err
 }
 
+func (c *Client) PurgeEntities(ctx context.Context, deletedBefore time.Time) (int64, db.Error) {
+	ctx, span := tracer.Start(ctx, "PurgeEntities", tracerAttrs...)
+	defer span.End()
+
+	query := c.db.
+		NewDelete().
+		Model((*models.Entity)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", deletedBefore)
+
+	purged, err := c.purge(ctx, query)
+	span.RecordError(err)
+	return purged, err
+}
+
 func (c *Client) ReadAllEntities(ctx context.Context) ([]*models.Entity, db.Error) {
 	ctx, span := tracer.Start(ctx, "ReadAllEntities", tracerAttrs...)
 	defer span.End()
@@ -34,7 +55,77 @@ func (c *Client) ReadAllEntities(ctx context.Context) ([]*models.Entity, db.Erro
 	var entities []*models.Entity
 	query := newEntitiesQ(c.db, &entities)
 
-	if err := query.Scan(ctx); err != nil {
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+func (c *Client) ReadDeletedEntities(ctx context.Context) ([]*models.Entity, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedEntities", tracerAttrs...)
+	defer span.End()
+
+	var entities []*models.Entity
+	query := newDeletedEntitiesQ(c.db, &entities)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// ReadDeletedEntityByName returns the entity with name that was moved to the trash most recently.
+func (c *Client) ReadDeletedEntityByName(ctx context.Context, name string) (*models.Entity, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedEntityByName", tracerAttrs...)
+	defer span.End()
+
+	entity := new(models.Entity)
+	query := c.db.
+		NewSelect().
+		Model(entity).
+		WhereDeleted().
+		Where("name = ?", name).
+		Order("deleted_at DESC").
+		Limit(1)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// ReadEntitiesByIDs returns the entities with the given ids, including the ones in the trash.
+func (c *Client) ReadEntitiesByIDs(ctx context.Context, ids []int64) ([]*models.Entity, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadEntitiesByIDs", tracerAttrs...)
+	defer span.End()
+
+	entities := make([]*models.Entity, 0, len(ids))
+	if len(ids) == 0 {
+		return entities, nil
+	}
+
+	query := c.db.
+		NewSelect().
+		Model(&entities).
+		WhereAllWithDeleted().
+		Where("id IN (?)", bun.In(ids))
+
+	if err := c.scan(ctx, query); err != nil {
 		err := c.ProcessError(err)
 		if !errors.Is(err, db.ErrNoEntries) {
 			span.RecordError(err)
@@ -53,7 +144,7 @@ func (c *Client) ReadEntityByName(ctx context.Context, name string) (*models.Ent
 	query := newEntityQ(c.db, entity).
 		Where("name = ?", name)
 
-	if err := query.Scan(ctx); err != nil {
+	if err := c.scan(ctx, query); err != nil {
 		err := c.ProcessError(err)
 		if !errors.Is(err, db.ErrNoEntries) {
 			span.RecordError(err)
@@ -64,6 +155,37 @@ func (c *Client) ReadEntityByName(ctx context.Context, name string) (*models.Ent
 	return entity, nil
 }
 
+func (c *Client) ReadEntitiesByNameFold(ctx context.Context, name string) ([]*models.Entity, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadEntitiesByNameFold", tracerAttrs...)
+	defer span.End()
+
+	var entities []*models.Entity
+	query := newEntitiesQ(c.db, &entities).
+		Where("LOWER(?) = LOWER(?)", bun.Ident("entity.name"), name).
+		Order("entity.id ASC")
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+func (c *Client) RestoreEntity(ctx context.Context, entity *models.Entity) db.Error {
+	ctx, span := tracer.Start(ctx, "RestoreEntity", tracerAttrs...)
+	defer span.End()
+
+	c.remember(entity)
+	entity.DeletedAt = time.Time{}
+	err := c.restore(ctx, entity)
+	span.RecordError(err)
+	return err
+}
+
 func newEntityQ(c bun.IDB, i *models.Entity) *bun.SelectQuery {
 	return c.
 		NewSelect().
@@ -77,3 +199,11 @@ func newEntitiesQ(c bun.IDB, i *[]*models.Entity) *bun.SelectQuery {
 		Model(i).
 		Relation("Observations")
 }
+
+func newDeletedEntitiesQ(c bun.IDB, i *[]*models.Entity) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model(i).
+		WhereDeleted().
+		Order("deleted_at DESC")
+}
@@ -0,0 +1,123 @@
+package bun
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+)
+
+func (c *Client) CreateEntityAlias(ctx context.Context, alias *models.EntityAlias) db.Error {
+	ctx, span := tracer.Start(ctx, "CreateEntityAlias", tracerAttrs...)
+	defer span.End()
+
+	err := c.create(ctx, alias)
+	span.RecordError(err)
+	return err
+}
+
+func (c *Client) DeleteEntityAlias(ctx context.Context, alias *models.EntityAlias) db.Error {
+	ctx, span := tracer.Start(ctx, "DeleteEntityAlias", tracerAttrs...)
+	defer span.End()
+
+	query := c.db.
+		NewDelete().
+		Model(alias).
+		WherePK()
+
+	if _, err := query.Exec(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+// ReadEntityAliasByNormalized returns the alias with its entity. Aliases of entities in the trash aren't returned.
+func (c *Client) ReadEntityAliasByNormalized(ctx context.Context, normalized string) (*models.EntityAlias, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadEntityAliasByNormalized", tracerAttrs...)
+	defer span.End()
+
+	alias := new(models.EntityAlias)
+	query := newEntityAliasQ(c.db, alias).
+		Where("? = ?", bun.Ident("entity_alias.normalized"), normalized)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return alias, nil
+}
+
+// ReadEntityAliasByNormalizedWithDeleted returns the alias with its entity, including aliases of entities in the trash.
+// Those still hold their normalized form, so collisions are checked with it.
+func (c *Client) ReadEntityAliasByNormalizedWithDeleted(ctx context.Context, normalized string) (*models.EntityAlias, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadEntityAliasByNormalizedWithDeleted", tracerAttrs...)
+	defer span.End()
+
+	alias := new(models.EntityAlias)
+	query := c.db.
+		NewSelect().
+		Model(alias).
+		Where("normalized = ?", normalized)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	// the relation join can't include deleted entities, read the entity on its own
+	alias.Entity = new(models.Entity)
+	query = c.db.
+		NewSelect().
+		Model(alias.Entity).
+		WhereAllWithDeleted().
+		Where("id = ?", alias.EntityID)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return alias, nil
+}
+
+func (c *Client) ReadEntityAliasesByEntityID(ctx context.Context, entityID int64) ([]*models.EntityAlias, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadEntityAliasesByEntityID", tracerAttrs...)
+	defer span.End()
+
+	var aliases []*models.EntityAlias
+	query := c.db.
+		NewSelect().
+		Model(&aliases).
+		Where("entity_id = ?", entityID).
+		Order("alias ASC")
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+func newEntityAliasQ(c bun.IDB, i *models.EntityAlias) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model(i).
+		Relation("Entity").
+		Where("? IS NOT NULL", bun.Ident("entity.id"))
+}
@@ -0,0 +1,41 @@
+package bun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestClient_ReadEntityAliasByNormalized(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newMigratedClient(t, "aliases.db")
+	entity := &models.Entity{Name: "alice", Type: "person"}
+	require.NoError(t, client.CreateEntity(ctx, entity))
+	require.NoError(t, client.CreateEntityAlias(ctx, &models.EntityAlias{Alias: "Ali", Normalized: "ali", EntityID: entity.ID}))
+
+	alias, err := client.ReadEntityAliasByNormalized(ctx, "ali")
+	require.NoError(t, err)
+	assert.Equal(t, "Ali", alias.Alias)
+	require.NotNil(t, alias.Entity)
+	assert.Equal(t, "alice", alias.Entity.Name)
+
+	err = client.CreateEntityAlias(ctx, &models.EntityAlias{Alias: "ALI", Normalized: "ali", EntityID: entity.ID})
+	var exists *db.AlreadyExistsError
+	assert.ErrorAs(t, err, &exists, "normalized aliases are unique")
+
+	require.NoError(t, client.DeleteEntity(ctx, entity))
+	_, err = client.ReadEntityAliasByNormalized(ctx, "ali")
+	assert.ErrorIs(t, err, db.ErrNoEntries)
+
+	alias, err = client.ReadEntityAliasByNormalizedWithDeleted(ctx, "ali")
+	require.NoError(t, err)
+	require.NotNil(t, alias.Entity)
+	assert.Equal(t, "alice", alias.Entity.Name)
+	assert.False(t, alias.Entity.DeletedAt.IsZero())
+}
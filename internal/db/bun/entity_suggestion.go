@@ -0,0 +1,166 @@
+package bun
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/util"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// nameCandidate is an entity name or alias scored against the name being looked up.
+type nameCandidate struct {
+	EntityID int64   `bun:"entity_id"`
+	Name     string  `bun:"name"`
+	Alias    string  `bun:"alias"`
+	Score    float64 `bun:"score"`
+}
+
+func (c *Client) SuggestEntities(ctx context.Context, name string, minScore float64, limit int) ([]*models.EntitySuggestion, db.Error) {
+	ctx, span := tracer.Start(ctx, "SuggestEntities", tracerAttrs...)
+	defer span.End()
+
+	var candidates []*nameCandidate
+	var err error
+	if c.db.Dialect().Name() == dialect.PG {
+		candidates, err = c.scoreCandidatesPG(ctx, name, minScore, limit)
+	} else {
+		candidates, err = c.scoreCandidates(ctx, name, minScore)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+
+	// keep the best scoring candidate for each entity
+	best := make(map[int64]*nameCandidate, len(candidates))
+	for _, candidate := range candidates {
+		if current, ok := best[candidate.EntityID]; !ok || candidate.Score > current.Score {
+			best[candidate.EntityID] = candidate
+		}
+	}
+	ranked := make([]*nameCandidate, 0, len(best))
+	for _, candidate := range best {
+		ranked = append(ranked, candidate)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].EntityID < ranked[j].EntityID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	ids := make([]int64, 0, len(ranked))
+	for _, candidate := range ranked {
+		ids = append(ids, candidate.EntityID)
+	}
+	entities, err := c.ReadEntitiesByIDs(ctx, ids)
+	if err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+	byID := make(map[int64]*models.Entity, len(entities))
+	for _, entity := range entities {
+		byID[entity.ID] = entity
+	}
+
+	suggestions := make([]*models.EntitySuggestion, 0, len(ranked))
+	for _, candidate := range ranked {
+		entity, ok := byID[candidate.EntityID]
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, &models.EntitySuggestion{
+			Entity: entity,
+			Alias:  candidate.Alias,
+			Score:  candidate.Score,
+		})
+	}
+
+	return suggestions, nil
+}
+
+// scoreCandidatesPG scores names and aliases in the database with pg_trgm. Candidates are filtered with the % operator
+// so the trigram indexes are used, it compares against the similarity threshold of the server, 0.3 by default. Changing
+// the threshold would change it for the rest of the caller's transaction as well, so minScore is checked on its own.
+func (c *Client) scoreCandidatesPG(ctx context.Context, name string, minScore float64, limit int) ([]*nameCandidate, error) {
+	var candidates []*nameCandidate
+	entitiesQ := c.db.
+		NewSelect().
+		Model((*models.Entity)(nil)).
+		ColumnExpr("? AS entity_id", bun.Ident("entity.id")).
+		ColumnExpr("? AS name", bun.Ident("entity.name")).
+		ColumnExpr("'' AS alias").
+		ColumnExpr("similarity(?, ?) AS score", bun.Ident("entity.name"), name).
+		Where("? % ?", bun.Ident("entity.name"), name).
+		Where("similarity(?, ?) >= ?", bun.Ident("entity.name"), name, minScore).
+		OrderExpr("score DESC").
+		Limit(limit)
+	if err := c.scan(ctx, entitiesQ, &candidates); err != nil {
+		return nil, err
+	}
+
+	var aliasCandidates []*nameCandidate
+	aliasesQ := newAliasCandidatesQ(c.db).
+		ColumnExpr("similarity(?, ?) AS score", bun.Ident("entity_alias.alias"), name).
+		Where("? % ?", bun.Ident("entity_alias.alias"), name).
+		Where("similarity(?, ?) >= ?", bun.Ident("entity_alias.alias"), name, minScore).
+		OrderExpr("score DESC").
+		Limit(limit)
+	if err := c.scan(ctx, aliasesQ, &aliasCandidates); err != nil {
+		return nil, err
+	}
+
+	return append(candidates, aliasCandidates...), nil
+}
+
+// scoreCandidates reads every name and alias and scores them in go.
+func (c *Client) scoreCandidates(ctx context.Context, name string, minScore float64) ([]*nameCandidate, error) {
+	var candidates []*nameCandidate
+	entitiesQ := c.db.
+		NewSelect().
+		Model((*models.Entity)(nil)).
+		ColumnExpr("? AS entity_id", bun.Ident("entity.id")).
+		ColumnExpr("? AS name", bun.Ident("entity.name"))
+	if err := c.scan(ctx, entitiesQ, &candidates); err != nil {
+		return nil, err
+	}
+
+	var aliasCandidates []*nameCandidate
+	if err := c.scan(ctx, newAliasCandidatesQ(c.db), &aliasCandidates); err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, aliasCandidates...)
+
+	scored := make([]*nameCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		matched := candidate.Name
+		if candidate.Alias != "" {
+			matched = candidate.Alias
+		}
+		candidate.Score = util.TrigramSimilarity(matched, name)
+		if candidate.Score >= minScore {
+			scored = append(scored, candidate)
+		}
+	}
+
+	return scored, nil
+}
+
+// newAliasCandidatesQ selects the aliases of entities not in the trash.
+func newAliasCandidatesQ(c bun.IDB) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model((*models.EntityAlias)(nil)).
+		Join("JOIN ? AS ? ON ? = ?", bun.Ident("entities"), bun.Ident("entity"), bun.Ident("entity.id"), bun.Ident("entity_alias.entity_id")).
+		Where("? IS NULL", bun.Ident("entity.deleted_at")).
+		ColumnExpr("? AS entity_id", bun.Ident("entity_alias.entity_id")).
+		ColumnExpr("? AS name", bun.Ident("entity.name")).
+		ColumnExpr("? AS alias", bun.Ident("entity_alias.alias"))
+}
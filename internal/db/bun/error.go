@@ -1,8 +1,14 @@
 package bun
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jackc/pgconn"
@@ -12,21 +18,6 @@ import (
 	sqlite3 "modernc.org/sqlite/lib"
 )
 
-// AlreadyExistsError is returned when a caller tries to insert a database entry that already exists in the db.
-type AlreadyExistsError struct {
-	message string
-}
-
-// Error returns the error message as a string.
-func (e *AlreadyExistsError) Error() string {
-	return e.message
-}
-
-// NewErrAlreadyExists wraps a message in an AlreadyExistsError object.
-func NewErrAlreadyExists(msg string) error {
-	return &AlreadyExistsError{message: msg}
-}
-
 // ProcessError replaces any known values with our own db.Error types.
 func (c *Client) ProcessError(err error) db.Error {
 	switch {
@@ -34,12 +25,34 @@ func (c *Client) ProcessError(err error) db.Error {
 		return nil
 	case errors.Is(err, sql.ErrNoRows):
 		return db.ErrNoEntries
+	case isConnectionLoss(err):
+		return db.NewErrConnection(err.Error())
 	default:
 		return c.errProc(err)
 	}
 }
 
-// processMySQLError processes an error.
+// isConnectionLoss returns true if err says the connection to the database broke, whatever the dialect.
+func isConnectionLoss(err error) bool {
+	// a canceled or timed out context is also a net.Error, but the connection is fine
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	var syscallErr *os.SyscallError
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.As(err, &opErr) ||
+		errors.As(err, &syscallErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// processMySQLError processes an error, replacing any mysql specific errors with our own error type.
 func processMySQLError(err error) db.Error {
 	// Attempt to cast as mysql
 	myErr := &mysql.MySQLError{}
@@ -49,7 +62,25 @@ func processMySQLError(err error) db.Error {
 	}
 
 	zap.L().Debug("mysql error", zap.Int("code", int(myErr.Number)), zap.Error(myErr))
-	return err
+
+	// Handle supplied error number:
+	// (https://mariadb.com/kb/en/mariadb-error-codes/)
+	switch myErr.Number {
+	case 1062 /* ER_DUP_ENTRY */, 1586 /* ER_DUP_ENTRY_WITH_KEY_NAME */ :
+		return db.NewErrAlreadyExists(myErr.Message)
+	case 1216 /* ER_NO_REFERENCED_ROW */, 1217 /* ER_ROW_IS_REFERENCED */, 1451 /* ER_ROW_IS_REFERENCED_2 */, 1452 /* ER_NO_REFERENCED_ROW_2 */ :
+		return db.NewErrForeignKeyViolation(myErr.Message)
+	case 1048 /* ER_BAD_NULL_ERROR */, 1364 /* ER_NO_DEFAULT_FOR_FIELD */ :
+		return db.NewErrNotNullViolation(myErr.Message)
+	case 3819 /* ER_CHECK_CONSTRAINT_VIOLATED */, 4025 /* ER_CONSTRAINT_FAILED */ :
+		return db.NewErrCheckViolation(myErr.Message)
+	case 1205 /* ER_LOCK_WAIT_TIMEOUT */, 1213 /* ER_LOCK_DEADLOCK */ :
+		return db.NewErrSerialization(myErr.Message)
+	case 1053 /* ER_SERVER_SHUTDOWN */, 1927 /* ER_CONNECTION_KILLED */, 2006 /* CR_SERVER_GONE_ERROR */, 2013 /* CR_SERVER_LOST */ :
+		return db.NewErrConnection(myErr.Message)
+	default:
+		return err
+	}
 }
 
 // processPostgresError processes an error, replacing any postgres specific errors with our own error type.
@@ -67,14 +98,28 @@ func processPostgresError(err error) db.Error {
 	// (https://www.postgresql.org/docs/10/errcodes-appendix.html)
 	switch pgErr.Code {
 	case "23505" /* unique_violation */ :
-		return NewErrAlreadyExists(pgErr.Message)
-	default:
-		return err
+		return db.NewErrAlreadyExists(pgErr.Message)
+	case "23503" /* foreign_key_violation */ :
+		return db.NewErrForeignKeyViolation(pgErr.Message)
+	case "23502" /* not_null_violation */ :
+		return db.NewErrNotNullViolation(pgErr.Message)
+	case "23514" /* check_violation */ :
+		return db.NewErrCheckViolation(pgErr.Message)
+	case "40001" /* serialization_failure */, "40P01" /* deadlock_detected */ :
+		return db.NewErrSerialization(pgErr.Message)
+	case "57P01" /* admin_shutdown */, "57P02" /* crash_shutdown */, "57P03" /* cannot_connect_now */ :
+		return db.NewErrConnection(pgErr.Message)
 	}
-}
 
-// processSQLiteError processes an error, replacing any sqlite specific errors with our own error type
+	// class 08 is every connection exception
+	if strings.HasPrefix(pgErr.Code, "08") {
+		return db.NewErrConnection(pgErr.Message)
+	}
+
+	return err
+}
 
+// processSQLiteError processes an error, replacing any sqlite specific errors with our own error type.
 func processSQLiteError(err error) db.Error {
 	// Attempt to cast as sqlite
 	var sqliteErr *sqlite.Error
@@ -83,11 +128,31 @@ func processSQLiteError(err error) db.Error {
 		return err
 	}
 
-	zap.L().Debug("Postgres error", zap.Int("code", sqliteErr.Code()), zap.Error(sqliteErr))
-	// Handle supplied error code:
-	switch sqliteErr.Code() {
+	zap.L().Debug("sqlite error", zap.Int("code", sqliteErr.Code()), zap.Error(sqliteErr))
+
+	return processSQLiteCode(sqliteErr.Code(), err)
+}
+
+// processSQLiteCode replaces err by our own error type for its extended result code.
+// (https://www.sqlite.org/rescode.html)
+func processSQLiteCode(code int, err error) db.Error {
+	switch code {
 	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
-		return NewErrAlreadyExists(err.Error())
+		return db.NewErrAlreadyExists(err.Error())
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return db.NewErrForeignKeyViolation(err.Error())
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return db.NewErrNotNullViolation(err.Error())
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return db.NewErrCheckViolation(err.Error())
+	}
+
+	// the extended codes of busy and locked share the primary code in their low byte
+	switch code & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return db.NewErrSerialization(err.Error())
+	case sqlite3.SQLITE_CANTOPEN:
+		return db.NewErrConnection(err.Error())
 	default:
 		return err
 	}
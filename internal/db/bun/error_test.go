@@ -0,0 +1,137 @@
+package bun
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func TestProcessMySQLError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number uint16
+		want   any
+	}{
+		{number: 1062, want: &db.AlreadyExistsError{}},
+		{number: 1586, want: &db.AlreadyExistsError{}},
+		{number: 1451, want: &db.ForeignKeyViolationError{}},
+		{number: 1452, want: &db.ForeignKeyViolationError{}},
+		{number: 1048, want: &db.NotNullViolationError{}},
+		{number: 3819, want: &db.CheckViolationError{}},
+		{number: 4025, want: &db.CheckViolationError{}},
+		{number: 1213, want: &db.SerializationError{}},
+		{number: 1205, want: &db.SerializationError{}},
+		{number: 2013, want: &db.ConnectionError{}},
+		{number: 1146, want: &mysql.MySQLError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.number), func(t *testing.T) {
+			t.Parallel()
+
+			err := processMySQLError(fmt.Errorf("exec: %w", &mysql.MySQLError{Number: tt.number, Message: "message"}))
+			assert.IsType(t, tt.want, unwrapAll(err))
+		})
+	}
+}
+
+func TestProcessPostgresError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code string
+		want any
+	}{
+		{code: "23505", want: &db.AlreadyExistsError{}},
+		{code: "23503", want: &db.ForeignKeyViolationError{}},
+		{code: "23502", want: &db.NotNullViolationError{}},
+		{code: "23514", want: &db.CheckViolationError{}},
+		{code: "40001", want: &db.SerializationError{}},
+		{code: "40P01", want: &db.SerializationError{}},
+		{code: "08006", want: &db.ConnectionError{}},
+		{code: "57P01", want: &db.ConnectionError{}},
+		{code: "42P01", want: &pgconn.PgError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			t.Parallel()
+
+			err := processPostgresError(fmt.Errorf("exec: %w", &pgconn.PgError{Code: tt.code, Message: "message"}))
+			assert.IsType(t, tt.want, unwrapAll(err))
+		})
+	}
+}
+
+func TestProcessSQLiteCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		code int
+		want any
+	}{
+		{name: "unique", code: sqlite3.SQLITE_CONSTRAINT_UNIQUE, want: &db.AlreadyExistsError{}},
+		{name: "primary key", code: sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, want: &db.AlreadyExistsError{}},
+		{name: "foreign key", code: sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, want: &db.ForeignKeyViolationError{}},
+		{name: "not null", code: sqlite3.SQLITE_CONSTRAINT_NOTNULL, want: &db.NotNullViolationError{}},
+		{name: "check", code: sqlite3.SQLITE_CONSTRAINT_CHECK, want: &db.CheckViolationError{}},
+		{name: "busy", code: sqlite3.SQLITE_BUSY, want: &db.SerializationError{}},
+		{name: "busy snapshot", code: sqlite3.SQLITE_BUSY_SNAPSHOT, want: &db.SerializationError{}},
+		{name: "locked shared cache", code: sqlite3.SQLITE_LOCKED_SHAREDCACHE, want: &db.SerializationError{}},
+		{name: "can't open", code: sqlite3.SQLITE_CANTOPEN, want: &db.ConnectionError{}},
+		{name: "other", code: sqlite3.SQLITE_ERROR, want: errors.New("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := processSQLiteCode(tt.code, errors.New("message"))
+			assert.IsType(t, tt.want, err)
+		})
+	}
+}
+
+func TestClient_ProcessError(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: ":memory:"})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.DoMigration(ctx))
+
+	// a real driver error is mapped from its extended code
+	_, err = client.db.NewInsert().Model(&models.Observation{EntityID: -1, Contents: "orphan"}).Exec(ctx)
+	assert.IsType(t, &db.ForeignKeyViolationError{}, client.ProcessError(err))
+
+	assert.IsType(t, &db.ConnectionError{}, client.ProcessError(fmt.Errorf("query: %w", driver.ErrBadConn)))
+	assert.IsType(t, &db.ConnectionError{}, client.ProcessError(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}))
+
+	// timeouts aren't a lost connection even though they implement net.Error
+	assert.Equal(t, context.DeadlineExceeded, client.ProcessError(context.DeadlineExceeded))
+	assert.ErrorIs(t, client.ProcessError(fmt.Errorf("query: %w", context.Canceled)), context.Canceled)
+}
+
+// unwrapAll returns the innermost error of err.
+func unwrapAll(err error) error {
+	for errors.Unwrap(err) != nil {
+		err = errors.Unwrap(err)
+	}
+
+	return err
+}
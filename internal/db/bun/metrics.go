@@ -0,0 +1,80 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/uptrace/bun"
+)
+
+// queryDurationOpts describes the histogram of query latencies, shared by every client registered with the same
+// registry.
+var queryDurationOpts = prometheus.HistogramOpts{
+	Namespace: "mcp_dbmem",
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "How long database queries took by database, operation and status.",
+	Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+}
+
+// retriesOpts describes the counter of operations retried after a transient error, shared like queryDurationOpts.
+var retriesOpts = prometheus.CounterOpts{
+	Namespace: "mcp_dbmem",
+	Subsystem: "db",
+	Name:      "retries_total",
+	Help:      "Database operations retried after a transient error by database, operation and reason.",
+}
+
+// metricsHook records the latency of every query.
+type metricsHook struct {
+	dbName   string
+	duration *prometheus.HistogramVec
+}
+
+var _ bun.QueryHook = (*metricsHook)(nil)
+
+func (h *metricsHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *metricsHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	status := "ok"
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		status = "error"
+	}
+
+	h.duration.WithLabelValues(h.dbName, event.Operation(), status).Observe(time.Since(event.StartTime).Seconds())
+}
+
+// registerMetrics adds the connection pool stats, query latencies and retries of c to registerer, labeled with dbName.
+func (c *Client) registerMetrics(registerer prometheus.Registerer, dbName string) error {
+	duration := prometheus.NewHistogramVec(queryDurationOpts, []string{"db_name", "operation", "status"})
+	if err := registerer.Register(duration); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return err
+		}
+		duration = registered.ExistingCollector.(*prometheus.HistogramVec)
+	}
+
+	retries := prometheus.NewCounterVec(retriesOpts, []string{"db_name", "operation", "reason"})
+	if err := registerer.Register(retries); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return err
+		}
+		retries = registered.ExistingCollector.(*prometheus.CounterVec)
+	}
+
+	if err := registerer.Register(collectors.NewDBStatsCollector(c.conn.DB, dbName)); err != nil {
+		return err
+	}
+	c.conn.AddQueryHook(&metricsHook{dbName: dbName, duration: duration})
+	c.retries = retries.MustCurryWith(prometheus.Labels{"db_name": dbName})
+
+	return nil
+}
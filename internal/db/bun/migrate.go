@@ -0,0 +1,288 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/db/bun/migrations"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate"
+	"go.uber.org/zap"
+)
+
+// DefaultMigrationLockTimeout is how long to wait for the migration lock if no timeout is configured.
+const DefaultMigrationLockTimeout = time.Minute
+
+const (
+	// migrationLockName names the MySQL lock held while migrating.
+	migrationLockName = "mcp-dbmem-migrations"
+	// migrationLockID identifies the Postgres advisory lock held while migrating.
+	migrationLockID = 7_302_150_921_145_081_390
+
+	migrationLockRetryMin = 100 * time.Millisecond
+	migrationLockRetryMax = 2 * time.Second
+)
+
+// errNoMigrations is returned by bun if no migrations are registered.
+const errNoMigrations = "migrate: there are no any migrations"
+
+// errMigrationLockTimeout is returned if the database gave up waiting for the migration lock.
+var errMigrationLockTimeout = errors.New("migration lock not acquired")
+
+// newMigrator returns a migrator for the registered migrations. Migrations are only marked applied once they
+// succeeded, so a failed migration is run again by the next migrate.
+func (c *Client) newMigrator() *migrate.Migrator {
+	return migrate.NewMigrator(c.conn, migrations.Migrations, migrate.WithMarkAppliedOnSuccess(true))
+}
+
+// MigrationStatus returns every migration, the applied ones with their group and when they were applied.
+func (c *Client) MigrationStatus(ctx context.Context) (migrate.MigrationSlice, error) {
+	ctx, span := tracer.Start(ctx, "MigrationStatus", tracerAttrs...)
+	defer span.End()
+
+	migrator := c.newMigrator()
+	if err := migrator.Init(ctx); err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+
+	ms, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, c.ProcessError(err)
+	}
+
+	return ms, nil
+}
+
+// RollbackMigrations rolls back the last group of applied migrations and returns it. The group is empty if no
+// migrations are applied.
+func (c *Client) RollbackMigrations(ctx context.Context) (*migrate.MigrationGroup, error) {
+	ctx, span := tracer.Start(ctx, "RollbackMigrations", tracerAttrs...)
+	defer span.End()
+
+	var group *migrate.MigrationGroup
+	err := c.withMigrationLock(ctx, func(ctx context.Context, migrator *migrate.Migrator) error {
+		var err error
+		group, err = migrator.Rollback(ctx)
+
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return group, c.ProcessError(err)
+	}
+
+	return group, nil
+}
+
+// RedoMigrations rolls back the last group of applied migrations and migrates again, returning the group applied.
+func (c *Client) RedoMigrations(ctx context.Context) (*migrate.MigrationGroup, error) {
+	ctx, span := tracer.Start(ctx, "RedoMigrations", tracerAttrs...)
+	defer span.End()
+
+	var group *migrate.MigrationGroup
+	err := c.withMigrationLock(ctx, func(ctx context.Context, migrator *migrate.Migrator) error {
+		rolledBack, err := migrator.Rollback(ctx)
+		if err != nil {
+			return fmt.Errorf("can't roll back: %w", err)
+		}
+		if rolledBack.IsZero() {
+			return errors.New("no migrations are applied")
+		}
+
+		group, err = migrator.Migrate(ctx)
+
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return group, c.ProcessError(err)
+	}
+
+	return group, nil
+}
+
+// MarkMigrationsApplied marks every unapplied migration applied without running it, for databases whose schema was
+// created another way, and returns the group marked.
+func (c *Client) MarkMigrationsApplied(ctx context.Context) (*migrate.MigrationGroup, error) {
+	ctx, span := tracer.Start(ctx, "MarkMigrationsApplied", tracerAttrs...)
+	defer span.End()
+
+	var group *migrate.MigrationGroup
+	err := c.withMigrationLock(ctx, func(ctx context.Context, migrator *migrate.Migrator) error {
+		var err error
+		group, err = migrator.Migrate(ctx, migrate.WithNopMigration())
+
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return group, c.ProcessError(err)
+	}
+
+	return group, nil
+}
+
+// UnlockMigrations releases a migration lock left behind by a migrator that didn't exit cleanly. Only SQLite keeps
+// the lock in a table, Postgres and MySQL release it when the migrator's connection closes.
+func (c *Client) UnlockMigrations(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "UnlockMigrations", tracerAttrs...)
+	defer span.End()
+
+	migrator := c.newMigrator()
+	if err := migrator.Init(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+	if err := migrator.Unlock(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) migrate(ctx context.Context) error {
+	var group *migrate.MigrationGroup
+	err := c.withMigrationLock(ctx, func(ctx context.Context, migrator *migrate.Migrator) error {
+		var err error
+		group, err = migrator.Migrate(ctx)
+
+		return err
+	})
+	if err != nil {
+		if err.Error() == errNoMigrations {
+			zap.L().Info("No migrations to run", zap.String("db_dialect", c.db.Dialect().Name().String()))
+			return nil
+		}
+
+		return err
+	}
+
+	if group.ID == 0 {
+		zap.L().Info("No migrations to run", zap.String("db_dialect", c.db.Dialect().Name().String()))
+		return nil
+	}
+	zap.L().Info("Migration successful", zap.String("db_dialect", c.db.Dialect().Name().String()), zap.String("group", group.String()))
+
+	return nil
+}
+
+// withMigrationLock runs fn with a migrator while holding the migration lock, so concurrent migrators take turns.
+func (c *Client) withMigrationLock(ctx context.Context, fn func(ctx context.Context, migrator *migrate.Migrator) error) error {
+	migrator := c.newMigrator()
+	if err := migrator.Init(ctx); err != nil {
+		return err
+	}
+
+	unlock, err := c.lockMigrations(ctx, migrator)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn(ctx, migrator)
+}
+
+// lockMigrations waits for the migration lock until the lock timeout passes and returns the function releasing it.
+// Postgres and MySQL hold the lock in a session of their own, SQLite in bun's lock table.
+func (c *Client) lockMigrations(ctx context.Context, migrator *migrate.Migrator) (func(), error) {
+	timeout := c.migrationLockTimeout
+	if timeout <= 0 {
+		timeout = DefaultMigrationLockTimeout
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var unlock func()
+	var err error
+	switch c.conn.Dialect().Name() {
+	case dialect.PG:
+		unlock, err = c.lockMigrationsSession(lockCtx, "SELECT 1 FROM pg_advisory_lock(?)", "SELECT pg_advisory_unlock(?)", int64(migrationLockID))
+	case dialect.MySQL:
+		// GET_LOCK waits up to the lock timeout on the server too, and returns 0 once it passes
+		seconds := int64(math.Ceil(timeout.Seconds()))
+		unlock, err = c.lockMigrationsSession(lockCtx, "SELECT GET_LOCK(?, ?)", "SELECT RELEASE_LOCK(?)", migrationLockName, seconds)
+	default:
+		unlock, err = lockMigrationsTable(lockCtx, migrator)
+	}
+	if err != nil {
+		if (lockCtx.Err() != nil && ctx.Err() == nil) || errors.Is(err, errMigrationLockTimeout) {
+			return nil, fmt.Errorf("timed out after %s waiting for the migration lock, is another migration running? (%w)", timeout, err)
+		}
+
+		return nil, err
+	}
+
+	return unlock, nil
+}
+
+// lockMigrationsSession takes a session lock with lockQuery, which returns 1 once it's taken, on a connection of its
+// own. The lock is released with unlockQuery and the connection closed by the returned function. The connection is
+// held while migrating, so the pool is allowed one more connection for the migrator until then.
+func (c *Client) lockMigrationsSession(ctx context.Context, lockQuery, unlockQuery string, key any, args ...any) (func(), error) {
+	maxOpen := c.conn.Stats().MaxOpenConnections
+	if maxOpen > 0 {
+		c.conn.SetMaxOpenConns(maxOpen + 1)
+	}
+	restorePool := func() {
+		if maxOpen > 0 {
+			c.conn.SetMaxOpenConns(maxOpen)
+		}
+	}
+
+	conn, err := c.conn.Conn(ctx)
+	if err != nil {
+		restorePool()
+		return nil, err
+	}
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, lockQuery, append([]any{key}, args...)...).Scan(&locked); err != nil {
+		_ = conn.Close()
+		restorePool()
+		return nil, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		_ = conn.Close()
+		restorePool()
+		return nil, errMigrationLockTimeout
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), unlockQuery, key); err != nil {
+			zap.L().Warn("Can't release the migration lock", zap.Error(err))
+		}
+		if err := conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+			zap.L().Warn("Can't close the migration lock connection", zap.Error(err))
+		}
+		restorePool()
+	}, nil
+}
+
+// lockMigrationsTable inserts into bun's lock table until it succeeds or ctx is done.
+func lockMigrationsTable(ctx context.Context, migrator *migrate.Migrator) (func(), error) {
+	wait := migrationLockRetryMin
+	for {
+		err := migrator.Lock(ctx)
+		if err == nil {
+			return func() {
+				if err := migrator.Unlock(context.Background()); err != nil {
+					zap.L().Warn("Can't release the migration lock", zap.Error(err))
+				}
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, migrationLockRetryMax)
+	}
+}
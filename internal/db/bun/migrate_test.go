@@ -0,0 +1,132 @@
+package bun
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun/migrations"
+	"github.com/uptrace/bun/migrate"
+)
+
+func TestClient_Migrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: filepath.Join(t.TempDir(), "migrations.db")})
+	require.NoError(t, err)
+	defer client.Close()
+
+	total := len(migrations.Migrations.Sorted())
+	ms, err := client.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ms.Unapplied(), total)
+
+	group, err := client.RollbackMigrations(ctx)
+	require.NoError(t, err)
+	assert.True(t, group.IsZero(), "nothing to roll back")
+	_, err = client.RedoMigrations(ctx)
+	assert.Error(t, err, "nothing to redo")
+
+	require.NoError(t, client.DoMigration(ctx))
+	ms, err = client.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ms.Applied(), total)
+
+	group, err = client.RedoMigrations(ctx)
+	require.NoError(t, err)
+	assert.Len(t, group.Migrations, total)
+	assert.NoError(t, client.Ready(ctx))
+
+	group, err = client.RollbackMigrations(ctx)
+	require.NoError(t, err)
+	assert.Len(t, group.Migrations, total)
+	assert.Error(t, client.Ready(ctx), "rolled back")
+
+	group, err = client.MarkMigrationsApplied(ctx)
+	require.NoError(t, err)
+	assert.Len(t, group.Migrations, total)
+	assert.NoError(t, client.Ready(ctx), "marked applied")
+	exists, err := client.conn.NewSelect().Table("sqlite_master").Where("name = ?", "entities").Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists, "marking applied doesn't migrate")
+}
+
+func TestClient_MigrationLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	address := filepath.Join(t.TempDir(), "lock.db")
+	newClient := func() *Client {
+		client, err := New(ctx, ClientConfig{
+			Type:                 dbTypeSqlite,
+			Address:              address,
+			MigrationLockTimeout: 200 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+
+		return client
+	}
+	holder, waiter := newClient(), newClient()
+
+	// a lock left behind times out other migrators until it's released
+	migrator := holder.newMigrator()
+	require.NoError(t, migrator.Init(ctx))
+	require.NoError(t, migrator.Lock(ctx))
+	assert.ErrorContains(t, waiter.DoMigration(ctx), "timed out")
+	require.NoError(t, holder.UnlockMigrations(ctx))
+
+	// concurrent migrators take turns
+	waiter.migrationLockTimeout = time.Minute
+	holder.migrationLockTimeout = time.Minute
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, client := range []*Client{holder, waiter} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = client.DoMigration(ctx)
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+
+	ms, err := waiter.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ms.Unapplied())
+	assert.Equal(t, int64(1), ms.LastGroupID(), "migrated once")
+}
+
+func TestClient_CheckSchema(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: filepath.Join(t.TempDir(), "schema.db")})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var schemaErr *SchemaVersionError
+	require.ErrorAs(t, client.CheckSchema(ctx), &schemaErr)
+	assert.False(t, schemaErr.Ahead())
+	assert.Len(t, schemaErr.Pending, len(migrations.Migrations.Sorted()))
+
+	require.NoError(t, client.DoMigration(ctx))
+	require.NoError(t, client.CheckSchema(ctx))
+
+	// a newer version applied a migration this one doesn't know
+	_, err = client.conn.NewInsert().
+		Model(&migrate.Migration{Name: "29991231000000", GroupID: 2}).
+		ModelTableExpr("bun_migrations").
+		Exec(ctx)
+	require.NoError(t, err)
+	require.ErrorAs(t, client.CheckSchema(ctx), &schemaErr)
+	assert.True(t, schemaErr.Ahead())
+	assert.Equal(t, []string{"29991231000000"}, schemaErr.Unknown)
+	assert.Error(t, client.Ready(ctx))
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"context"
+
+	models "github.com/tyrm/mcp-dbmem/internal/db/bun/migrations/20251019120000_changes"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"tyr.codes/libs/libmigration"
+)
+
+func init() {
+	addTables := libmigration.TableList{
+		{
+			Model: &models.Change{},
+		},
+	}
+
+	addIndexes := libmigration.IndexList{}
+
+	up := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if err := libmigration.AddTablesUp(ctx, tx, addTables); err != nil {
+				return err
+			}
+
+			// changes are looked up by time, the default DATETIME of mysql drops the fraction of a second
+			if db.Dialect().Name() == dialect.MySQL {
+				query := tx.NewRaw(
+					"ALTER TABLE ? MODIFY ? DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)",
+					bun.Ident("changes"), bun.Ident("created_at"),
+				)
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			if err := libmigration.AddIndexesUp(ctx, tx, addIndexes); err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if err := libmigration.AddIndexesDown(ctx, tx, addIndexes); err != nil {
+				return err
+			}
+
+			if err := libmigration.AddTablesDown(ctx, tx, addTables); err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
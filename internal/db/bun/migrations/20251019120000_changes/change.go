@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Change struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	SessionID string `bun:"session_id,notnull"     json:"session_id"`
+	Action    string `bun:"action,notnull"         json:"action"`
+	Kind      string `bun:"kind,notnull"           json:"kind"`
+	RecordID  int64  `bun:"record_id,notnull"      json:"record_id"`
+	Data      string `bun:"data,type:text,notnull" json:"data"`
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+
+	models "github.com/tyrm/mcp-dbmem/internal/db/bun/migrations/20251019130000_soft_delete"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+func init() {
+	softDeleteModels := []any{
+		&models.Entity{},
+		&models.Observation{},
+		&models.Relation{},
+	}
+
+	up := func(ctx context.Context, db *bun.DB) error {
+		columnType := "TIMESTAMP"
+		switch db.Dialect().Name() {
+		case dialect.PG:
+			columnType = "TIMESTAMPTZ"
+		case dialect.MySQL:
+			columnType = "DATETIME"
+		default:
+		}
+
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, model := range softDeleteModels {
+				query := tx.NewAddColumn().
+					Model(model).
+					ColumnExpr("? ? NULL", bun.Ident("deleted_at"), bun.Safe(columnType))
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, model := range softDeleteModels {
+				query := tx.NewDropColumn().
+					Model(model).
+					Column("deleted_at")
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
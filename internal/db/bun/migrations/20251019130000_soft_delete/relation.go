@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+type Relation struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	DeletedAt time.Time `bun:",soft_delete,nullzero"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type EntityAlias struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Alias      string `bun:"alias,notnull"            json:"alias"`
+	Normalized string `bun:"normalized,notnull,unique" json:"normalized"`
+
+	EntityID int64 `bun:"entity_id,notnull" json:"entity_id"`
+}
@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+func init() {
+	// only postgres has trigram indexes, other databases score similarity in go
+	trigramIndexes := []struct {
+		name   string
+		table  string
+		column string
+	}{
+		{name: "entities_name_trgm_idx", table: "entities", column: "name"},
+		{name: "entity_aliases_alias_trgm_idx", table: "entity_aliases", column: "alias"},
+	}
+
+	up := func(ctx context.Context, db *bun.DB) error {
+		if db.Dialect().Name() != dialect.PG {
+			return nil
+		}
+
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if _, err := tx.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm"); err != nil {
+				return err
+			}
+
+			for _, index := range trigramIndexes {
+				query := tx.NewCreateIndex().
+					Index(index.name).
+					Table(index.table).
+					Using("GIN").
+					ColumnExpr("? gin_trgm_ops", bun.Ident(index.column)).
+					IfNotExists()
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		if db.Dialect().Name() != dialect.PG {
+			return nil
+		}
+
+		// the extension is left in place, other schemas may be using it
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, index := range trigramIndexes {
+				query := tx.NewDropIndex().
+					Index(index.name).
+					IfExists()
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Ontology struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Document string `bun:"document,type:text,notnull" json:"document"`
+}
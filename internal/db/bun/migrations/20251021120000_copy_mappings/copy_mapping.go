@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type CopyMapping struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Source      string `bun:"source,type:varchar(64),notnull,unique:copy_mappings_source_row"       json:"source"`
+	SourceTable string `bun:"source_table,type:varchar(32),notnull,unique:copy_mappings_source_row" json:"source_table"`
+	SourceID    int64  `bun:"source_id,notnull,unique:copy_mappings_source_row"                     json:"source_id"`
+	TargetID    int64  `bun:"target_id,notnull"                                                     json:"target_id"`
+}
@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	models "github.com/tyrm/mcp-dbmem/internal/db/bun/migrations/20251022120000_sync"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"tyr.codes/libs/libmigration"
+)
+
+func init() {
+	globalIDModels := []struct {
+		model any
+		index string
+	}{
+		{model: &models.Entity{}, index: "entities_global_id_idx"},
+		{model: &models.Observation{}, index: "observations_global_id_idx"},
+		{model: &models.Relation{}, index: "relations_global_id_idx"},
+	}
+
+	addTables := libmigration.TableList{
+		{
+			Model: &models.SyncNode{},
+		},
+		{
+			Model: &models.SyncPeer{},
+		},
+	}
+
+	addIndexes := libmigration.IndexList{}
+
+	up := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, m := range globalIDModels {
+				query := tx.NewAddColumn().
+					Model(m.model).
+					ColumnExpr("? VARCHAR(36) NULL", bun.Ident("global_id"))
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+				if err := backfillGlobalIDs(ctx, tx, m.model); err != nil {
+					return err
+				}
+
+				index := tx.NewCreateIndex().
+					Model(m.model).
+					Unique().
+					Index(m.index).
+					Column("global_id")
+				if _, err := index.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			if err := libmigration.AddTablesUp(ctx, tx, addTables); err != nil {
+				return err
+			}
+
+			if err := libmigration.AddIndexesUp(ctx, tx, addIndexes); err != nil {
+				return err
+			}
+
+			// the node id identifies this database to the databases it syncs with
+			node := &models.SyncNode{NodeID: newGlobalID()}
+			if _, err := tx.NewInsert().Model(node).Exec(ctx); err != nil {
+				return err
+			}
+
+			return nil
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if err := libmigration.AddIndexesDown(ctx, tx, addIndexes); err != nil {
+				return err
+			}
+
+			if err := libmigration.AddTablesDown(ctx, tx, addTables); err != nil {
+				return err
+			}
+
+			for _, m := range globalIDModels {
+				// mysql drops the index along with the column
+				if db.Dialect().Name() != dialect.MySQL {
+					index := tx.NewDropIndex().
+						Model(m.model).
+						Index(m.index)
+					if _, err := index.Exec(ctx); err != nil {
+						return err
+					}
+				}
+
+				query := tx.NewDropColumn().
+					Model(m.model).
+					Column("global_id")
+				if _, err := query.Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
+
+// backfillGlobalIDs gives every row of the table of model a global id.
+func backfillGlobalIDs(ctx context.Context, tx bun.Tx, model any) error {
+	for {
+		var ids []int64
+		err := tx.NewSelect().
+			Model(model).
+			Column("id").
+			Where("global_id IS NULL").
+			Limit(500).
+			Scan(ctx, &ids)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			query := tx.NewUpdate().
+				Model(model).
+				Set("global_id = ?", newGlobalID()).
+				Where("id = ?", id)
+			if _, err := query.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newGlobalID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
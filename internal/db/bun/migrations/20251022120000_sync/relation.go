@@ -0,0 +1,6 @@
+package models
+
+type Relation struct {
+	ID       int64  `bun:",pk,autoincrement"`
+	GlobalID string `bun:"global_id,type:varchar(36)"`
+}
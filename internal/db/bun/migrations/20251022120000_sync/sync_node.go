@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type SyncNode struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	NodeID string `bun:"node_id,type:varchar(36),notnull,unique" json:"node_id"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type SyncPeer struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	NodeID           string    `bun:"node_id,type:varchar(36),notnull,unique" json:"node_id"`
+	SentChangeID     int64     `bun:"sent_change_id,notnull"                  json:"sent_change_id"`
+	ReceivedChangeID int64     `bun:"received_change_id,notnull"              json:"received_change_id"`
+	SyncedAt         time.Time `bun:"synced_at,notnull"                       json:"synced_at"`
+}
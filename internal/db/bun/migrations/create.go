@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	_ "embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// timestampFormat prefixes migration file names, bun orders migrations by it.
+const timestampFormat = "20060102150405"
+
+//go:embed migration.go.tmpl
+var template []byte
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes a new migration from the template to dir, named name prefixed with the UTC timestamp of now, and
+// returns its path.
+func Create(dir, name string, now time.Time) (string, error) {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", errors.New("migration name must contain a letter or digit")
+	}
+
+	path := filepath.Join(dir, now.UTC().Format(timestampFormat)+"_"+name+".go")
+	/* #nosec G304 */
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(template); err != nil {
+		_ = file.Close()
+		return "", err
+	}
+
+	return path, file.Close()
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2025, 10, 21, 9, 30, 0, 0, time.UTC)
+
+	path, err := Create(dir, "Add Entity Tags!", now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20251021093000_add_entity_tags.go"), path)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, template, contents)
+
+	_, err = Create(dir, "add entity tags", now)
+	assert.Error(t, err, "exists")
+	_, err = Create(dir, "--", now)
+	assert.Error(t, err, "no name")
+}
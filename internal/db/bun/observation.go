@@ -3,6 +3,7 @@ package bun
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/tyrm/mcp-dbmem/internal/db"
 	"github.com/tyrm/mcp-dbmem/internal/models"
@@ -18,12 +19,13 @@ func (c *Client) CreateObservation(ctx context.Context, observation *models.Obse
 	return err
 }
 
-func (c *Client) DeleteAllObservationsByEntityID(ctx context.Context, entityID int64) db.Error {
+func (c *Client) DeleteAllObservationsByEntityID(ctx context.Context, entityID int64, deletedAt time.Time) db.Error {
 	ctx, span := tracer.Start(ctx, "DeleteAllObservationsByEntityID", tracerAttrs...)
 	defer span.End()
 
-	query := c.db.NewDelete().
+	query := c.db.NewUpdate().
 		Model((*models.Observation)(nil)).
+		Set("deleted_at = ?", deletedAt).
 		Where("entity_id = ?", entityID)
 
 	if _, err := query.Exec(ctx); err != nil {
@@ -38,11 +40,122 @@ func (c *Client) DeleteObservation(ctx context.Context, observation *models.Obse
 	ctx, span := tracer.Start(ctx, "DeleteObservation", tracerAttrs...)
 	defer span.End()
 
+	c.remember(observation)
+	if observation.DeletedAt.IsZero() {
+		observation.DeletedAt = time.Now()
+	}
+
 	err := c.delete(ctx, observation)
 	span.RecordError(err)
 	return err
 }
 
+// MoveObservation moves observation to the entity with entityID.
+func (c *Client) MoveObservation(ctx context.Context, observation *models.Observation, entityID int64) db.Error {
+	ctx, span := tracer.Start(ctx, "MoveObservation", tracerAttrs...)
+	defer span.End()
+
+	c.remember(observation)
+	observation.EntityID = entityID
+	observation.Entity = nil
+	observation.UpdatedAt = time.Now().UTC()
+
+	query := c.db.
+		NewUpdate().
+		Model(observation).
+		Column("entity_id", "updated_at").
+		WherePK()
+
+	if _, err := query.Exec(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+// PurgeObservations permanently removes observations moved to the trash before deletedBefore, along with the
+// observations of entities that are purged.
+func (c *Client) PurgeObservations(ctx context.Context, deletedBefore time.Time) (int64, db.Error) {
+	ctx, span := tracer.Start(ctx, "PurgeObservations", tracerAttrs...)
+	defer span.End()
+
+	query := c.db.
+		NewDelete().
+		Model((*models.Observation)(nil)).
+		Where("deleted_at < ?", deletedBefore).
+		WhereOr("entity_id IN (?)", newDeletedEntityIDsQ(c.db, deletedBefore))
+
+	purged, err := c.purge(ctx, query)
+	span.RecordError(err)
+	return purged, err
+}
+
+// ReadDeletedObservations returns the observations in the trash with their entity, even if it is in the trash too.
+func (c *Client) ReadDeletedObservations(ctx context.Context) ([]*models.Observation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedObservations", tracerAttrs...)
+	defer span.End()
+
+	var observations []*models.Observation
+	query := c.db.
+		NewSelect().
+		Model(&observations).
+		Relation("Entity").
+		WhereAllWithDeleted().
+		Where("observation.deleted_at IS NOT NULL").
+		Order("observation.deleted_at DESC")
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+func (c *Client) ReadDeletedObservationsByEntityID(ctx context.Context, entityID int64) ([]*models.Observation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedObservationsByEntityID", tracerAttrs...)
+	defer span.End()
+
+	var observations []*models.Observation
+	query := newObservationsQ(c.db, &observations).
+		WhereDeleted().
+		Where("entity_id = ?", entityID)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+func (c *Client) ReadObservationByIDWithDeleted(ctx context.Context, id int64) (*models.Observation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadObservationByIDWithDeleted", tracerAttrs...)
+	defer span.End()
+
+	observation := new(models.Observation)
+	query := newObservationQ(c.db, observation).
+		WhereAllWithDeleted().
+		Where("id = ?", id)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return observation, nil
+}
+
 func (c *Client) ReadObservationByTextForEntityID(ctx context.Context, entityID int64, text string) (*models.Observation, db.Error) {
 	ctx, span := tracer.Start(ctx, "ReadObservationByTextForEntityID", tracerAttrs...)
 	defer span.End()
@@ -52,7 +165,7 @@ func (c *Client) ReadObservationByTextForEntityID(ctx context.Context, entityID
 		Where("contents = ?", text).
 		Where("entity_id = ?", entityID)
 
-	if err := query.Scan(ctx); err != nil {
+	if err := c.scan(ctx, query); err != nil {
 		err := c.ProcessError(err)
 		if !errors.Is(err, db.ErrNoEntries) {
 			span.RecordError(err)
@@ -63,8 +176,63 @@ func (c *Client) ReadObservationByTextForEntityID(ctx context.Context, entityID
 	return observation, nil
 }
 
+func (c *Client) ReadObservationsByEntityID(ctx context.Context, entityID int64) ([]*models.Observation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadObservationsByEntityID", tracerAttrs...)
+	defer span.End()
+
+	var observations []*models.Observation
+	query := newObservationsQ(c.db, &observations).
+		Where("entity_id = ?", entityID)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+func (c *Client) ReadOrphanObservations(ctx context.Context) ([]*models.Observation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadOrphanObservations", tracerAttrs...)
+	defer span.End()
+
+	var observations []*models.Observation
+	query := newObservationsQ(c.db, &observations).
+		Where("entity_id NOT IN (?)", newLiveEntityIDsQ(c.db))
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+func (c *Client) RestoreObservation(ctx context.Context, observation *models.Observation) db.Error {
+	ctx, span := tracer.Start(ctx, "RestoreObservation", tracerAttrs...)
+	defer span.End()
+
+	c.remember(observation)
+	observation.DeletedAt = time.Time{}
+	err := c.restore(ctx, observation)
+	span.RecordError(err)
+	return err
+}
+
 func newObservationQ(c bun.IDB, i *models.Observation) *bun.SelectQuery {
 	return c.
 		NewSelect().
 		Model(i)
 }
+
+func newObservationsQ(c bun.IDB, i *[]*models.Observation) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model(i)
+}
@@ -0,0 +1,40 @@
+package bun
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func (c *Client) CreateOntology(ctx context.Context, ontology *models.Ontology) db.Error {
+	ctx, span := tracer.Start(ctx, "CreateOntology", tracerAttrs...)
+	defer span.End()
+
+	err := c.create(ctx, ontology)
+	span.RecordError(err)
+	return err
+}
+
+func (c *Client) ReadLatestOntology(ctx context.Context) (*models.Ontology, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadLatestOntology", tracerAttrs...)
+	defer span.End()
+
+	ontology := new(models.Ontology)
+	query := c.db.
+		NewSelect().
+		Model(ontology).
+		Order("id DESC").
+		Limit(1)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return ontology, nil
+}
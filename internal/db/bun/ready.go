@@ -0,0 +1,24 @@
+package bun
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ready checks the database can be reached and its schema matches the registered migrations.
+func (c *Client) Ready(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Ready", tracerAttrs...)
+	defer span.End()
+
+	if err := c.conn.PingContext(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("can't reach database: %w", c.ProcessError(err))
+	}
+
+	if err := c.checkSchema(ctx, c.newMigrator()); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package bun
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Ready(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: filepath.Join(t.TempDir(), "ready.db")})
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Error(t, client.Ready(ctx), "not migrated")
+	require.NoError(t, client.DoMigration(ctx))
+	assert.NoError(t, client.Ready(ctx))
+
+	require.NoError(t, client.Close())
+	assert.Error(t, client.Ready(ctx), "closed")
+}
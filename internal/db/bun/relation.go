@@ -3,6 +3,7 @@ package bun
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/tyrm/mcp-dbmem/internal/db"
 	"github.com/tyrm/mcp-dbmem/internal/models"
@@ -18,15 +19,19 @@ func (c *Client) CreateRelation(ctx context.Context, relation *models.Relation)
 	return err
 }
 
-func (c *Client) DeleteAllRelationsByEntityID(ctx context.Context, entityID int64) db.Error {
+func (c *Client) DeleteAllRelationsByEntityID(ctx context.Context, entityID int64, deletedAt time.Time) db.Error {
 	ctx, span := tracer.Start(ctx, "DeleteAllRelationsByEntityID", tracerAttrs...)
 	defer span.End()
 
 	query := c.db.
-		NewDelete().
+		NewUpdate().
 		Model((*models.Relation)(nil)).
-		Where("from_id = ?", entityID).
-		WhereOr("to_id = ?", entityID)
+		Set("deleted_at = ?", deletedAt).
+		WhereGroup(" AND ", func(q *bun.UpdateQuery) *bun.UpdateQuery {
+			return q.
+				Where("from_id = ?", entityID).
+				WhereOr("to_id = ?", entityID)
+		})
 
 	if _, err := query.Exec(ctx); err != nil {
 		span.RecordError(err)
@@ -40,11 +45,34 @@ func (c *Client) DeleteRelation(ctx context.Context, relation *models.Relation)
 	ctx, span := tracer.Start(ctx, "DeleteRelation", tracerAttrs...)
 	defer span.End()
 
+	c.remember(relation)
+	if relation.DeletedAt.IsZero() {
+		relation.DeletedAt = time.Now()
+	}
+
 	err := c.delete(ctx, relation)
 	span.RecordError(err)
 	return err
 }
 
+// PurgeRelations permanently removes relations moved to the trash before deletedBefore, along with the relations
+// of entities that are purged.
+func (c *Client) PurgeRelations(ctx context.Context, deletedBefore time.Time) (int64, db.Error) {
+	ctx, span := tracer.Start(ctx, "PurgeRelations", tracerAttrs...)
+	defer span.End()
+
+	query := c.db.
+		NewDelete().
+		Model((*models.Relation)(nil)).
+		Where("deleted_at < ?", deletedBefore).
+		WhereOr("from_id IN (?)", newDeletedEntityIDsQ(c.db, deletedBefore)).
+		WhereOr("to_id IN (?)", newDeletedEntityIDsQ(c.db, deletedBefore))
+
+	purged, err := c.purge(ctx, query)
+	span.RecordError(err)
+	return purged, err
+}
+
 func (c *Client) ReadAllRelations(ctx context.Context) ([]*models.Relation, db.Error) {
 	ctx, span := tracer.Start(ctx, "ReadAllRelations", tracerAttrs...)
 	defer span.End()
@@ -52,7 +80,7 @@ func (c *Client) ReadAllRelations(ctx context.Context) ([]*models.Relation, db.E
 	var relations []*models.Relation
 	query := newRelationsQ(c.db, &relations)
 
-	if err := query.Scan(ctx); err != nil {
+	if err := c.scan(ctx, query); err != nil {
 		span.RecordError(err)
 		return nil, c.ProcessError(err)
 	}
@@ -60,17 +88,84 @@ func (c *Client) ReadAllRelations(ctx context.Context) ([]*models.Relation, db.E
 	return relations, nil
 }
 
+// ReadDeletedRelations returns the relations in the trash with their entities, even if they are in the trash too.
+func (c *Client) ReadDeletedRelations(ctx context.Context) ([]*models.Relation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedRelations", tracerAttrs...)
+	defer span.End()
+
+	var relations []*models.Relation
+	query := newDeletedRelationsQ(c.db, &relations)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+func (c *Client) ReadDeletedRelationsByEntityID(ctx context.Context, entityID int64) ([]*models.Relation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDeletedRelationsByEntityID", tracerAttrs...)
+	defer span.End()
+
+	var relations []*models.Relation
+	query := newDeletedRelationsQ(c.db, &relations).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("relation.from_id = ?", entityID).
+				WhereOr("relation.to_id = ?", entityID)
+		})
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+func (c *Client) ReadDanglingRelations(ctx context.Context) ([]*models.Relation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadDanglingRelations", tracerAttrs...)
+	defer span.End()
+
+	var relations []*models.Relation
+	query := c.db.
+		NewSelect().
+		Model(&relations).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("relation.from_id NOT IN (?)", newLiveEntityIDsQ(c.db)).
+				WhereOr("relation.to_id NOT IN (?)", newLiveEntityIDsQ(c.db))
+		})
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return relations, nil
+}
+
 func (c *Client) ReadExactRelation(ctx context.Context, fromID, toID int64, relationType string) (*models.Relation, db.Error) {
 	ctx, span := tracer.Start(ctx, "ReadExactRelation", tracerAttrs...)
 	defer span.End()
 
 	relation := new(models.Relation)
 	query := newRelationQ(c.db, relation).
-		Where("from_id = ?", fromID).
-		Where("to_id = ?", toID).
-		Where("relation_type = ?", relationType)
+		Where("relation.from_id = ?", fromID).
+		Where("relation.to_id = ?", toID).
+		Where("relation.type = ?", relationType)
 
-	if err := query.Scan(ctx); err != nil {
+	if err := c.scan(ctx, query); err != nil {
 		err := c.ProcessError(err)
 		if !errors.Is(err, db.ErrNoEntries) {
 			span.RecordError(err)
@@ -81,18 +176,90 @@ func (c *Client) ReadExactRelation(ctx context.Context, fromID, toID int64, rela
 	return relation, nil
 }
 
+func (c *Client) ReadRelationsAfterID(ctx context.Context, id int64, limit int) ([]*models.Relation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadRelationsAfterID", tracerAttrs...)
+	defer span.End()
+
+	var relations []*models.Relation
+	query := newRelationsQ(c.db, &relations).
+		Where("relation.id > ?", id).
+		Order("relation.id ASC").
+		Limit(limit)
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+func (c *Client) ReadRelationsByEntityID(ctx context.Context, entityID int64) ([]*models.Relation, db.Error) {
+	ctx, span := tracer.Start(ctx, "ReadRelationsByEntityID", tracerAttrs...)
+	defer span.End()
+
+	var relations []*models.Relation
+	query := newRelationsQ(c.db, &relations).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("relation.from_id = ?", entityID).
+				WhereOr("relation.to_id = ?", entityID)
+		})
+
+	if err := c.scan(ctx, query); err != nil {
+		err := c.ProcessError(err)
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+func (c *Client) RestoreRelation(ctx context.Context, relation *models.Relation) db.Error {
+	ctx, span := tracer.Start(ctx, "RestoreRelation", tracerAttrs...)
+	defer span.End()
+
+	c.remember(relation)
+	relation.DeletedAt = time.Time{}
+	err := c.restore(ctx, relation)
+	span.RecordError(err)
+	return err
+}
+
+// newRelationQ selects a relation with its entities. Relations to an entity in the trash are hidden.
 func newRelationQ(c bun.IDB, i *models.Relation) *bun.SelectQuery {
 	return c.
 		NewSelect().
 		Model(i).
 		Relation("From").
-		Relation("To")
+		Relation("To").
+		Where("? IS NOT NULL", bun.Ident("from.id")).
+		Where("? IS NOT NULL", bun.Ident("to.id"))
 }
 
+// newRelationsQ selects relations with their entities. Relations to an entity in the trash are hidden.
 func newRelationsQ(c bun.IDB, i *[]*models.Relation) *bun.SelectQuery {
 	return c.
 		NewSelect().
 		Model(i).
 		Relation("From").
-		Relation("To")
+		Relation("To").
+		Where("? IS NOT NULL", bun.Ident("from.id")).
+		Where("? IS NOT NULL", bun.Ident("to.id"))
+}
+
+func newDeletedRelationsQ(c bun.IDB, i *[]*models.Relation) *bun.SelectQuery {
+	return c.
+		NewSelect().
+		Model(i).
+		Relation("From").
+		Relation("To").
+		WhereAllWithDeleted().
+		Where("relation.deleted_at IS NOT NULL").
+		Order("relation.deleted_at DESC")
 }
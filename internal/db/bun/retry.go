@@ -0,0 +1,125 @@
+package bun
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+)
+
+// RetryConfig says how operations failing with a transient error, a serialization failure, deadlock or lost
+// connection, are retried.
+type RetryConfig struct {
+	// InitialInterval is the wait before the first retry. Every retry waits Multiplier times longer than the last, up
+	// to MaxInterval.
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	// Jitter randomizes every wait by up to this fraction of it so clients don't retry in lockstep.
+	Jitter float64
+	// MaxElapsedTime is how long reads and transactions are retried for, 0 disables retries.
+	MaxElapsedTime time.Duration
+}
+
+// DefaultRetryConfig is used if the client isn't configured otherwise.
+var DefaultRetryConfig = RetryConfig{
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     5 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.5,
+	MaxElapsedTime:  15 * time.Second,
+}
+
+// newBackOff returns the backoff for an operation retried for at most maxElapsed.
+func (r RetryConfig) newBackOff(maxElapsed time.Duration) *backoff.ExponentialBackOff {
+	return backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(r.InitialInterval),
+		backoff.WithMaxInterval(r.MaxInterval),
+		backoff.WithMultiplier(r.Multiplier),
+		backoff.WithRandomizationFactor(r.Jitter),
+		backoff.WithMaxElapsedTime(maxElapsed),
+	)
+}
+
+// isTransient returns true if err may go away when the operation is tried again.
+func isTransient(err error) bool {
+	var serialization *db.SerializationError
+	var connection *db.ConnectionError
+
+	return errors.As(err, &serialization) || errors.As(err, &connection)
+}
+
+// inTx returns true if c is bound to a transaction.
+func (c *Client) inTx() bool {
+	_, ok := c.db.(bun.Tx)
+	return ok
+}
+
+// retry runs fn until it succeeds, fails with an error that isn't transient, or has been retried for maxElapsed. The
+// error of the last attempt is returned unprocessed. fn must be safe to run more than once.
+func (c *Client) retry(ctx context.Context, operation string, maxElapsed time.Duration, fn func(ctx context.Context) error) error {
+	if maxElapsed <= 0 {
+		return fn(ctx)
+	}
+
+	attempt := 0
+	err := backoff.RetryNotify(
+		func() error {
+			err := fn(ctx)
+			if err != nil && !isTransient(c.ProcessError(err)) {
+				return backoff.Permanent(err)
+			}
+
+			return err
+		},
+		backoff.WithContext(c.retryConfig.newBackOff(maxElapsed), ctx),
+		func(err error, wait time.Duration) {
+			attempt++
+			reason := "connection"
+			var serialization *db.SerializationError
+			if errors.As(c.ProcessError(err), &serialization) {
+				reason = "serialization"
+			}
+
+			logging.L(ctx).Warn("Retrying database operation",
+				zap.String("operation", operation),
+				zap.String("reason", reason),
+				zap.Int("attempt", attempt),
+				zap.Duration("wait", wait),
+				zap.Error(err),
+			)
+			if c.retries != nil {
+				c.retries.WithLabelValues(operation, reason).Inc()
+			}
+		},
+	)
+
+	var permanent *backoff.PermanentError
+	if errors.As(err, &permanent) {
+		return permanent.Err
+	}
+
+	return err
+}
+
+// ping checks the database is reachable, waiting up to wait for it to come up.
+func (c *Client) ping(ctx context.Context, wait time.Duration) error {
+	return c.retry(ctx, "ping", wait, c.conn.PingContext)
+}
+
+// scan runs a read query. Outside a transaction it's retried if it fails with a transient error, inside one the
+// transaction is retried instead.
+func (c *Client) scan(ctx context.Context, query *bun.SelectQuery, dest ...any) error {
+	if c.inTx() {
+		return query.Scan(ctx, dest...)
+	}
+
+	return c.retry(ctx, "scan", c.retryConfig.MaxElapsedTime, func(ctx context.Context) error {
+		return query.Scan(ctx, dest...)
+	})
+}
@@ -0,0 +1,271 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+var errConnReset = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+var testRetryConfig = RetryConfig{
+	InitialInterval: time.Millisecond,
+	MaxInterval:     2 * time.Millisecond,
+	Multiplier:      2,
+	Jitter:          0.5,
+	MaxElapsedTime:  time.Second,
+}
+
+// fakeDriver fails every ping, query and commit with err until failures runs out.
+type fakeDriver struct {
+	err      error
+	failures atomic.Int32
+	calls    atomic.Int32
+}
+
+func (d *fakeDriver) fail() error {
+	d.calls.Add(1)
+	if d.failures.Add(-1) >= 0 {
+		return d.err
+	}
+
+	return nil
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return &fakeConn{driver: d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return &fakeTx{driver: c.driver}, nil }
+
+func (c *fakeConn) Ping(context.Context) error { return c.driver.fail() }
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	if err := c.driver.fail(); err != nil {
+		return nil, err
+	}
+
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct {
+	driver *fakeDriver
+}
+
+func (t *fakeTx) Commit() error   { return t.driver.fail() }
+func (t *fakeTx) Rollback() error { return nil }
+
+// fakeRows is a single row with n = 1.
+type fakeRows struct {
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+
+	return nil
+}
+
+func newFakeClient(t *testing.T, err error, failures int32) (*Client, *fakeDriver) {
+	t.Helper()
+
+	d := &fakeDriver{err: err}
+	d.failures.Store(failures)
+	sqldb := sql.OpenDB(d)
+	t.Cleanup(func() { _ = sqldb.Close() })
+
+	return getErrConn(bun.NewDB(sqldb, sqlitedialect.New()), testRetryConfig), d
+}
+
+func TestClient_ping(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("waits for the database", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 3)
+		require.NoError(t, client.ping(ctx, time.Second))
+		assert.Equal(t, int32(4), d.calls.Load())
+	})
+
+	t.Run("doesn't wait without a wait", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 3)
+		assert.ErrorIs(t, client.ping(ctx, 0), syscall.ECONNRESET)
+		assert.Equal(t, int32(1), d.calls.Load())
+	})
+
+	t.Run("counts the retries", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newFakeClient(t, errConnReset, 3)
+		require.NoError(t, client.registerMetrics(prometheus.NewRegistry(), "fake"))
+		require.NoError(t, client.ping(ctx, time.Second))
+		assert.Equal(t, float64(3), testutil.ToFloat64(client.retries.WithLabelValues("ping", "connection")))
+	})
+
+	t.Run("gives up after the wait", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 1<<30)
+		start := time.Now()
+		assert.ErrorIs(t, client.ping(ctx, 20*time.Millisecond), syscall.ECONNRESET)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Greater(t, d.calls.Load(), int32(1))
+	})
+}
+
+func TestClient_scan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 2)
+		var n int
+		require.NoError(t, client.scan(ctx, client.db.NewSelect().ColumnExpr("1 AS n"), &n))
+		assert.Equal(t, 1, n)
+		assert.Equal(t, int32(3), d.calls.Load())
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		t.Parallel()
+
+		errSyntax := errors.New("syntax error")
+		client, d := newFakeClient(t, errSyntax, 2)
+		var n int
+		assert.ErrorIs(t, client.scan(ctx, client.db.NewSelect().ColumnExpr("1 AS n"), &n), errSyntax)
+		assert.Equal(t, int32(1), d.calls.Load())
+	})
+
+	t.Run("doesn't retry when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 2)
+		client.retryConfig.MaxElapsedTime = 0
+		var n int
+		assert.ErrorIs(t, client.scan(ctx, client.db.NewSelect().ColumnExpr("1 AS n"), &n), syscall.ECONNRESET)
+		assert.Equal(t, int32(1), d.calls.Load())
+	})
+}
+
+func TestClient_RunInTx(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("retries a serialization failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newFakeClient(t, db.NewErrSerialization("database is locked"), 2)
+		runs := 0
+		require.NoError(t, client.RunInTx(ctx, func(context.Context, db.DB) error {
+			runs++
+			return nil
+		}))
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("retries reads with the transaction", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 1)
+		runs := 0
+		require.NoError(t, client.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+			runs++
+			txClient := tx.(*Client)
+			return txClient.scan(ctx, txClient.db.NewSelect().ColumnExpr("1 AS n"), new(int))
+		}))
+		assert.Equal(t, 2, runs)
+		// a failed read, a read and a commit
+		assert.Equal(t, int32(3), d.calls.Load())
+	})
+
+	t.Run("doesn't retry a lost connection while committing", func(t *testing.T) {
+		t.Parallel()
+
+		client, d := newFakeClient(t, errConnReset, 1)
+		runs := 0
+		err := client.RunInTx(ctx, func(context.Context, db.DB) error {
+			runs++
+			return nil
+		})
+		assert.IsType(t, &db.ConnectionError{}, err)
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, int32(1), d.calls.Load())
+	})
+
+	t.Run("puts models back before retrying", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newFakeClient(t, errConnReset, 1)
+		entity := &models.Entity{Name: "alice"}
+		var ids []int64
+		require.NoError(t, client.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+			txClient := tx.(*Client)
+			txClient.remember(entity)
+			entity.ID++
+			ids = append(ids, entity.ID)
+			return txClient.scan(ctx, txClient.db.NewSelect().ColumnExpr("1 AS n"), new(int))
+		}))
+		assert.Equal(t, []int64{1, 1}, ids)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newFakeClient(t, errConnReset, 0)
+		errFn := errors.New("fn failed")
+		runs := 0
+		assert.ErrorIs(t, client.RunInTx(ctx, func(context.Context, db.DB) error {
+			runs++
+			return errFn
+		}), errFn)
+		assert.Equal(t, 1, runs)
+	})
+
+	t.Run("fails with the processed error", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newFakeClient(t, errConnReset, 1<<30)
+		client.retryConfig.MaxElapsedTime = 20 * time.Millisecond
+		err := client.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+			txClient := tx.(*Client)
+			return txClient.scan(ctx, txClient.db.NewSelect().ColumnExpr("1 AS n"), new(int))
+		})
+		assert.IsType(t, &db.ConnectionError{}, err)
+	})
+}
@@ -0,0 +1,78 @@
+package bun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun/migrate"
+)
+
+// SchemaVersionError is returned when the migrations applied to the database don't match the registered ones.
+type SchemaVersionError struct {
+	// Pending are the registered migrations that aren't applied.
+	Pending []string
+	// Unknown are the applied migrations that aren't registered, added by a newer version.
+	Unknown []string
+}
+
+// Ahead reports whether the database was migrated by a newer version.
+func (e *SchemaVersionError) Ahead() bool {
+	return len(e.Unknown) > 0
+}
+
+// Error returns the error message as a string.
+func (e *SchemaVersionError) Error() string {
+	if e.Ahead() {
+		return fmt.Sprintf("database schema is ahead of this version, %d applied migrations are unknown: %s",
+			len(e.Unknown), strings.Join(e.Unknown, ", "))
+	}
+
+	return fmt.Sprintf("database schema is behind, %d migrations are pending: %s",
+		len(e.Pending), strings.Join(e.Pending, ", "))
+}
+
+// CheckSchema checks the migrations applied to the database match the registered ones, returning a
+// *SchemaVersionError if the schema is behind or ahead.
+func (c *Client) CheckSchema(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "CheckSchema", tracerAttrs...)
+	defer span.End()
+
+	migrator := c.newMigrator()
+	if err := migrator.Init(ctx); err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	if err := c.checkSchema(ctx, migrator); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) checkSchema(ctx context.Context, migrator *migrate.Migrator) error {
+	ms, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("can't read migration status: %w", c.ProcessError(err))
+	}
+	unknown, err := migrator.MissingMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("can't read migration status: %w", c.ProcessError(err))
+	}
+
+	schemaErr := &SchemaVersionError{}
+	for _, m := range ms.Unapplied() {
+		schemaErr.Pending = append(schemaErr.Pending, m.String())
+	}
+	// applied migrations are stored without their comment
+	for _, m := range unknown {
+		schemaErr.Unknown = append(schemaErr.Unknown, m.Name)
+	}
+	if len(schemaErr.Pending) == 0 && len(schemaErr.Unknown) == 0 {
+		return nil
+	}
+
+	return schemaErr
+}
@@ -0,0 +1,105 @@
+package bun
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SQLiteConfig holds the pragmas set on every SQLite connection.
+type SQLiteConfig struct {
+	// ForeignKeys enforces foreign keys, without it ON DELETE CASCADE does nothing.
+	ForeignKeys bool
+	// JournalMode is one of DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF.
+	JournalMode string
+	// BusyTimeout is how long a connection waits for a lock held by another.
+	BusyTimeout time.Duration
+	// Synchronous is one of OFF, NORMAL, FULL or EXTRA.
+	Synchronous string
+}
+
+// DefaultSQLiteConfig is used if a client config doesn't have a SQLite config.
+var DefaultSQLiteConfig = SQLiteConfig{
+	ForeignKeys: true,
+	JournalMode: "WAL",
+	BusyTimeout: 5 * time.Second,
+	Synchronous: "NORMAL",
+}
+
+var (
+	sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	// sqliteSynchronous is in the order of the values SQLite reports them as
+	sqliteSynchronous = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// pragmas returns the connection string parameters setting the pragmas.
+func (c SQLiteConfig) pragmas() (string, error) {
+	journalMode := strings.ToUpper(c.JournalMode)
+	if !slices.Contains(sqliteJournalModes, journalMode) {
+		return "", fmt.Errorf("unknown sqlite journal mode %q, use one of %v", c.JournalMode, sqliteJournalModes)
+	}
+	synchronous := strings.ToUpper(c.Synchronous)
+	if !slices.Contains(sqliteSynchronous, synchronous) {
+		return "", fmt.Errorf("unknown sqlite synchronous %q, use one of %v", c.Synchronous, sqliteSynchronous)
+	}
+	if c.BusyTimeout < 0 {
+		return "", fmt.Errorf("sqlite busy timeout can't be negative")
+	}
+
+	foreignKeys := 0
+	if c.ForeignKeys {
+		foreignKeys = 1
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("foreign_keys(%d)", foreignKeys))
+	params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", journalMode))
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", c.BusyTimeout.Milliseconds()))
+	params.Add("_pragma", fmt.Sprintf("synchronous(%s)", synchronous))
+
+	return params.Encode(), nil
+}
+
+// verifySQLitePragmas checks the pragmas of cfg are in effect. In memory databases can't use every journal mode, so
+// theirs isn't checked.
+func verifySQLitePragmas(ctx context.Context, conn bun.IDB, cfg SQLiteConfig, inMemory bool) error {
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("can't read sqlite foreign_keys: %w", err)
+	}
+	if (foreignKeys == 1) != cfg.ForeignKeys {
+		return fmt.Errorf("sqlite foreign_keys is %d, expected it to be %t", foreignKeys, cfg.ForeignKeys)
+	}
+
+	var journalMode string
+	if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		return fmt.Errorf("can't read sqlite journal_mode: %w", err)
+	}
+	if !inMemory && !strings.EqualFold(journalMode, cfg.JournalMode) {
+		return fmt.Errorf("sqlite journal_mode is %s, expected %s", journalMode, cfg.JournalMode)
+	}
+
+	var busyTimeout int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		return fmt.Errorf("can't read sqlite busy_timeout: %w", err)
+	}
+	if busyTimeout != cfg.BusyTimeout.Milliseconds() {
+		return fmt.Errorf("sqlite busy_timeout is %dms, expected %dms", busyTimeout, cfg.BusyTimeout.Milliseconds())
+	}
+
+	var synchronous int
+	if err := conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous); err != nil {
+		return fmt.Errorf("can't read sqlite synchronous: %w", err)
+	}
+	if synchronous < 0 || synchronous >= len(sqliteSynchronous) || !strings.EqualFold(sqliteSynchronous[synchronous], cfg.Synchronous) {
+		return fmt.Errorf("sqlite synchronous is %s, expected %s", strconv.Itoa(synchronous), cfg.Synchronous)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package bun
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_sqlitePragmas(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := SQLiteConfig{
+		ForeignKeys: true,
+		JournalMode: "truncate",
+		BusyTimeout: 1500 * time.Millisecond,
+		Synchronous: "FULL",
+	}
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: filepath.Join(t.TempDir(), "pragmas.db"), SQLite: &cfg})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	var journalMode string
+	require.NoError(t, client.conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "truncate", journalMode)
+	var busyTimeout int64
+	require.NoError(t, client.conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout))
+	assert.Equal(t, int64(1500), busyTimeout)
+	var synchronous int
+	require.NoError(t, client.conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous))
+	assert.Equal(t, 2, synchronous, "FULL")
+
+	require.NoError(t, verifySQLitePragmas(ctx, client.conn, cfg, false))
+	for _, tt := range []struct {
+		name    string
+		change  func(cfg *SQLiteConfig)
+		wantErr string
+	}{
+		{name: "journal mode", change: func(cfg *SQLiteConfig) { cfg.JournalMode = "WAL" }, wantErr: "sqlite journal_mode is truncate, expected WAL"},
+		{name: "busy timeout", change: func(cfg *SQLiteConfig) { cfg.BusyTimeout = time.Second }, wantErr: "sqlite busy_timeout is 1500ms, expected 1000ms"},
+		{name: "synchronous", change: func(cfg *SQLiteConfig) { cfg.Synchronous = "NORMAL" }, wantErr: "sqlite synchronous is 2, expected NORMAL"},
+		{name: "foreign keys", change: func(cfg *SQLiteConfig) { cfg.ForeignKeys = false }, wantErr: "sqlite foreign_keys is 1, expected it to be false"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := cfg
+			tt.change(&changed)
+			assert.EqualError(t, verifySQLitePragmas(ctx, client.conn, changed, false), tt.wantErr)
+		})
+	}
+}
+
+func TestClient_sqlitePragmas_inMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := DefaultSQLiteConfig
+	cfg.JournalMode = "wal"
+	client, err := New(ctx, ClientConfig{Type: dbTypeSqlite, Address: ":memory:", SQLite: &cfg})
+	require.NoError(t, err, "in memory databases aren't held to the journal mode")
+	t.Cleanup(func() { _ = client.Close() })
+
+	assert.EqualError(t, verifySQLitePragmas(ctx, client.conn, cfg, false), "sqlite journal_mode is memory, expected wal")
+	assert.NoError(t, verifySQLitePragmas(ctx, client.conn, cfg, true))
+}
@@ -0,0 +1,35 @@
+package bun
+
+import (
+	"context"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func (c *Client) CountGraph(ctx context.Context) (*models.GraphCounts, db.Error) {
+	ctx, span := tracer.Start(ctx, "CountGraph", tracerAttrs...)
+	defer span.End()
+
+	counts := new(models.GraphCounts)
+	for _, count := range []struct {
+		model any
+		dest  *int
+	}{
+		{model: (*models.Entity)(nil), dest: &counts.Entities},
+		{model: (*models.Observation)(nil), dest: &counts.Observations},
+		{model: (*models.Relation)(nil), dest: &counts.Relations},
+	} {
+		query := c.db.
+			NewSelect().
+			Model(count.model).
+			ColumnExpr("count(*)")
+
+		if err := c.scan(ctx, query, count.dest); err != nil {
+			span.RecordError(err)
+			return nil, c.ProcessError(err)
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,58 @@
+package bun
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestClient_CountGraph(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	client, err := New(ctx, ClientConfig{
+		Type:    dbTypeSqlite,
+		Address: filepath.Join(t.TempDir(), "stats.db"),
+		Metrics: registry,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.DoMigration(ctx))
+
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, client.CreateEntity(ctx, alice))
+	require.NoError(t, client.CreateEntity(ctx, bob))
+	require.NoError(t, client.CreateObservation(ctx, &models.Observation{EntityID: alice.ID, Contents: "likes tea"}))
+	require.NoError(t, client.CreateRelation(ctx, &models.Relation{FromID: alice.ID, ToID: bob.ID, Type: "knows"}))
+	require.NoError(t, client.DeleteEntity(ctx, bob))
+
+	counts, err := client.CountGraph(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &models.GraphCounts{Entities: 1, Observations: 1, Relations: 1}, counts)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Contains(t, names, "mcp_dbmem_db_query_duration_seconds")
+	assert.Contains(t, names, "go_sql_open_connections")
+
+	// a second client shares the latency histogram
+	other, err := New(ctx, ClientConfig{
+		Type:     dbTypeSqlite,
+		Address:  filepath.Join(t.TempDir(), "other.db"),
+		Database: "other",
+		Metrics:  registry,
+	})
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+}
@@ -0,0 +1,313 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+	"go.uber.org/zap"
+)
+
+// SyncStrategy says how a row changed differently in both databases since their last sync is resolved.
+type SyncStrategy string
+
+const (
+	// SyncLastWriterWins keeps the state of the row changed last. When an entity was deleted in one database and
+	// given observations or relations in the other, the later of the two wins.
+	SyncLastWriterWins SyncStrategy = "lww"
+	// SyncMerge keeps rows that are live in either database, so the observation sets of an entity are merged and
+	// an entity given observations in one database stays even if it was deleted in the other.
+	SyncMerge SyncStrategy = "merge"
+)
+
+// SyncConfig configures a sync.
+type SyncConfig struct {
+	Strategy SyncStrategy
+	// Full compares every row instead of the rows changed since the last sync. The first sync between two databases
+	// is always full.
+	Full bool
+	// DryRun plans the sync and reports it without changing either database.
+	DryRun bool
+}
+
+// SyncResult reports a sync.
+type SyncResult struct {
+	Full bool `json:"full"`
+	// Pulled counts the rows of every kind changed in this database, Pushed the rows changed in the remote one.
+	Pulled map[models.ChangeKind]int64 `json:"pulled"`
+	Pushed map[models.ChangeKind]int64 `json:"pushed"`
+	// Linked counts the rows created in both databases that turned out to be the same, an entity of the same name
+	// for example. They're given the same global id.
+	Linked    map[models.ChangeKind]int64 `json:"linked"`
+	Conflicts []*SyncConflict             `json:"conflicts"`
+}
+
+// SyncConflict is a row changed in both databases since their last sync that ended up different.
+type SyncConflict struct {
+	Kind     models.ChangeKind `json:"kind"`
+	GlobalID string            `json:"global_id"`
+	// Record describes the row, the name of an entity for example.
+	Record string `json:"record"`
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+	// Kept is the database whose state was kept, local or remote.
+	Kept   string `json:"kept"`
+	Reason string `json:"reason"`
+}
+
+// syncSettleWindow is how long before a sync the changes it read may still have been committing. Postgres and MySQL
+// hand out change ids on insert, so a change committed after a sync can have an id below its watermark. The changes
+// journaled in the window are read again by the next sync, rows that are the same on both sides are left alone.
+const syncSettleWindow = 5 * time.Minute
+
+// SyncKinds are the kinds of rows synced, in the order they're synced.
+var SyncKinds = []models.ChangeKind{
+	models.ChangeKindEntity,
+	models.ChangeKindObservation,
+	models.ChangeKindRelation,
+}
+
+// Sync makes the entities, observations and relations of this database and remote the same. Rows are matched by
+// their global id, and only the rows changed since the last sync between the two are compared. Changes made by the
+// sync are journaled in both databases under a session of their own, so they're passed on when syncing with a third
+// database but not sent back. Running a sync again after it failed half way is safe.
+func (c *Client) Sync(ctx context.Context, remote *Client, cfg SyncConfig) (*SyncResult, error) {
+	ctx, span := tracer.Start(ctx, "Sync", tracerAttrs...)
+	defer span.End()
+
+	result, err := c.sync(ctx, remote, cfg)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) sync(ctx context.Context, remote *Client, cfg SyncConfig) (*SyncResult, error) {
+	switch cfg.Strategy {
+	case SyncLastWriterWins, SyncMerge:
+	case "":
+		cfg.Strategy = SyncLastWriterWins
+	default:
+		return nil, fmt.Errorf("unknown sync strategy %q", cfg.Strategy)
+	}
+
+	if err := c.CheckSchema(ctx); err != nil {
+		return nil, fmt.Errorf("local: %w", err)
+	}
+	if err := remote.CheckSchema(ctx); err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+
+	localNode, err := c.syncNodeID(ctx)
+	if err != nil {
+		return nil, c.ProcessError(err)
+	}
+	remoteNode, err := remote.syncNodeID(ctx)
+	if err != nil {
+		return nil, remote.ProcessError(err)
+	}
+	if localNode == remoteNode {
+		return nil, errors.New("can't sync a database with itself")
+	}
+
+	local := &syncSide{client: c, name: "local", node: localNode}
+	other := &syncSide{client: remote, name: "remote", node: remoteNode}
+	local.other, other.other = other, local
+
+	if local.until, err = c.lastChangeID(ctx); err != nil {
+		return nil, c.ProcessError(err)
+	}
+	if other.until, err = remote.lastChangeID(ctx); err != nil {
+		return nil, remote.ProcessError(err)
+	}
+
+	full := cfg.Full
+	if !full {
+		peer, err := c.readSyncPeer(ctx, remoteNode)
+		if err != nil {
+			return nil, c.ProcessError(err)
+		}
+		switch {
+		case peer == nil:
+			full = true
+		case peer.SentChangeID > local.until || peer.ReceivedChangeID > other.until:
+			// a journal went back, the database was probably restored from a backup
+			zap.L().Warn("Journal is behind the last sync, comparing every row", zap.String("peer", remoteNode))
+			full = true
+		default:
+			// changes journaled shortly before the last sync may have committed after it under a lower id
+			settled := peer.SyncedAt.Add(-syncSettleWindow)
+			if local.since, err = c.settledChangeID(ctx, peer.SentChangeID, settled); err != nil {
+				return nil, c.ProcessError(err)
+			}
+			if other.since, err = remote.settledChangeID(ctx, peer.ReceivedChangeID, settled); err != nil {
+				return nil, remote.ProcessError(err)
+			}
+		}
+	}
+	local.full, other.full = full, full
+
+	s := &syncer{
+		strategy: cfg.Strategy,
+		local:    local,
+		remote:   other,
+		relinked: make(map[string]string),
+		result: &SyncResult{
+			Full:   full,
+			Pulled: make(map[models.ChangeKind]int64),
+			Pushed: make(map[models.ChangeKind]int64),
+			Linked: make(map[models.ChangeKind]int64),
+
+			Conflicts: make([]*SyncConflict, 0),
+		},
+	}
+	if err := s.plan(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.DryRun {
+		return s.result, nil
+	}
+
+	// both databases are changed before either watermark moves, so a failed sync is redone as a whole
+	if err := local.apply(ctx); err != nil {
+		return nil, fmt.Errorf("can't apply changes to local: %w", c.ProcessError(err))
+	}
+	if err := other.apply(ctx); err != nil {
+		return nil, fmt.Errorf("can't apply changes to remote: %w", remote.ProcessError(err))
+	}
+
+	now := time.Now().UTC()
+	err = c.writeSyncPeer(ctx, &models.SyncPeer{
+		NodeID:           remoteNode,
+		SentChangeID:     local.until,
+		ReceivedChangeID: other.until,
+		SyncedAt:         now,
+	})
+	if err != nil {
+		return nil, c.ProcessError(err)
+	}
+	err = remote.writeSyncPeer(ctx, &models.SyncPeer{
+		NodeID:           localNode,
+		SentChangeID:     other.until,
+		ReceivedChangeID: local.until,
+		SyncedAt:         now,
+	})
+	if err != nil {
+		return nil, remote.ProcessError(err)
+	}
+
+	return s.result, nil
+}
+
+// SyncNodeID returns the node id identifying this database to the databases it syncs with.
+func (c *Client) SyncNodeID(ctx context.Context) (string, error) {
+	ctx, span := tracer.Start(ctx, "SyncNodeID", tracerAttrs...)
+	defer span.End()
+
+	nodeID, err := c.syncNodeID(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return "", c.ProcessError(err)
+	}
+
+	return nodeID, nil
+}
+
+func (c *Client) syncNodeID(ctx context.Context) (string, error) {
+	node := new(models.SyncNode)
+	err := c.conn.NewSelect().
+		Model(node).
+		Order("id").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return node.NodeID, nil
+}
+
+// lastChangeID returns the id of the last change journaled, 0 if there's none.
+func (c *Client) lastChangeID(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	err := c.conn.NewSelect().
+		Model((*models.Change)(nil)).
+		ColumnExpr("MAX(id)").
+		Scan(ctx, &id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id.Int64, nil
+}
+
+// settledChangeID returns the id of the last change up to since journaled before settled, the changes after it are
+// read again. It's 0 if there's none.
+func (c *Client) settledChangeID(ctx context.Context, since int64, settled time.Time) (int64, error) {
+	var id int64
+	err := c.conn.NewSelect().
+		Model((*models.Change)(nil)).
+		Column("id").
+		Where("id <= ?", since).
+		Where("created_at < ?", settled.UTC()).
+		Order("id DESC").
+		Limit(1).
+		Scan(ctx, &id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// readSyncPeer returns the watermarks of the last sync with the database with nodeID, nil if they never synced.
+func (c *Client) readSyncPeer(ctx context.Context, nodeID string) (*models.SyncPeer, error) {
+	peer := new(models.SyncPeer)
+	err := c.conn.NewSelect().
+		Model(peer).
+		Where("node_id = ?", nodeID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return peer, nil
+}
+
+// writeSyncPeer stores the watermarks of a sync.
+func (c *Client) writeSyncPeer(ctx context.Context, peer *models.SyncPeer) error {
+	return c.conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// bun leaves the columns out of an update once Set is used, so updated_at is set through the model
+		peer.UpdatedAt = peer.SyncedAt
+		result, err := tx.NewUpdate().
+			Model(peer).
+			Column("sent_change_id", "received_change_id", "synced_at", "updated_at").
+			Where("node_id = ?", peer.NodeID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+			return nil
+		}
+
+		_, err = tx.NewInsert().
+			Model(peer).
+			ExcludeColumn("created_at", "updated_at").
+			Exec(ctx)
+
+		return err
+	})
+}
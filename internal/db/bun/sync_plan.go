@@ -0,0 +1,952 @@
+package bun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/uptrace/bun"
+)
+
+// syncBatchSize is how many rows are read at a time by id.
+const syncBatchSize = 500
+
+// syncSide is one of the two databases of a sync.
+type syncSide struct {
+	client *Client
+	name   string
+	node   string
+	other  *syncSide
+
+	// since and until are the journal range of the changes synced, every row is compared if full is set.
+	since int64
+	until int64
+	full  bool
+
+	// rows are the rows of every kind being synced by global id.
+	rows map[models.ChangeKind]map[string]*syncRow
+	// entities are the entities the synced rows refer to by global id, nil if the entity doesn't exist.
+	entities map[string]*syncRow
+	// restored holds the deletion time of the entities the sync takes out of the trash.
+	restored map[string]time.Time
+	// childChanges holds when live observations or relations were last added to entities since the last sync.
+	childChanges map[string]time.Time
+	ops          []*syncOp
+}
+
+// session returns the session id the changes made by syncing with the other side are journaled under.
+func (s *syncSide) session() string {
+	return "sync:" + s.other.node
+}
+
+// syncRow is an entity, observation or relation of one of the databases.
+type syncRow struct {
+	kind      models.ChangeKind
+	id        int64
+	globalID  string
+	createdAt time.Time
+	deletedAt time.Time
+	// changed is set if the row changed since the last sync, at changedAt.
+	changed   bool
+	changedAt time.Time
+
+	name     string
+	typ      string
+	contents string
+	// refs are the global ids of the entities the row refers to, the entity of an observation or the ends of a
+	// relation.
+	refs []string
+	// labels are the names of the refs, for the report.
+	labels []string
+	model  any
+}
+
+func (r *syncRow) live() bool {
+	return r.deletedAt.IsZero()
+}
+
+// state describes the row for the conflict report.
+func (r *syncRow) state() string {
+	state := "live"
+	if !r.live() {
+		state = "deleted " + r.deletedAt.UTC().Format(time.RFC3339)
+	}
+	if r.kind == models.ChangeKindEntity {
+		state += ", type " + r.typ
+	}
+
+	return state
+}
+
+// record describes the row for the conflict report.
+func (r *syncRow) record() string {
+	switch r.kind {
+	case models.ChangeKindObservation:
+		return r.labels[0] + ": " + r.contents
+	case models.ChangeKindRelation:
+		return r.labels[0] + " " + r.typ + " " + r.labels[1]
+	default:
+		return r.name
+	}
+}
+
+// syncAction is something done to a row of one of the databases.
+type syncAction int
+
+const (
+	syncInsert syncAction = iota
+	syncTrash
+	syncRestore
+	syncRetype
+	syncRelink
+)
+
+// syncOp is a change to a row of one of the databases. Rows are inserted from the row of the other database.
+type syncOp struct {
+	action    syncAction
+	row       *syncRow
+	globalID  string
+	deletedAt time.Time
+	typ       string
+}
+
+// syncer plans a sync.
+type syncer struct {
+	strategy SyncStrategy
+	local    *syncSide
+	remote   *syncSide
+	// relinked maps the global ids replaced while linking rows to the ids replacing them.
+	relinked map[string]string
+	result   *SyncResult
+}
+
+// plan reads the rows to sync and decides how each database has to change.
+func (s *syncer) plan(ctx context.Context) error {
+	for _, side := range []*syncSide{s.local, s.remote} {
+		side.rows = make(map[models.ChangeKind]map[string]*syncRow, len(SyncKinds))
+		side.entities = make(map[string]*syncRow)
+		side.restored = make(map[string]time.Time)
+		if err := side.readChanged(ctx); err != nil {
+			return side.client.ProcessError(err)
+		}
+	}
+
+	for _, kind := range SyncKinds {
+		// the entities are read before and after matching, rows are linked by them and the rows matched refer to them
+		if kind != models.ChangeKindEntity {
+			if err := s.readEntities(ctx, kind); err != nil {
+				return err
+			}
+		}
+		if err := s.match(ctx, kind); err != nil {
+			return err
+		}
+		if kind == models.ChangeKindEntity {
+			s.readChildChanges()
+		} else if err := s.readEntities(ctx, kind); err != nil {
+			return err
+		}
+
+		for _, globalID := range s.globalIDs(kind) {
+			s.decide(kind, globalID)
+		}
+	}
+
+	return nil
+}
+
+// readChildChanges records when live observations and relations were added to the entities of each side since the
+// last sync, their observation sets changed.
+func (s *syncer) readChildChanges() {
+	for _, side := range []*syncSide{s.local, s.remote} {
+		side.childChanges = make(map[string]time.Time)
+		for _, kind := range SyncKinds[1:] {
+			for _, row := range side.rows[kind] {
+				if !row.changed || !row.live() {
+					continue
+				}
+				for _, ref := range row.refs {
+					ref = s.resolve(ref)
+					side.childChanges[ref] = latest(side.childChanges[ref], row.changedAt)
+				}
+			}
+		}
+	}
+}
+
+// readChanged reads the rows changed since the last sync, or every row if the sync is full. The changes made by
+// syncing with the other side aren't read, the other side made them.
+func (s *syncSide) readChanged(ctx context.Context) error {
+	if s.full {
+		for _, kind := range SyncKinds {
+			rows, err := s.readRows(ctx, kind, func(q *bun.SelectQuery) *bun.SelectQuery { return q })
+			if err != nil {
+				return err
+			}
+			s.rows[kind] = make(map[string]*syncRow, len(rows))
+			for _, row := range rows {
+				row.changed = true
+				row.changedAt = latest(updatedAt(row.model), row.deletedAt)
+				s.rows[kind][row.globalID] = row
+			}
+		}
+
+		return nil
+	}
+
+	var changes []struct {
+		Kind      models.ChangeKind `bun:"kind"`
+		RecordID  int64             `bun:"record_id"`
+		ChangedAt time.Time         `bun:"changed_at"`
+	}
+	err := s.client.conn.NewSelect().
+		Model((*models.Change)(nil)).
+		Column("kind", "record_id").
+		ColumnExpr("MAX(created_at) AS changed_at").
+		Where("id > ? AND id <= ?", s.since, s.until).
+		Where("session_id <> ?", s.session()).
+		Group("kind", "record_id").
+		Scan(ctx, &changes)
+	if err != nil {
+		return err
+	}
+
+	changedAt := make(map[models.ChangeKind]map[int64]time.Time, len(SyncKinds))
+	for _, kind := range SyncKinds {
+		changedAt[kind] = make(map[int64]time.Time)
+		s.rows[kind] = make(map[string]*syncRow)
+	}
+	for _, change := range changes {
+		if ids, ok := changedAt[change.Kind]; ok {
+			ids[change.RecordID] = change.ChangedAt
+		}
+	}
+
+	for _, kind := range SyncKinds {
+		ids := make([]int64, 0, len(changedAt[kind]))
+		for id := range changedAt[kind] {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for start := 0; start < len(ids); start += syncBatchSize {
+			batch := ids[start:min(start+syncBatchSize, len(ids))]
+			rows, err := s.readRows(ctx, kind, func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("?TableAlias.id IN (?)", bun.In(batch))
+			})
+			if err != nil {
+				return err
+			}
+			// purged rows are gone, the other side keeps them
+			for _, row := range rows {
+				row.changed = true
+				row.changedAt = changedAt[kind][row.id]
+				s.rows[kind][row.globalID] = row
+			}
+		}
+	}
+
+	return nil
+}
+
+// readRows reads the rows of kind selected by where, the trash included.
+func (s *syncSide) readRows(ctx context.Context, kind models.ChangeKind, where func(*bun.SelectQuery) *bun.SelectQuery) ([]*syncRow, error) {
+	conn := s.client.conn
+	var rows []*syncRow
+	var refIDs [][]int64
+
+	switch kind {
+	case models.ChangeKindEntity:
+		var entities []*models.Entity
+		query := where(withTrash(conn, conn.NewSelect().Model(&entities), reflect.TypeFor[models.Entity]()))
+		if err := query.Order("id").Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, entity := range entities {
+			rows = append(rows, &syncRow{
+				kind:      kind,
+				id:        entity.ID,
+				globalID:  entity.GlobalID,
+				createdAt: entity.CreatedAt,
+				deletedAt: entity.DeletedAt,
+				name:      entity.Name,
+				typ:       entity.Type,
+				model:     entity,
+			})
+		}
+
+		return rows, nil
+	case models.ChangeKindObservation:
+		var observations []*models.Observation
+		query := where(withTrash(conn, conn.NewSelect().Model(&observations), reflect.TypeFor[models.Observation]()))
+		if err := query.Order("id").Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, observation := range observations {
+			rows = append(rows, &syncRow{
+				kind:      kind,
+				id:        observation.ID,
+				globalID:  observation.GlobalID,
+				createdAt: observation.CreatedAt,
+				deletedAt: observation.DeletedAt,
+				contents:  observation.Contents,
+				model:     observation,
+			})
+			refIDs = append(refIDs, []int64{observation.EntityID})
+		}
+	case models.ChangeKindRelation:
+		var relations []*models.Relation
+		query := where(withTrash(conn, conn.NewSelect().Model(&relations), reflect.TypeFor[models.Relation]()))
+		if err := query.Order("id").Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			rows = append(rows, &syncRow{
+				kind:      kind,
+				id:        relation.ID,
+				globalID:  relation.GlobalID,
+				createdAt: relation.CreatedAt,
+				deletedAt: relation.DeletedAt,
+				typ:       relation.Type,
+				model:     relation,
+			})
+			refIDs = append(refIDs, []int64{relation.FromID, relation.ToID})
+		}
+	default:
+		return nil, fmt.Errorf("can't sync %s", kind)
+	}
+
+	// rows refer to entities by their global ids, the ids differ between databases
+	var ids []int64
+	for _, refs := range refIDs {
+		ids = append(ids, refs...)
+	}
+	entities, err := s.readEntitiesByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	kept := rows[:0]
+	for i, row := range rows {
+		refs := refIDs[i]
+		for _, id := range refs {
+			entity, ok := entities[id]
+			if !ok {
+				break
+			}
+			row.refs = append(row.refs, entity.GlobalID)
+			row.labels = append(row.labels, entity.Name)
+		}
+		// rows whose entity is missing can't be synced
+		if len(row.refs) == len(refs) {
+			kept = append(kept, row)
+		}
+	}
+
+	return kept, nil
+}
+
+// readEntitiesByID reads the entities with ids, the trash included.
+func (s *syncSide) readEntitiesByID(ctx context.Context, ids []int64) (map[int64]*models.Entity, error) {
+	entities := make(map[int64]*models.Entity, len(ids))
+	for start := 0; start < len(ids); start += syncBatchSize {
+		batch := ids[start:min(start+syncBatchSize, len(ids))]
+		var read []*models.Entity
+		query := s.client.conn.NewSelect().
+			Model(&read).
+			Where("?TableAlias.id IN (?)", bun.In(batch))
+		if err := withTrash(s.client.conn, query, reflect.TypeFor[models.Entity]()).Scan(ctx); err != nil {
+			return nil, err
+		}
+		for _, entity := range read {
+			entities[entity.ID] = entity
+		}
+	}
+
+	return entities, nil
+}
+
+// readByGlobalID reads the rows of kind with globalIDs, the trash included.
+func (s *syncSide) readByGlobalID(ctx context.Context, kind models.ChangeKind, globalIDs []string) (map[string]*syncRow, error) {
+	rows := make(map[string]*syncRow, len(globalIDs))
+	for start := 0; start < len(globalIDs); start += syncBatchSize {
+		batch := globalIDs[start:min(start+syncBatchSize, len(globalIDs))]
+		read, err := s.readRows(ctx, kind, func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("?TableAlias.global_id IN (?)", bun.In(batch))
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range read {
+			rows[row.globalID] = row
+		}
+	}
+
+	return rows, nil
+}
+
+// match reads the rows of the other side that the changed rows of kind of each side have, and links the changed rows
+// the other side has under another global id.
+func (s *syncer) match(ctx context.Context, kind models.ChangeKind) error {
+	for _, side := range []*syncSide{s.local, s.remote} {
+		// every row is read in a full sync
+		if side.full {
+			break
+		}
+
+		var missing []string
+		for globalID := range side.rows[kind] {
+			if _, ok := side.other.rows[kind][globalID]; !ok {
+				missing = append(missing, globalID)
+			}
+		}
+		found, err := side.other.readByGlobalID(ctx, kind, missing)
+		if err != nil {
+			return side.other.client.ProcessError(err)
+		}
+		for globalID, row := range found {
+			side.other.rows[kind][globalID] = row
+		}
+	}
+
+	for _, side := range []*syncSide{s.local, s.remote} {
+		for _, globalID := range sortedKeys(side.rows[kind]) {
+			row := side.rows[kind][globalID]
+			if _, ok := side.other.rows[kind][globalID]; ok || !row.live() {
+				continue
+			}
+			if err := s.link(ctx, side, row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// link looks for a live row of the other side that's the same as row but has another global id, an entity of the
+// same name, an observation of the same entity and contents or a relation of the same type between the same
+// entities. Both rows are given the global id of the older one.
+func (s *syncer) link(ctx context.Context, side *syncSide, row *syncRow) error {
+	other := side.other
+	conn := other.client.conn
+
+	var model any
+	var query *bun.SelectQuery
+	switch row.kind {
+	case models.ChangeKindEntity:
+		model = new(models.Entity)
+		query = conn.NewSelect().Model(model).Where("name = ?", row.name)
+	case models.ChangeKindObservation:
+		entity, ok := other.entities[s.resolve(row.refs[0])]
+		if !ok || entity == nil {
+			return nil
+		}
+		model = new(models.Observation)
+		query = conn.NewSelect().Model(model).Where("entity_id = ? AND contents = ?", entity.id, row.contents)
+	case models.ChangeKindRelation:
+		from, fromOK := other.entities[s.resolve(row.refs[0])]
+		to, toOK := other.entities[s.resolve(row.refs[1])]
+		if !fromOK || !toOK || from == nil || to == nil {
+			return nil
+		}
+		model = new(models.Relation)
+		query = conn.NewSelect().Model(model).Where("from_id = ? AND to_id = ? AND type = ?", from.id, to.id, row.typ)
+	}
+
+	var match string
+	err := query.Column("global_id").Order("id").Limit(1).Scan(ctx, &match)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return other.client.ProcessError(err)
+	}
+	if _, ok := side.rows[row.kind][match]; ok {
+		return nil
+	}
+	otherRow, ok := other.rows[row.kind][match]
+	if !ok {
+		found, err := other.readByGlobalID(ctx, row.kind, []string{match})
+		if err != nil {
+			return other.client.ProcessError(err)
+		}
+		if otherRow, ok = found[match]; !ok {
+			return nil
+		}
+	}
+	// the global id has to be free in both databases
+	taken, err := side.readByGlobalID(ctx, row.kind, []string{match})
+	if err != nil {
+		return side.client.ProcessError(err)
+	}
+	if len(taken) > 0 {
+		return nil
+	}
+
+	// the older row keeps its global id
+	keep, renamed, renamedSide := otherRow, row, side
+	if row.createdAt.Before(otherRow.createdAt) ||
+		row.createdAt.Equal(otherRow.createdAt) && row.globalID < otherRow.globalID {
+		keep, renamed, renamedSide = row, otherRow, other
+	}
+
+	delete(renamedSide.rows[row.kind], renamed.globalID)
+	s.relinked[renamed.globalID] = keep.globalID
+	renamedSide.ops = append(renamedSide.ops, &syncOp{action: syncRelink, row: renamed, globalID: keep.globalID})
+	renamed.globalID = keep.globalID
+	side.rows[row.kind][keep.globalID] = row
+	other.rows[row.kind][keep.globalID] = otherRow
+	s.result.Linked[row.kind]++
+	if row.kind == models.ChangeKindEntity {
+		side.entities[keep.globalID], other.entities[keep.globalID] = row, otherRow
+	}
+
+	return nil
+}
+
+// readEntities reads the entities the rows of kind refer to in both databases, and counts live rows changed since
+// the last sync as changes of their entities, their observation sets changed.
+func (s *syncer) readEntities(ctx context.Context, kind models.ChangeKind) error {
+	var globalIDs []string
+	for _, side := range []*syncSide{s.local, s.remote} {
+		for _, row := range side.rows[kind] {
+			for i, ref := range row.refs {
+				row.refs[i] = s.resolve(ref)
+				globalIDs = append(globalIDs, row.refs[i])
+			}
+		}
+	}
+
+	for _, side := range []*syncSide{s.local, s.remote} {
+		var missing []string
+		for _, globalID := range globalIDs {
+			if _, ok := side.entities[globalID]; !ok {
+				missing = append(missing, globalID)
+				side.entities[globalID] = nil
+			}
+		}
+		found, err := side.readByGlobalID(ctx, models.ChangeKindEntity, missing)
+		if err != nil {
+			return side.client.ProcessError(err)
+		}
+		for globalID, row := range found {
+			side.entities[globalID] = row
+		}
+	}
+
+	return nil
+}
+
+// globalIDs returns the global ids of the rows of kind read on either side, in order.
+func (s *syncer) globalIDs(kind models.ChangeKind) []string {
+	seen := make(map[string]bool)
+	for _, side := range []*syncSide{s.local, s.remote} {
+		for globalID := range side.rows[kind] {
+			seen[globalID] = true
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// resolve returns the global id replacing globalID if it was linked.
+func (s *syncer) resolve(globalID string) string {
+	if linked, ok := s.relinked[globalID]; ok {
+		return linked
+	}
+
+	return globalID
+}
+
+// decide plans the changes making the rows with globalID of both sides the same.
+func (s *syncer) decide(kind models.ChangeKind, globalID string) {
+	local, remote := s.local.rows[kind][globalID], s.remote.rows[kind][globalID]
+	if kind == models.ChangeKindEntity {
+		s.touchEntity(s.local, local)
+		s.touchEntity(s.remote, remote)
+	}
+
+	switch {
+	case local == nil && remote == nil:
+		return
+	case local == nil:
+		s.insert(s.local, remote)
+		return
+	case remote == nil:
+		s.insert(s.remote, local)
+		return
+	}
+
+	// rows keep their entity and contents, they only differ in being in the trash or not, and in the types of
+	// entities linked by name
+	localWant, remoteWant := s.cascade(local), s.cascade(remote)
+	if localWant.IsZero() != remoteWant.IsZero() {
+		switch s.winner(local, remote, localWant.IsZero()) {
+		case local:
+			remoteWant = localWant
+		case remote:
+			localWant = remoteWant
+		default:
+			return
+		}
+	}
+	s.settle(s.local, local, localWant)
+	s.settle(s.remote, remote, remoteWant)
+
+	if kind == models.ChangeKindEntity && local.typ != remote.typ {
+		// the older entity keeps its type, every database ends up with the same one whichever pair syncs first
+		older, newer, newerSide := local, remote, s.remote
+		if remote.createdAt.Before(local.createdAt) {
+			older, newer, newerSide = remote, local, s.local
+		}
+		s.conflict(local, remote, older == local, "the older entity keeps its type")
+		newerSide.ops = append(newerSide.ops, &syncOp{action: syncRetype, row: newer, typ: older.typ})
+		s.count(newerSide, kind)
+		newer.typ = older.typ
+	}
+}
+
+// winner returns the row whose state both sides end up with when one is live and the other in the trash, nil if
+// neither changed. Rows changed on one side only win, rows changed on both are resolved by the strategy and
+// reported.
+func (s *syncer) winner(local, remote *syncRow, localLive bool) *syncRow {
+	localChanged, localAt := s.changed(s.local, local)
+	remoteChanged, remoteAt := s.changed(s.remote, remote)
+	switch {
+	case localChanged && !remoteChanged:
+		return local
+	case remoteChanged && !localChanged:
+		return remote
+	case !localChanged && !remoteChanged:
+		return nil
+	}
+
+	live, liveAt, trashed, trashedAt := local, localAt, remote, remoteAt
+	if !localLive {
+		live, liveAt, trashed, trashedAt = remote, remoteAt, local, localAt
+	}
+	winner, reason := live, "live rows are merged"
+	if s.strategy == SyncLastWriterWins {
+		reason = "changed last"
+		if trashedAt.After(liveAt) {
+			winner = trashed
+		}
+	}
+	s.conflict(local, remote, winner == local, reason)
+
+	return winner
+}
+
+// changed reports if row changed on side since the last sync and when. Entities also change when live
+// observations or relations are added to them.
+func (s *syncer) changed(side *syncSide, row *syncRow) (bool, time.Time) {
+	changed, at := row.changed, row.changedAt
+	if childAt, ok := side.childChanges[row.globalID]; ok && row.kind == models.ChangeKindEntity {
+		changed, at = true, latest(at, childAt)
+	}
+
+	return changed, at
+}
+
+// settle plans moving row of side in or out of the trash so it's deleted at deletedAt, or live if it's zero. Rows
+// already in the trash stay there.
+func (s *syncer) settle(side *syncSide, row *syncRow, deletedAt time.Time) {
+	switch {
+	case deletedAt.IsZero() && !row.live():
+		s.restore(side, row)
+	case !deletedAt.IsZero() && row.live():
+		s.trash(side, row, deletedAt)
+	}
+}
+
+// cascade returns when row has to be in the trash after the sync, zero if it stays live. Rows whose entity ends up
+// in the trash go with it, rows deleted along with an entity the sync restores come back with it.
+func (s *syncer) cascade(row *syncRow) time.Time {
+	if row.kind == models.ChangeKindEntity {
+		return row.deletedAt
+	}
+
+	for _, side := range []*syncSide{s.local, s.remote} {
+		for _, ref := range row.refs {
+			entity := side.entities[ref]
+			if entity == nil {
+				continue
+			}
+			if deletedAt, ok := side.restored[ref]; ok && !row.live() && row.deletedAt.Equal(deletedAt) {
+				return time.Time{}
+			}
+			if row.live() && !entity.live() {
+				return entity.deletedAt
+			}
+		}
+	}
+
+	return row.deletedAt
+}
+
+// touchEntity makes row the entity of its global id on side, so rows referring to it see the state the sync leaves
+// it in.
+func (s *syncer) touchEntity(side *syncSide, row *syncRow) {
+	if row != nil {
+		side.entities[row.globalID] = row
+	}
+}
+
+// insert plans inserting row into side, along with the entities it refers to that side doesn't have.
+func (s *syncer) insert(side *syncSide, row *syncRow) {
+	for _, ref := range row.refs {
+		if side.entities[ref] == nil && side.other.entities[ref] != nil {
+			s.insert(side, side.other.entities[ref])
+		}
+	}
+
+	deletedAt := s.cascade(row)
+	side.ops = append(side.ops, &syncOp{action: syncInsert, row: row, deletedAt: deletedAt})
+	s.count(side, row.kind)
+
+	if row.kind == models.ChangeKindEntity {
+		inserted := *row
+		inserted.deletedAt = deletedAt
+		side.entities[row.globalID] = &inserted
+	}
+	if !deletedAt.Equal(row.deletedAt) {
+		if deletedAt.IsZero() {
+			s.restore(side.other, row)
+		} else {
+			s.trash(side.other, row, deletedAt)
+		}
+	}
+}
+
+// trash plans moving row of side to the trash.
+func (s *syncer) trash(side *syncSide, row *syncRow, deletedAt time.Time) {
+	side.ops = append(side.ops, &syncOp{action: syncTrash, row: row, deletedAt: deletedAt})
+	s.count(side, row.kind)
+	row.deletedAt = deletedAt
+}
+
+// restore plans taking row of side out of the trash.
+func (s *syncer) restore(side *syncSide, row *syncRow) {
+	side.ops = append(side.ops, &syncOp{action: syncRestore, row: row})
+	s.count(side, row.kind)
+	if row.kind == models.ChangeKindEntity {
+		side.restored[row.globalID] = row.deletedAt
+	}
+	row.deletedAt = time.Time{}
+}
+
+func (s *syncer) count(side *syncSide, kind models.ChangeKind) {
+	if side == s.local {
+		s.result.Pulled[kind]++
+		return
+	}
+	s.result.Pushed[kind]++
+}
+
+func (s *syncer) conflict(local, remote *syncRow, localKept bool, reason string) {
+	kept := s.remote.name
+	if localKept {
+		kept = s.local.name
+	}
+	s.result.Conflicts = append(s.result.Conflicts, &SyncConflict{
+		Kind:     local.kind,
+		GlobalID: local.globalID,
+		Record:   local.record(),
+		Local:    local.state(),
+		Remote:   remote.state(),
+		Kept:     kept,
+		Reason:   reason,
+	})
+}
+
+// apply makes the planned changes to the database of the side in a transaction, journaling them.
+func (s *syncSide) apply(ctx context.Context) error {
+	if len(s.ops) == 0 {
+		return nil
+	}
+
+	return s.client.conn.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, op := range s.ops {
+			if err := s.applyOp(ctx, tx, op); err != nil {
+				return fmt.Errorf("%s %s: %w", op.row.kind, op.row.globalID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *syncSide) applyOp(ctx context.Context, tx bun.Tx, op *syncOp) error {
+	switch op.action {
+	case syncRelink:
+		setGlobalID(op.row.model, op.globalID)
+		_, err := tx.NewUpdate().
+			Model(op.row.model).
+			Set("global_id = ?", op.globalID).
+			WherePK().
+			WhereAllWithDeleted().
+			Exec(ctx)
+		return err
+	case syncRetype:
+		_, err := tx.NewUpdate().
+			Model(op.row.model).
+			Set("type = ?", op.typ).
+			WherePK().
+			WhereAllWithDeleted().
+			Exec(ctx)
+		return err
+	case syncInsert:
+		model, err := s.newModel(ctx, tx, op.row, op.deletedAt)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(model).Exec(ctx); err != nil {
+			return err
+		}
+		return s.journal(ctx, tx, models.ChangeActionCreate, model)
+	case syncTrash:
+		setDeletedAt(op.row.model, op.deletedAt)
+		_, err := tx.NewUpdate().
+			Model(op.row.model).
+			Column("deleted_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return s.journal(ctx, tx, models.ChangeActionDelete, op.row.model)
+	case syncRestore:
+		setDeletedAt(op.row.model, time.Time{})
+		_, err := tx.NewUpdate().
+			Model(op.row.model).
+			Set("deleted_at = NULL").
+			WherePK().
+			WhereDeleted().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		return s.journal(ctx, tx, models.ChangeActionCreate, op.row.model)
+	default:
+		return fmt.Errorf("unknown sync action %d", op.action)
+	}
+}
+
+// newModel returns the row of the other side to insert into this side, referring to the entities of this side.
+func (s *syncSide) newModel(ctx context.Context, tx bun.Tx, row *syncRow, deletedAt time.Time) (any, error) {
+	entityIDs := make([]int64, len(row.refs))
+	for i, ref := range row.refs {
+		entity := new(models.Entity)
+		query := tx.NewSelect().
+			Model(entity).
+			Column("id").
+			Where("global_id = ?", ref)
+		if err := withTrash(tx, query, reflect.TypeFor[models.Entity]()).Scan(ctx); err != nil {
+			return nil, fmt.Errorf("can't find entity %s: %w", ref, err)
+		}
+		entityIDs[i] = entity.ID
+	}
+
+	switch m := row.model.(type) {
+	case *models.Entity:
+		return &models.Entity{
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.UpdatedAt,
+			DeletedAt: deletedAt,
+			GlobalID:  row.globalID,
+			Name:      m.Name,
+			Type:      m.Type,
+		}, nil
+	case *models.Observation:
+		return &models.Observation{
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.UpdatedAt,
+			DeletedAt: deletedAt,
+			GlobalID:  row.globalID,
+			Contents:  m.Contents,
+			EntityID:  entityIDs[0],
+		}, nil
+	case *models.Relation:
+		return &models.Relation{
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.UpdatedAt,
+			DeletedAt: deletedAt,
+			GlobalID:  row.globalID,
+			Type:      m.Type,
+			FromID:    entityIDs[0],
+			ToID:      entityIDs[1],
+		}, nil
+	default:
+		return nil, fmt.Errorf("can't sync %T", row.model)
+	}
+}
+
+// journal records a change made by the sync, under the session of the other side.
+func (s *syncSide) journal(ctx context.Context, tx bun.Tx, action models.ChangeAction, record any) error {
+	change, err := models.NewChange(action, record)
+	if err != nil {
+		return err
+	}
+	change.SessionID = s.session()
+
+	_, err = tx.NewInsert().Model(change).Exec(ctx)
+	return err
+}
+
+func setDeletedAt(model any, deletedAt time.Time) {
+	switch m := model.(type) {
+	case *models.Entity:
+		m.DeletedAt = deletedAt
+	case *models.Observation:
+		m.DeletedAt = deletedAt
+	case *models.Relation:
+		m.DeletedAt = deletedAt
+	}
+}
+
+func setGlobalID(model any, globalID string) {
+	switch m := model.(type) {
+	case *models.Entity:
+		m.GlobalID = globalID
+	case *models.Observation:
+		m.GlobalID = globalID
+	case *models.Relation:
+		m.GlobalID = globalID
+	}
+}
+
+func updatedAt(model any) time.Time {
+	switch m := model.(type) {
+	case *models.Entity:
+		return m.UpdatedAt
+	case *models.Observation:
+		return m.UpdatedAt
+	case *models.Relation:
+		return m.UpdatedAt
+	default:
+		return time.Time{}
+	}
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+
+	return a
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,256 @@
+package bun
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tyrm/mcp-dbmem/internal/logic/v1"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// syncedGraph returns the live entities of client with their sorted observations, and the relations.
+func syncedGraph(t *testing.T, client *Client) (map[string][]string, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+	entities, err := client.ReadAllEntities(ctx)
+	require.NoError(t, err)
+	graph := make(map[string][]string, len(entities))
+	for _, entity := range entities {
+		observations, err := client.ReadObservationsByEntityID(ctx, entity.ID)
+		require.NoError(t, err)
+		contents := make([]string, 0, len(observations))
+		for _, observation := range observations {
+			contents = append(contents, observation.Contents)
+		}
+		sort.Strings(contents)
+		graph[entity.Name] = contents
+	}
+
+	relations, err := client.ReadAllRelations(ctx)
+	require.NoError(t, err)
+	described := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		described = append(described, relation.From.Name+" "+relation.Type+" "+relation.To.Name)
+	}
+	sort.Strings(described)
+
+	return graph, described
+}
+
+func TestClient_Sync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	local := newMigratedClient(t, "local.db")
+	remote := newMigratedClient(t, "remote.db")
+	localLogic := v1.NewLogic(v1.LogicConfig{DB: local})
+	remoteLogic := v1.NewLogic(v1.LogicConfig{DB: remote})
+
+	// alice is created on both sides and linked by name
+	alice := &models.Entity{Name: "alice", Type: "person"}
+	require.NoError(t, localLogic.CreateEntity(ctx, alice))
+	require.NoError(t, localLogic.CreateObservation(ctx, &models.Observation{EntityID: alice.ID, Contents: "likes tea"}))
+	remoteAlice := &models.Entity{Name: "alice", Type: "person"}
+	bob := &models.Entity{Name: "bob", Type: "person"}
+	require.NoError(t, remoteLogic.CreateEntity(ctx, remoteAlice))
+	require.NoError(t, remoteLogic.CreateEntity(ctx, bob))
+	require.NoError(t, remoteLogic.CreateObservation(ctx, &models.Observation{EntityID: remoteAlice.ID, Contents: "likes tea"}))
+	cake := &models.Observation{EntityID: remoteAlice.ID, Contents: "likes cake"}
+	require.NoError(t, remoteLogic.CreateObservation(ctx, cake))
+
+	dryRun, err := local.Sync(ctx, remote, SyncConfig{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dryRun.Pulled[models.ChangeKindEntity])
+	graph, _ := syncedGraph(t, local)
+	assert.Len(t, graph, 1, "a dry run doesn't change anything")
+
+	result, err := local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+	assert.True(t, result.Full, "the first sync is full")
+	assert.Equal(t, int64(1), result.Linked[models.ChangeKindEntity])
+	assert.Equal(t, int64(1), result.Linked[models.ChangeKindObservation])
+	assert.Empty(t, result.Conflicts)
+
+	want := map[string][]string{"alice": {"likes cake", "likes tea"}, "bob": {}}
+	localGraph, _ := syncedGraph(t, local)
+	remoteGraph, _ := syncedGraph(t, remote)
+	assert.Equal(t, want, localGraph)
+	assert.Equal(t, want, remoteGraph)
+	localAlice, err := local.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	remoteAlice, err = remote.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, localAlice.GlobalID, remoteAlice.GlobalID)
+
+	result, err = local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+	assert.False(t, result.Full)
+	assert.Empty(t, result.Pulled, "the changes made by the sync aren't synced back")
+	assert.Empty(t, result.Pushed)
+
+	// changes on either side are passed on
+	require.NoError(t, remoteLogic.DeleteObservation(ctx, cake))
+	localBob, err := local.ReadEntityByName(ctx, "bob")
+	require.NoError(t, err)
+	require.NoError(t, localLogic.CreateRelation(ctx, &models.Relation{FromID: localAlice.ID, ToID: localBob.ID, Type: "knows"}))
+	result, err = local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Pulled[models.ChangeKindObservation])
+	assert.Equal(t, int64(1), result.Pushed[models.ChangeKindRelation])
+	want = map[string][]string{"alice": {"likes tea"}, "bob": {}}
+	localGraph, localRelations := syncedGraph(t, local)
+	remoteGraph, remoteRelations := syncedGraph(t, remote)
+	assert.Equal(t, want, localGraph)
+	assert.Equal(t, want, remoteGraph)
+	assert.Equal(t, []string{"alice knows bob"}, localRelations)
+	assert.Equal(t, []string{"alice knows bob"}, remoteRelations)
+
+	// bob is deleted on one side and given an observation on the other, merging keeps him
+	require.NoError(t, localLogic.DeleteEntity(ctx, localBob))
+	remoteBob, err := remote.ReadEntityByName(ctx, "bob")
+	require.NoError(t, err)
+	require.NoError(t, remoteLogic.CreateObservation(ctx, &models.Observation{EntityID: remoteBob.ID, Contents: "likes jam"}))
+	result, err = local.Sync(ctx, remote, SyncConfig{Strategy: SyncMerge})
+	require.NoError(t, err)
+	if assert.Len(t, result.Conflicts, 1) {
+		assert.Equal(t, "bob", result.Conflicts[0].Record)
+		assert.Equal(t, "remote", result.Conflicts[0].Kept)
+	}
+	want = map[string][]string{"alice": {"likes tea"}, "bob": {"likes jam"}}
+	localGraph, localRelations = syncedGraph(t, local)
+	remoteGraph, _ = syncedGraph(t, remote)
+	assert.Equal(t, want, localGraph)
+	assert.Equal(t, want, remoteGraph)
+	assert.Equal(t, []string{"alice knows bob"}, localRelations, "the relation deleted with bob comes back")
+}
+
+func TestClient_Sync_lastWriterWins(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	local := newMigratedClient(t, "local.db")
+	remote := newMigratedClient(t, "remote.db")
+	localLogic := v1.NewLogic(v1.LogicConfig{DB: local})
+	remoteLogic := v1.NewLogic(v1.LogicConfig{DB: remote})
+
+	require.NoError(t, localLogic.CreateEntity(ctx, &models.Entity{Name: "bob", Type: "person"}))
+	_, err := local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+
+	remoteBob, err := remote.ReadEntityByName(ctx, "bob")
+	require.NoError(t, err)
+	require.NoError(t, remoteLogic.CreateObservation(ctx, &models.Observation{EntityID: remoteBob.ID, Contents: "likes jam"}))
+	localBob, err := local.ReadEntityByName(ctx, "bob")
+	require.NoError(t, err)
+	require.NoError(t, localLogic.DeleteEntity(ctx, localBob))
+	// the deletion happened after the observation was added
+	_, err = local.conn.NewUpdate().
+		Model((*models.Change)(nil)).
+		Set("created_at = ?", "2999-01-01 00:00:00").
+		Where("action = ?", models.ChangeActionDelete).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	result, err := local.Sync(ctx, remote, SyncConfig{Strategy: SyncLastWriterWins})
+	require.NoError(t, err)
+	if assert.Len(t, result.Conflicts, 1) {
+		assert.Equal(t, "local", result.Conflicts[0].Kept)
+	}
+	for _, client := range []*Client{local, remote} {
+		graph, _ := syncedGraph(t, client)
+		assert.Empty(t, graph)
+		trashed, err := client.ReadDeletedObservations(ctx)
+		require.NoError(t, err)
+		if assert.Len(t, trashed, 1) {
+			assert.Equal(t, "likes jam", trashed[0].Contents)
+		}
+	}
+}
+
+func TestClient_Sync_throughHub(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	laptop := newMigratedClient(t, "laptop.db")
+	desktop := newMigratedClient(t, "desktop.db")
+	hub := newMigratedClient(t, "hub.db")
+	laptopLogic := v1.NewLogic(v1.LogicConfig{DB: laptop})
+
+	_, err := laptop.Sync(ctx, hub, SyncConfig{})
+	require.NoError(t, err)
+	_, err = desktop.Sync(ctx, hub, SyncConfig{})
+	require.NoError(t, err)
+
+	require.NoError(t, laptopLogic.CreateEntity(ctx, &models.Entity{Name: "alice", Type: "person"}))
+	result, err := laptop.Sync(ctx, hub, SyncConfig{})
+	require.NoError(t, err)
+	assert.False(t, result.Full)
+	assert.Equal(t, int64(1), result.Pushed[models.ChangeKindEntity])
+
+	// the hub passes on what it got from the laptop
+	result, err = desktop.Sync(ctx, hub, SyncConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Pulled[models.ChangeKindEntity])
+	_, err = desktop.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+
+	result, err = laptop.Sync(ctx, hub, SyncConfig{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Pulled)
+	assert.Empty(t, result.Pushed)
+
+	_, err = laptop.Sync(ctx, laptop, SyncConfig{})
+	assert.Error(t, err)
+}
+
+func TestClient_Sync_outOfOrderCommit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	local := newMigratedClient(t, "local.db")
+	remote := newMigratedClient(t, "remote.db")
+	localLogic := v1.NewLogic(v1.LogicConfig{DB: local})
+
+	// moveChange gives the last change journaled in local the id to
+	moveChange := func(to int64) {
+		t.Helper()
+
+		last, err := local.lastChangeID(ctx)
+		require.NoError(t, err)
+		_, err = local.conn.NewUpdate().
+			Model((*models.Change)(nil)).
+			Set("id = ?", to).
+			Where("id = ?", last).
+			Exec(ctx)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, localLogic.CreateEntity(ctx, &models.Entity{Name: "alice", Type: "person"}))
+	_, err := local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+
+	// bob's change leaves a gap, as if the change before it was still committing during the sync
+	require.NoError(t, localLogic.CreateEntity(ctx, &models.Entity{Name: "bob", Type: "person"}))
+	gap, err := local.lastChangeID(ctx)
+	require.NoError(t, err)
+	moveChange(gap + 1)
+	result, err := local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Pushed[models.ChangeKindEntity])
+
+	// carol's change commits into the gap below the watermark
+	require.NoError(t, localLogic.CreateEntity(ctx, &models.Entity{Name: "carol", Type: "person"}))
+	moveChange(gap)
+	result, err = local.Sync(ctx, remote, SyncConfig{})
+	require.NoError(t, err)
+	assert.False(t, result.Full)
+	assert.Equal(t, int64(1), result.Pushed[models.ChangeKindEntity])
+	assert.Empty(t, result.Conflicts)
+
+	remoteGraph, _ := syncedGraph(t, remote)
+	assert.Equal(t, map[string][]string{"alice": {}, "bob": {}, "carol": {}}, remoteGraph)
+}
@@ -0,0 +1,153 @@
+package bun
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	dbTLSModeDisable    = "disable"
+	dbTLSModeEnable     = "enable"
+	dbTLSModeVerifyCA   = "verify-ca"
+	dbTLSModeRequire    = "require"
+	dbTLSModeVerifyFull = "verify-full"
+	dbTLSModeUnset      = ""
+)
+
+func makeTLSConfig(cfg ClientConfig) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	tlsMode := cfg.TLSMode
+	switch tlsMode {
+	case dbTLSModeDisable, dbTLSModeUnset:
+		if cfg.TLSClientCert != "" || cfg.TLSClientKey != "" {
+			return nil, errors.New("a client certificate needs a tls mode other than disable")
+		}
+		return nil, nil
+	case dbTLSModeEnable:
+		/* #nosec G402 */
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+	case dbTLSModeVerifyCA:
+		// the hostname isn't checked, so the chain is verified by hand
+		/* #nosec G402 */
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyChain(state, tlsConfig.RootCAs)
+		}
+	case dbTLSModeRequire, dbTLSModeVerifyFull:
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: false,
+		}
+	default:
+		return nil, fmt.Errorf("unknown tls mode %s, use one of disable, enable, verify-ca, require or verify-full", tlsMode)
+	}
+
+	tlsConfig.ServerName = cfg.Address
+	if cfg.TLSServerName != "" {
+		tlsConfig.ServerName = cfg.TLSServerName
+	}
+
+	minVersion, err := parseTLSVersion(cfg.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.MinVersion = minVersion
+
+	caCertPath := cfg.TLSCACert
+	if caCertPath != "" {
+		// load the system cert pool first -- we'll append the given CA cert to this
+		certPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching system CA cert pool: %w", err)
+		}
+
+		// read the CA cert from the file
+		caCert, err := readCertFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA cert file %s: %w", caCertPath, err)
+		}
+
+		// we're happy, add it to the existing pool and then use this pool in our tls config
+		certPool.AddCert(caCert)
+		tlsConfig.RootCAs = certPool
+	}
+
+	switch {
+	case cfg.TLSClientCert != "" && cfg.TLSClientKey != "":
+		clientCert, err := tls.LoadX509KeyPair(cfg.TLSClientCert, cfg.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate %s: %w", cfg.TLSClientCert, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	case cfg.TLSClientCert != "" || cfg.TLSClientKey != "":
+		return nil, errors.New("a client certificate needs both a certificate and a key")
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyChain verifies the server certificate was issued by one of roots, or the system roots if it's nil, without
+// checking the hostname.
+func verifyChain(state tls.ConnectionState, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// parseTLSVersion returns the TLS version named version, like 1.2.
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	case "1.0", "1.1":
+		return 0, fmt.Errorf("tls version %s is insecure, use 1.2 or 1.3", version)
+	default:
+		return 0, fmt.Errorf("unknown tls version %s, use 1.2 or 1.3", version)
+	}
+}
+
+func readCertFile(caCertPath string) (*x509.Certificate, error) {
+	// open the file itself and make sure there's something in it
+	/* #nosec G304 */
+	caCertBytes, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening CA certificate at %s: %w", caCertPath, err)
+	}
+	if len(caCertBytes) == 0 {
+		return nil, fmt.Errorf("ca cert at %s was empty", caCertPath)
+	}
+
+	// make sure we have a PEM block
+	caPem, _ := pem.Decode(caCertBytes)
+	if caPem == nil {
+		return nil, fmt.Errorf("could not parse cert at %s into PEM", caCertPath)
+	}
+
+	// parse the PEM block into the certificate
+	caCert, err := x509.ParseCertificate(caPem.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse cert at %s into x509 certificate: %w", caCertPath, err)
+	}
+
+	return caCert, nil
+}
@@ -0,0 +1,296 @@
+package bun
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPKI is a CA with a server certificate for db.example.com and a client certificate, written to a temp dir.
+type testPKI struct {
+	dir        string
+	caCert     string
+	clientCert string
+	clientKey  string
+	server     tls.Certificate
+	pool       *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	p := &testPKI{dir: t.TempDir()}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	p.caCert = p.write(t, "ca.crt", "CERTIFICATE", caDER)
+	p.pool = x509.NewCertPool()
+	p.pool.AddCert(ca)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		return der, key
+	}
+
+	serverDER, serverKey := issue(2, "db.example.com", x509.ExtKeyUsageServerAuth)
+	p.server = tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+
+	clientDER, clientKey := issue(3, "mcp-dbmem", x509.ExtKeyUsageClientAuth)
+	p.clientCert = p.write(t, "client.crt", "CERTIFICATE", clientDER)
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	require.NoError(t, err)
+	p.clientKey = p.write(t, "client.key", "EC PRIVATE KEY", clientKeyDER)
+
+	return p
+}
+
+func (p *testPKI) write(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(p.dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return path
+}
+
+// serve accepts TLS connections requiring a client certificate issued by the CA until the test ends, and returns the
+// address to dial.
+func (p *testPKI) serve(t *testing.T, maxVersion uint16) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{p.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    p.pool,
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   maxVersion,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// handshake dials addr with tlsConfig and completes the handshake.
+func handshake(addr string, tlsConfig *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the server only checks the client certificate once the client reads
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+func TestMakeTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPKI(t)
+	tls12 := p.serve(t, tls.VersionTLS12)
+	tls13 := p.serve(t, tls.VersionTLS13)
+
+	tests := []struct {
+		name    string
+		cfg     ClientConfig
+		addr    string
+		wantErr bool
+	}{
+		{
+			name: "verify-full with client certificate",
+			cfg:  ClientConfig{TLSMode: dbTLSModeVerifyFull, TLSServerName: "db.example.com"},
+			addr: tls13,
+		},
+		{
+			name: "require checks the server name",
+			cfg:  ClientConfig{TLSMode: dbTLSModeRequire, TLSServerName: "other.example.com"},
+			addr: tls13, wantErr: true,
+		},
+		{
+			name: "require defaults to the address",
+			cfg:  ClientConfig{TLSMode: dbTLSModeRequire, Address: "127.0.0.1"},
+			addr: tls13, wantErr: true,
+		},
+		{
+			name: "verify-ca ignores the server name",
+			cfg:  ClientConfig{TLSMode: dbTLSModeVerifyCA, Address: "127.0.0.1"},
+			addr: tls13,
+		},
+		{
+			name: "min version",
+			cfg:  ClientConfig{TLSMode: dbTLSModeVerifyFull, TLSServerName: "db.example.com", TLSMinVersion: "1.3"},
+			addr: tls12, wantErr: true,
+		},
+		{
+			name: "min version met",
+			cfg:  ClientConfig{TLSMode: dbTLSModeVerifyFull, TLSServerName: "db.example.com", TLSMinVersion: "1.2"},
+			addr: tls12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := tt.cfg
+			cfg.TLSCACert = p.caCert
+			cfg.TLSClientCert = p.clientCert
+			cfg.TLSClientKey = p.clientKey
+
+			tlsConfig, err := makeTLSConfig(cfg)
+			require.NoError(t, err)
+
+			err = handshake(tt.addr, tlsConfig)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("verify-ca rejects an unknown CA", func(t *testing.T) {
+		t.Parallel()
+
+		other := newTestPKI(t)
+		tlsConfig, err := makeTLSConfig(ClientConfig{
+			TLSMode:       dbTLSModeVerifyCA,
+			TLSCACert:     other.caCert,
+			TLSClientCert: p.clientCert,
+			TLSClientKey:  p.clientKey,
+		})
+		require.NoError(t, err)
+		assert.Error(t, handshake(tls13, tlsConfig))
+	})
+
+	t.Run("server requires a client certificate", func(t *testing.T) {
+		t.Parallel()
+
+		tlsConfig, err := makeTLSConfig(ClientConfig{
+			TLSMode:       dbTLSModeVerifyFull,
+			TLSCACert:     p.caCert,
+			TLSServerName: "db.example.com",
+		})
+		require.NoError(t, err)
+		assert.Error(t, handshake(tls13, tlsConfig))
+	})
+}
+
+func TestDeriveBunDBPGOptions_TLS(t *testing.T) {
+	// a tcp default host makes pgx default to sslmode=prefer, with a plaintext fallback
+	t.Setenv("PGHOST", "localhost")
+	t.Setenv("PGSSLMODE", "")
+
+	p := newTestPKI(t)
+
+	for _, mode := range []string{dbTLSModeDisable, dbTLSModeEnable, dbTLSModeVerifyCA, dbTLSModeRequire, dbTLSModeVerifyFull} {
+		t.Run(mode, func(t *testing.T) {
+			cfg := ClientConfig{
+				Address:  "db.example.com",
+				Database: "memory",
+				TLSMode:  mode,
+			}
+			if mode != dbTLSModeDisable {
+				cfg.TLSCACert = p.caCert
+				cfg.TLSClientCert = p.clientCert
+				cfg.TLSClientKey = p.clientKey
+			}
+
+			postgresConfig, err := deriveBunDBPGOptions(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, mode != dbTLSModeDisable, postgresConfig.TLSConfig != nil)
+			assert.Empty(t, postgresConfig.Fallbacks, "a failed handshake mustn't fall back to plaintext")
+		})
+	}
+}
+
+func TestMakeTLSConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPKI(t)
+
+	tests := []struct {
+		name string
+		cfg  ClientConfig
+	}{
+		{name: "unknown mode", cfg: ClientConfig{TLSMode: "verify"}},
+		{name: "unknown version", cfg: ClientConfig{TLSMode: dbTLSModeEnable, TLSMinVersion: "2"}},
+		{name: "insecure version", cfg: ClientConfig{TLSMode: dbTLSModeEnable, TLSMinVersion: "1.0"}},
+		{name: "certificate without key", cfg: ClientConfig{TLSMode: dbTLSModeEnable, TLSClientCert: p.clientCert}},
+		{name: "key without certificate", cfg: ClientConfig{TLSMode: dbTLSModeEnable, TLSClientKey: p.clientKey}},
+		{name: "client certificate without tls", cfg: ClientConfig{TLSMode: dbTLSModeDisable, TLSClientCert: p.clientCert, TLSClientKey: p.clientKey}},
+		{name: "mismatched key", cfg: ClientConfig{TLSMode: dbTLSModeEnable, TLSClientCert: p.clientCert, TLSClientKey: newTestPKI(t).clientKey}},
+		{name: "missing CA", cfg: ClientConfig{TLSMode: dbTLSModeRequire, TLSCACert: filepath.Join(p.dir, "missing.crt")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := makeTLSConfig(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+
+	tlsConfig, err := makeTLSConfig(ClientConfig{TLSMode: dbTLSModeDisable})
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+}
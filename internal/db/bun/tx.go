@@ -0,0 +1,117 @@
+package bun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+)
+
+// txState is shared by the clients bound to a transaction and its savepoints.
+type txState struct {
+	// undo puts the models written in the transaction back the way they were, in reverse order.
+	undo []func()
+}
+
+// reset puts every model written in the transaction back the way it was before, so it can be run again.
+func (s *txState) reset() {
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+	s.undo = nil
+}
+
+// RunInTx runs fn inside a transaction. Calls made from within an existing transaction use a savepoint. A
+// transaction that fails with a serialization failure or deadlock, or loses its connection before committing, is
+// rolled back and run again with the models it wrote put back the way they were, anything else fn collects it has to
+// reset itself. A lost connection while committing isn't retried, the commit may have gone through. Calls from within
+// an existing transaction are retried with it.
+func (c *Client) RunInTx(ctx context.Context, fn func(ctx context.Context, tx db.DB) error) db.Error {
+	ctx, span := tracer.Start(ctx, "RunInTx", tracerAttrs...)
+	defer span.End()
+
+	state := c.tx
+	if state == nil {
+		state = new(txState)
+	}
+	maxElapsed := c.retryConfig.MaxElapsedTime
+	if c.inTx() {
+		maxElapsed = 0
+	}
+
+	attempted := false
+	err := c.retry(ctx, "transaction", maxElapsed, func(ctx context.Context) error {
+		if attempted {
+			state.reset()
+		}
+		attempted = true
+
+		return c.runInTx(ctx, state, fn)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return c.ProcessError(err)
+	}
+
+	return nil
+}
+
+// runInTx runs fn in a single transaction. A lost connection while committing is returned as a permanent error so
+// it isn't retried.
+func (c *Client) runInTx(ctx context.Context, state *txState, fn func(ctx context.Context, tx db.DB) error) (err error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	done := false
+	defer func() {
+		if !done {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(ctx, &Client{
+		conn:    c.conn,
+		db:      tx,
+		errProc: c.errProc,
+		tx:      state,
+
+		retryConfig: c.retryConfig,
+		retries:     c.retries,
+	})
+	if err != nil {
+		return err
+	}
+
+	done = true
+	if err := tx.Commit(); err != nil {
+		var connection *db.ConnectionError
+		if errors.As(c.ProcessError(err), &connection) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// remember saves model so it can be put back the way it is now if the transaction is run again. It does nothing
+// outside a transaction.
+func (c *Client) remember(model any) {
+	if c.tx == nil {
+		return
+	}
+
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	saved := reflect.New(value.Elem().Type()).Elem()
+	saved.Set(value.Elem())
+	c.tx.undo = append(c.tx.undo, func() { value.Elem().Set(saved) })
+}
@@ -2,35 +2,103 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/tyrm/mcp-dbmem/internal/models"
 )
 
-// DB is the interface that wraps the basic database operations.
+// DB is the interface that wraps the basic database operations. Deletes are soft, deleted rows stay in the trash
+// until they are purged.
 type DB interface {
+	Changes
 	Entities
+	EntityAliases
 	Observations
+	Ontologies
 	Relations
+	Stats
+
+	// RunInTx runs fn inside a transaction. The DB handed to fn is bound to that transaction. fn may be run again if
+	// the transaction fails with a transient error, so it shouldn't have side effects outside of it and has to build
+	// the results it hands back from scratch every time it's run.
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx DB) error) Error
+}
+
+type Changes interface {
+	CreateChange(ctx context.Context, change *models.Change) Error
+	ReadChangesAfterID(ctx context.Context, id int64) ([]*models.Change, Error)
+	ReadChangesSince(ctx context.Context, since time.Time) ([]*models.Change, Error)
 }
 
 type Entities interface {
 	CreateEntity(ctx context.Context, entity *models.Entity) Error
 	DeleteEntity(ctx context.Context, entity *models.Entity) Error
+	PurgeEntities(ctx context.Context, deletedBefore time.Time) (int64, Error)
 	ReadAllEntities(ctx context.Context) ([]*models.Entity, Error)
+	ReadDeletedEntities(ctx context.Context) ([]*models.Entity, Error)
+	ReadDeletedEntityByName(ctx context.Context, name string) (*models.Entity, Error)
+	ReadEntitiesByIDs(ctx context.Context, ids []int64) ([]*models.Entity, Error)
 	ReadEntityByName(ctx context.Context, name string) (*models.Entity, Error)
+	// ReadEntitiesByNameFold returns the entities whose name matches name ignoring case.
+	ReadEntitiesByNameFold(ctx context.Context, name string) ([]*models.Entity, Error)
+	RestoreEntity(ctx context.Context, entity *models.Entity) Error
+	// SuggestEntities returns up to limit entities whose name or alias scores at least minScore against name, best
+	// first.
+	SuggestEntities(ctx context.Context, name string, minScore float64, limit int) ([]*models.EntitySuggestion, Error)
+}
+
+// EntityAliases are stored with their normalized form, lookups are done with it.
+type EntityAliases interface {
+	CreateEntityAlias(ctx context.Context, alias *models.EntityAlias) Error
+	DeleteEntityAlias(ctx context.Context, alias *models.EntityAlias) Error
+	ReadEntityAliasByNormalized(ctx context.Context, normalized string) (*models.EntityAlias, Error)
+	ReadEntityAliasByNormalizedWithDeleted(ctx context.Context, normalized string) (*models.EntityAlias, Error)
+	ReadEntityAliasesByEntityID(ctx context.Context, entityID int64) ([]*models.EntityAlias, Error)
 }
 
 type Observations interface {
 	CreateObservation(ctx context.Context, observation *models.Observation) Error
-	DeleteAllObservationsByEntityID(ctx context.Context, entityID int64) Error
+	DeleteAllObservationsByEntityID(ctx context.Context, entityID int64, deletedAt time.Time) Error
 	DeleteObservation(ctx context.Context, observation *models.Observation) Error
+	PurgeObservations(ctx context.Context, deletedBefore time.Time) (int64, Error)
+	ReadDeletedObservations(ctx context.Context) ([]*models.Observation, Error)
+	ReadDeletedObservationsByEntityID(ctx context.Context, entityID int64) ([]*models.Observation, Error)
+	// MoveObservation moves observation to the entity with entityID.
+	MoveObservation(ctx context.Context, observation *models.Observation, entityID int64) Error
+	// ReadObservationByIDWithDeleted returns the observation with id, including observations in the trash.
+	ReadObservationByIDWithDeleted(ctx context.Context, id int64) (*models.Observation, Error)
 	ReadObservationByTextForEntityID(ctx context.Context, entityID int64, text string) (*models.Observation, Error)
+	ReadObservationsByEntityID(ctx context.Context, entityID int64) ([]*models.Observation, Error)
+	// ReadOrphanObservations returns the observations whose entity is missing or in the trash.
+	ReadOrphanObservations(ctx context.Context) ([]*models.Observation, Error)
+	RestoreObservation(ctx context.Context, observation *models.Observation) Error
+}
+
+type Ontologies interface {
+	CreateOntology(ctx context.Context, ontology *models.Ontology) Error
+	ReadLatestOntology(ctx context.Context) (*models.Ontology, Error)
 }
 
 type Relations interface {
 	CreateRelation(ctx context.Context, relation *models.Relation) Error
-	DeleteAllRelationsByEntityID(ctx context.Context, entityID int64) Error
+	DeleteAllRelationsByEntityID(ctx context.Context, entityID int64, deletedAt time.Time) Error
+	PurgeRelations(ctx context.Context, deletedBefore time.Time) (int64, Error)
 	ReadAllRelations(ctx context.Context) ([]*models.Relation, Error)
+	ReadDeletedRelations(ctx context.Context) ([]*models.Relation, Error)
+	ReadDeletedRelationsByEntityID(ctx context.Context, entityID int64) ([]*models.Relation, Error)
+	// ReadDanglingRelations returns the relations with an entity that is missing or in the trash, without their
+	// entities.
+	ReadDanglingRelations(ctx context.Context) ([]*models.Relation, Error)
 	ReadExactRelation(ctx context.Context, fromID, toID int64, relationType string) (*models.Relation, Error)
+	// ReadRelationsAfterID returns up to limit relations with an id greater than id, ordered by id, so large graphs
+	// can be read in batches.
+	ReadRelationsAfterID(ctx context.Context, id int64, limit int) ([]*models.Relation, Error)
+	ReadRelationsByEntityID(ctx context.Context, entityID int64) ([]*models.Relation, Error)
 	DeleteRelation(ctx context.Context, relation *models.Relation) Error
+	RestoreRelation(ctx context.Context, relation *models.Relation) Error
+}
+
+type Stats interface {
+	// CountGraph returns how many entities, observations and relations there are outside the trash.
+	CountGraph(ctx context.Context) (*models.GraphCounts, Error)
 }
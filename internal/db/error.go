@@ -34,3 +34,80 @@ func (e *AlreadyExistsError) Error() string {
 func NewErrAlreadyExists(msg string) error {
 	return &AlreadyExistsError{message: msg}
 }
+
+// ForeignKeyViolationError is returned when a change refers to an entry that doesn't exist, or removes an entry that
+// is still referred to.
+type ForeignKeyViolationError struct {
+	message string
+}
+
+// Error returns the error message as a string.
+func (e *ForeignKeyViolationError) Error() string {
+	return e.message
+}
+
+// NewErrForeignKeyViolation wraps a message in a ForeignKeyViolationError object.
+func NewErrForeignKeyViolation(msg string) error {
+	return &ForeignKeyViolationError{message: msg}
+}
+
+// NotNullViolationError is returned when a required column is left empty.
+type NotNullViolationError struct {
+	message string
+}
+
+// Error returns the error message as a string.
+func (e *NotNullViolationError) Error() string {
+	return e.message
+}
+
+// NewErrNotNullViolation wraps a message in a NotNullViolationError object.
+func NewErrNotNullViolation(msg string) error {
+	return &NotNullViolationError{message: msg}
+}
+
+// CheckViolationError is returned when a value fails a check constraint.
+type CheckViolationError struct {
+	message string
+}
+
+// Error returns the error message as a string.
+func (e *CheckViolationError) Error() string {
+	return e.message
+}
+
+// NewErrCheckViolation wraps a message in a CheckViolationError object.
+func NewErrCheckViolation(msg string) error {
+	return &CheckViolationError{message: msg}
+}
+
+// SerializationError is returned when a transaction conflicts with a concurrent one, by failing to serialize,
+// deadlocking or waiting too long for a lock. Running it again may succeed.
+type SerializationError struct {
+	message string
+}
+
+// Error returns the error message as a string.
+func (e *SerializationError) Error() string {
+	return e.message
+}
+
+// NewErrSerialization wraps a message in a SerializationError object.
+func NewErrSerialization(msg string) error {
+	return &SerializationError{message: msg}
+}
+
+// ConnectionError is returned when the connection to the database is lost or can't be made.
+type ConnectionError struct {
+	message string
+}
+
+// Error returns the error message as a string.
+func (e *ConnectionError) Error() string {
+	return e.message
+}
+
+// NewErrConnection wraps a message in a ConnectionError object.
+func NewErrConnection(msg string) error {
+	return &ConnectionError{message: msg}
+}
@@ -18,3 +18,28 @@ func TestAlreadyExistsError(t *testing.T) {
 	customErr := &AlreadyExistsError{message: "custom error"}
 	assert.Equal(t, "custom error", customErr.Error())
 }
+
+func TestTypedErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want any
+	}{
+		{name: "foreign key", err: NewErrForeignKeyViolation("fk"), want: &ForeignKeyViolationError{}},
+		{name: "not null", err: NewErrNotNullViolation("not null"), want: &NotNullViolationError{}},
+		{name: "check", err: NewErrCheckViolation("check"), want: &CheckViolationError{}},
+		{name: "serialization", err: NewErrSerialization("serialization"), want: &SerializationError{}},
+		{name: "connection", err: NewErrConnection("connection"), want: &ConnectionError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.IsType(t, tt.want, tt.err)
+			assert.NotEmpty(t, tt.err.Error())
+		})
+	}
+}
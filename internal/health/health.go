@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/logging"
+	"go.uber.org/zap"
+)
+
+// DefaultTimeout bounds the readiness check if no timeout is configured.
+const DefaultTimeout = 5 * time.Second
+
+// Checker checks whether the application can serve requests.
+type Checker func(ctx context.Context) error
+
+// Config configures the health endpoints.
+type Config struct {
+	// Ready is the readiness check.
+	Ready Checker
+	// Timeout bounds the readiness check, DefaultTimeout if it's 0.
+	Timeout time.Duration
+	Version string
+	Commit  string
+}
+
+// VersionResp is the body of /version.
+type VersionResp struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+}
+
+// Register adds /healthz, answering as long as the process is up, /readyz, answering if the readiness check passes,
+// and /version to mux.
+func Register(mux *http.ServeMux, cfg Config) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeText(w, http.StatusOK, "ok")
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := cfg.Ready(ctx); err != nil {
+			logging.L(ctx).Warn("Not ready", zap.Error(err))
+			writeText(w, http.StatusServiceUnavailable, "not ready: "+err.Error())
+			return
+		}
+		writeText(w, http.StatusOK, "ok")
+	})
+
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(VersionResp{Version: cfg.Version, Commit: cfg.Commit})
+	})
+}
+
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(text + "\n"))
+}
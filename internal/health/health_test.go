@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	var readyErr error
+	mux := http.NewServeMux()
+	Register(mux, Config{
+		Ready:   func(context.Context) error { return readyErr },
+		Version: "version1.2.3",
+		Commit:  "abcdef0",
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+	assert.Equal(t, http.StatusOK, get("/readyz").Code)
+
+	readyErr = errors.New("2 migrations aren't applied")
+	notReady := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, notReady.Code)
+	assert.Contains(t, notReady.Body.String(), "2 migrations aren't applied")
+	assert.Equal(t, http.StatusOK, get("/healthz").Code)
+
+	var version VersionResp
+	require.NoError(t, json.Unmarshal(get("/version").Body.Bytes(), &version))
+	assert.Equal(t, VersionResp{Version: "version1.2.3", Commit: "abcdef0"}, version)
+}
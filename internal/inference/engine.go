@@ -0,0 +1,176 @@
+package inference
+
+// DefaultMaxDepth is how many rounds of rules are applied if no limit is given.
+const DefaultMaxDepth = 10
+
+// Fact is a relation between two entities.
+type Fact struct {
+	From     int64
+	To       int64
+	Relation string
+}
+
+// Derived is a fact inferred by a rule.
+type Derived struct {
+	Fact
+	// Rule is the name of the rule that first derived the fact.
+	Rule string
+	// Depth is the round the fact was derived in, facts derived only from stored relations have depth 1.
+	Depth int
+}
+
+// Result holds the facts derived from a set of facts.
+type Result struct {
+	Derived []Derived
+	// Truncated is true if rules were still deriving new facts when the depth limit was reached.
+	Truncated bool
+}
+
+// Infer applies the rules to facts until nothing new is derived or maxDepth rounds have run. Each round only joins
+// against at least one fact from the previous round, so cycles in the graph end the recursion instead of repeating it.
+func (r *Rules) Infer(facts []Fact, maxDepth int) Result {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	known := newIndex()
+	for _, fact := range facts {
+		known.add(fact)
+	}
+
+	var result Result
+	delta := facts
+	for depth := 1; len(delta) > 0; depth++ {
+		if depth > maxDepth {
+			result.Truncated = true
+			break
+		}
+
+		deltaIndex := newIndex()
+		for _, fact := range delta {
+			deltaIndex.add(fact)
+		}
+
+		next := newIndex()
+		var derived []Fact
+		for _, rule := range r.Rules {
+			for i := range rule.Body {
+				// atom i comes from the last round, the rest from every fact known so far
+				rule.join(i, 0, make(map[string]int64, 2*len(rule.Body)), deltaIndex, known, func(fact Fact) {
+					if known.has(fact) || next.has(fact) {
+						return
+					}
+					next.add(fact)
+					derived = append(derived, fact)
+					result.Derived = append(result.Derived, Derived{Fact: fact, Rule: rule.Name, Depth: depth})
+				})
+			}
+		}
+
+		for _, fact := range derived {
+			known.add(fact)
+		}
+		delta = derived
+	}
+
+	return result
+}
+
+// join matches the body atoms from atom on, taking atom delta from the delta index, and calls emit with the head of
+// every complete match.
+func (rule *Rule) join(delta, atom int, bindings map[string]int64, deltaIndex, known *index, emit func(Fact)) {
+	if atom == len(rule.Body) {
+		emit(Fact{
+			From:     bindings[rule.Head.From],
+			To:       bindings[rule.Head.To],
+			Relation: rule.Head.Relation,
+		})
+		return
+	}
+
+	source := known
+	if atom == delta {
+		source = deltaIndex
+	}
+
+	a := rule.Body[atom]
+	from, fromBound := bindings[a.From]
+	to, toBound := bindings[a.To]
+	source.match(a.Relation, from, fromBound, to, toBound, func(f, t int64) {
+		// the same variable on both sides only matches loops
+		if a.From == a.To && f != t {
+			return
+		}
+
+		added := make([]string, 0, 2)
+		if !fromBound {
+			bindings[a.From] = f
+			added = append(added, a.From)
+		}
+		if _, ok := bindings[a.To]; !ok {
+			bindings[a.To] = t
+			added = append(added, a.To)
+		}
+		rule.join(delta, atom+1, bindings, deltaIndex, known, emit)
+		for _, variable := range added {
+			delete(bindings, variable)
+		}
+	})
+}
+
+// index finds facts by relation and either end.
+type index struct {
+	facts  map[Fact]bool
+	byFrom map[string]map[int64][]int64
+	byTo   map[string]map[int64][]int64
+}
+
+func newIndex() *index {
+	return &index{
+		facts:  make(map[Fact]bool),
+		byFrom: make(map[string]map[int64][]int64),
+		byTo:   make(map[string]map[int64][]int64),
+	}
+}
+
+func (i *index) add(fact Fact) {
+	if i.facts[fact] {
+		return
+	}
+	i.facts[fact] = true
+
+	if i.byFrom[fact.Relation] == nil {
+		i.byFrom[fact.Relation] = make(map[int64][]int64)
+		i.byTo[fact.Relation] = make(map[int64][]int64)
+	}
+	i.byFrom[fact.Relation][fact.From] = append(i.byFrom[fact.Relation][fact.From], fact.To)
+	i.byTo[fact.Relation][fact.To] = append(i.byTo[fact.Relation][fact.To], fact.From)
+}
+
+func (i *index) has(fact Fact) bool {
+	return i.facts[fact]
+}
+
+// match calls fn with both ends of every fact of relation matching the bound ends.
+func (i *index) match(relation string, from int64, fromBound bool, to int64, toBound bool, fn func(from, to int64)) {
+	switch {
+	case fromBound && toBound:
+		if i.facts[Fact{From: from, To: to, Relation: relation}] {
+			fn(from, to)
+		}
+	case fromBound:
+		for _, t := range i.byFrom[relation][from] {
+			fn(from, t)
+		}
+	case toBound:
+		for _, f := range i.byTo[relation][to] {
+			fn(f, to)
+		}
+	default:
+		for f, tos := range i.byFrom[relation] {
+			for _, t := range tos {
+				fn(f, t)
+			}
+		}
+	}
+}
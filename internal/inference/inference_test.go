@@ -0,0 +1,125 @@
+package inference
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:     "transitive and rule",
+			document: "transitive: [part_of]\nrules:\n  - works_in(A, C) :- works_at(A, B), part_of(B, C).\n",
+			want:     2,
+		},
+		{
+			name:     "missing implication",
+			document: "rules:\n  - works_in(A, C)\n",
+			wantErr:  true,
+		},
+		{
+			name:     "constant",
+			document: "rules:\n  - works_in(A, acme) :- works_at(A, acme).\n",
+			wantErr:  true,
+		},
+		{
+			name:     "unbound head variable",
+			document: "rules:\n  - works_in(A, C) :- works_at(A, B).\n",
+			wantErr:  true,
+		},
+		{
+			name:     "not yaml",
+			document: "rules: [",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse([]byte(tt.document))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, rules.Rules, tt.want)
+		})
+	}
+}
+
+func TestRules_Infer(t *testing.T) {
+	rules, err := Parse([]byte("transitive: [part_of]\nrules:\n  - works_in(A, C) :- works_at(A, B), part_of(B, C).\n"))
+	require.NoError(t, err)
+
+	// 1 works at 2, which is part of 3, which is part of 4
+	facts := []Fact{
+		{From: 1, To: 2, Relation: "works_at"},
+		{From: 2, To: 3, Relation: "part_of"},
+		{From: 3, To: 4, Relation: "part_of"},
+	}
+
+	tests := []struct {
+		name          string
+		maxDepth      int
+		want          []Derived
+		wantTruncated bool
+	}{
+		{
+			name: "fixpoint",
+			want: []Derived{
+				{Fact: Fact{From: 1, To: 3, Relation: "works_in"}, Rule: rules.Rules[1].Name, Depth: 1},
+				{Fact: Fact{From: 1, To: 4, Relation: "works_in"}, Rule: rules.Rules[1].Name, Depth: 2},
+				{Fact: Fact{From: 2, To: 4, Relation: "part_of"}, Rule: "transitive part_of", Depth: 1},
+			},
+		},
+		{
+			name:     "bounded",
+			maxDepth: 1,
+			want: []Derived{
+				{Fact: Fact{From: 1, To: 3, Relation: "works_in"}, Rule: rules.Rules[1].Name, Depth: 1},
+				{Fact: Fact{From: 2, To: 4, Relation: "part_of"}, Rule: "transitive part_of", Depth: 1},
+			},
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rules.Infer(facts, tt.maxDepth)
+			sort.Slice(result.Derived, func(i, j int) bool {
+				a, b := result.Derived[i], result.Derived[j]
+				if a.From != b.From {
+					return a.From < b.From
+				}
+				return a.To < b.To
+			})
+			assert.Equal(t, tt.want, result.Derived)
+			assert.Equal(t, tt.wantTruncated, result.Truncated)
+		})
+	}
+}
+
+func TestRules_InferCycle(t *testing.T) {
+	rules, err := Parse([]byte("transitive: [reports_to]\n"))
+	require.NoError(t, err)
+
+	result := rules.Infer([]Fact{
+		{From: 1, To: 2, Relation: "reports_to"},
+		{From: 2, To: 1, Relation: "reports_to"},
+	}, 100)
+
+	assert.False(t, result.Truncated)
+	assert.ElementsMatch(t, []Fact{
+		{From: 1, To: 1, Relation: "reports_to"},
+		{From: 2, To: 2, Relation: "reports_to"},
+	}, []Fact{result.Derived[0].Fact, result.Derived[1].Fact})
+	assert.Len(t, result.Derived, 2)
+}
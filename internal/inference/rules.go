@@ -0,0 +1,144 @@
+package inference
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are the Datalog style rules relations are inferred with.
+type Rules struct {
+	Rules []*Rule
+}
+
+// Rule derives its head relation for every way its body relations can be matched.
+type Rule struct {
+	// Name is the rule as it was written.
+	Name string
+	Head Atom
+	Body []Atom
+}
+
+// Atom is a relation between two variables, e.g. works_at(A, B).
+type Atom struct {
+	Relation string
+	From     string
+	To       string
+}
+
+// document is the YAML form of the rules.
+type document struct {
+	// Transitive lists relation types that are transitive, e.g. part_of.
+	Transitive []string `yaml:"transitive"`
+	// Rules are written as head :- body, e.g. works_in(A, C) :- works_at(A, B), part_of(B, C).
+	Rules []string `yaml:"rules"`
+}
+
+var atomPattern = regexp.MustCompile(`^\s*([^\s(),]+)\s*\(\s*([^\s(),]+)\s*,\s*([^\s(),]+)\s*\)\s*$`)
+
+// Load reads rules from a YAML file.
+func Load(path string) (*Rules, error) {
+	/* #nosec G304 */
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read rules %s: %w", path, err)
+	}
+
+	return Parse(data)
+}
+
+// Parse reads rules from a YAML document.
+func Parse(data []byte) (*Rules, error) {
+	doc := new(document)
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("can't parse rules: %w", err)
+	}
+
+	var errs []error
+	rules := &Rules{Rules: make([]*Rule, 0, len(doc.Transitive)+len(doc.Rules))}
+	for _, relation := range doc.Transitive {
+		rule, err := ParseRule(fmt.Sprintf("%[1]s(X, Z) :- %[1]s(X, Y), %[1]s(Y, Z).", relation))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rule.Name = "transitive " + relation
+		rules.Rules = append(rules.Rules, rule)
+	}
+	for _, text := range doc.Rules {
+		rule, err := ParseRule(text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid rules: %w", errors.Join(errs...))
+	}
+
+	return rules, nil
+}
+
+// ParseRule reads a rule written as head :- body, e.g. works_in(A, C) :- works_at(A, B), part_of(B, C).
+func ParseRule(text string) (*Rule, error) {
+	name := strings.TrimSpace(text)
+	head, body, ok := strings.Cut(strings.TrimSuffix(name, "."), ":-")
+	if !ok {
+		return nil, fmt.Errorf("rule %q has no :-", name)
+	}
+
+	rule := &Rule{Name: name}
+	var err error
+	if rule.Head, err = parseAtom(head); err != nil {
+		return nil, fmt.Errorf("rule %q: %w", name, err)
+	}
+
+	// atoms are separated by the commas outside their parentheses
+	for _, part := range strings.SplitAfter(body, ")") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), ",")
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		atom, err := parseAtom(part)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", name, err)
+		}
+		rule.Body = append(rule.Body, atom)
+	}
+	if len(rule.Body) == 0 {
+		return nil, fmt.Errorf("rule %q has an empty body", name)
+	}
+
+	// every variable of the head has to be bound by the body
+	bound := make(map[string]bool)
+	for _, atom := range rule.Body {
+		bound[atom.From], bound[atom.To] = true, true
+	}
+	for _, variable := range []string{rule.Head.From, rule.Head.To} {
+		if !bound[variable] {
+			return nil, fmt.Errorf("rule %q: variable %s of the head isn't in the body", name, variable)
+		}
+	}
+
+	return rule, nil
+}
+
+func parseAtom(text string) (Atom, error) {
+	match := atomPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Atom{}, fmt.Errorf("%q is not of the form relation(A, B)", strings.TrimSpace(text))
+	}
+	for _, variable := range match[2:] {
+		if r := []rune(variable)[0]; !unicode.IsUpper(r) {
+			return Atom{}, fmt.Errorf("%s in %s is not a variable, variables start with an upper case letter", variable, match[0])
+		}
+	}
+
+	return Atom{Relation: match[1], From: match[2], To: match[3]}, nil
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// redactContents is set from Config.RedactContents.
+var redactContents atomic.Bool
+
+// WithRequestID returns a copy of ctx carrying a new request id, logged with every message logged through L.
+func WithRequestID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, uuid.NewString())
+}
+
+// RequestID returns the request id ctx carries, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// L returns the global logger with the request id and the trace and span ids of ctx added, so logs can be matched to
+// the request and its traces.
+func L(ctx context.Context) *zap.Logger {
+	logger := zap.L()
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With(zap.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With(
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return logger
+}
+
+// Contents logs the contents of an observation under key, unless contents are redacted.
+func Contents(key, contents string) zap.Field {
+	if redactContents.Load() {
+		return zap.Int(key+"_length", len(contents))
+	}
+
+	return zap.String(key, contents)
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Config says how and where logs are written.
+type Config struct {
+	// Level is the lowest level logged, e.g. debug or info.
+	Level string
+	// Format is console or json.
+	Format string
+	// File is written instead of stderr if it's set, stdout is never used as it carries the MCP messages.
+	File string
+	// FileMaxSize is how many megabytes the file may grow to before it's rotated, 0 never rotates it.
+	FileMaxSize int
+	// FileMaxBackups is how many rotated files are kept.
+	FileMaxBackups int
+	// RedactContents keeps observation contents out of the logs.
+	RedactContents bool
+}
+
+// New creates a logger from cfg.
+func New(cfg Config) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("unknown log level %q: %w", cfg.Level, err)
+	}
+
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case FormatConsole, "":
+		encoderConfig := zap.NewDevelopmentEncoderConfig()
+		if cfg.File == "" {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use console or json", cfg.Format)
+	}
+
+	var out zapcore.WriteSyncer = zapcore.Lock(os.Stderr)
+	if cfg.File != "" {
+		file, err := newRotatingFile(cfg.File, int64(cfg.FileMaxSize)*1024*1024, cfg.FileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	redactContents.Store(cfg.RedactContents)
+
+	return zap.New(
+		zapcore.NewCore(encoder, out, level),
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	), nil
+}
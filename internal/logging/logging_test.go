@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mcp-dbmem.log")
+	file, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Sync())
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestRotatingFile_rotateFails(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mcp-dbmem.log")
+	file, err := newRotatingFile(path, 10, 1)
+	require.NoError(t, err)
+
+	// a directory in the way of the backup makes moving the file aside fail
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0o700))
+
+	_, err = file.Write([]byte("first\n"))
+	require.NoError(t, err)
+	n, err := file.Write([]byte("second\n"))
+	assert.Error(t, err)
+	assert.Equal(t, len("second\n"), n)
+
+	// logging carries on in the same file
+	_, _ = file.Write([]byte("third\n"))
+	require.NoError(t, file.Sync())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(data))
+
+	// and rotates again once it can
+	require.NoError(t, os.RemoveAll(path+".1"))
+	_, err = file.Write([]byte("fourth\n"))
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(data))
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp-dbmem.log")
+	logger, err := New(Config{Level: "info", Format: FormatJSON, File: path, RedactContents: true})
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background())
+	logger.Debug("hidden")
+	logger.With(zap.String("request_id", RequestID(ctx))).Info("shown", Contents("content", "a secret"))
+	require.NoError(t, logger.Sync())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"request_id":"`+RequestID(ctx)+`"`)
+	assert.Contains(t, lines[0], `"content_length":8`)
+	assert.NotContains(t, lines[0], "a secret")
+
+	_, err = New(Config{Level: "loud"})
+	assert.Error(t, err)
+	_, err = New(Config{Level: "info", Format: "xml"})
+	assert.Error(t, err)
+}
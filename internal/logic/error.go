@@ -2,15 +2,69 @@ package logic
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
 )
 
 var (
 	// ErrNotFound is returned when an entity is not found in the database.
 	ErrNotFound = errors.New("not found")
+	// ErrAmbiguousName is returned when a name matches more than one entity ignoring case.
+	ErrAmbiguousName = errors.New("ambiguous name")
+	// ErrEmptyAlias is returned when an alias is blank.
+	ErrEmptyAlias = errors.New("alias is empty")
+	// ErrNoRules is returned when inferring relations without any inference rules configured.
+	ErrNoRules = errors.New("no inference rules are configured")
+	// ErrNoOntology is returned when something needs an ontology but none is configured.
+	ErrNoOntology = errors.New("no ontology is configured")
+	// ErrAlreadyExists is returned when a change would duplicate an existing entry.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrForeignKeyViolation is returned when a change refers to an entry that doesn't exist.
+	ErrForeignKeyViolation = errors.New("refers to a missing entry")
+	// ErrNotNullViolation is returned when a required value is missing.
+	ErrNotNullViolation = errors.New("required value is missing")
+	// ErrCheckViolation is returned when a value is rejected by a database check.
+	ErrCheckViolation = errors.New("value is not allowed")
+	// ErrConflict is returned when a change conflicted with a concurrent one, it can be tried again.
+	ErrConflict = errors.New("conflicting change, try again")
+	// ErrUnavailable is returned when the database can't be reached.
+	ErrUnavailable = errors.New("database is unavailable")
 )
 
+// AliasCollisionError is returned when an alias or entity name is already used by another entity.
+type AliasCollisionError struct {
+	Alias      string
+	EntityName string
+	// Trashed is set when the entity using the alias is in the trash.
+	Trashed bool
+}
+
+// Error returns the error message as a string.
+func (e *AliasCollisionError) Error() string {
+	if e.Trashed {
+		return fmt.Sprintf("%s is already used by entity %s in the trash", e.Alias, e.EntityName)
+	}
+	return fmt.Sprintf("%s is already used by entity %s", e.Alias, e.EntityName)
+}
+
+// OntologyViolationError is returned when a change is rejected for violating the ontology.
+type OntologyViolationError struct {
+	Violations []*ontology.Violation
+}
+
+// Error returns the error message as a string.
+func (e *OntologyViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return "ontology violation: " + strings.Join(messages, "; ")
+}
+
 // ProcessError replaces any known values with our own db.Error types.
 func ProcessError(err error) Error {
 	switch {
@@ -18,6 +72,30 @@ func ProcessError(err error) Error {
 		return nil
 	case errors.Is(err, db.ErrNoEntries):
 		return ErrNotFound
+	}
+
+	// keep the database error so its message isn't lost
+	var (
+		alreadyExists *db.AlreadyExistsError
+		foreignKey    *db.ForeignKeyViolationError
+		notNull       *db.NotNullViolationError
+		check         *db.CheckViolationError
+		serialization *db.SerializationError
+		connection    *db.ConnectionError
+	)
+	switch {
+	case errors.As(err, &alreadyExists):
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+	case errors.As(err, &foreignKey):
+		return fmt.Errorf("%w: %w", ErrForeignKeyViolation, err)
+	case errors.As(err, &notNull):
+		return fmt.Errorf("%w: %w", ErrNotNullViolation, err)
+	case errors.As(err, &check):
+		return fmt.Errorf("%w: %w", ErrCheckViolation, err)
+	case errors.As(err, &serialization):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.As(err, &connection):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
 	default:
 		return err
 	}
@@ -2,17 +2,55 @@ package logic
 
 import (
 	"context"
+	"time"
 
+	"github.com/tyrm/mcp-dbmem/internal/analytics"
 	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
 )
 
 // Error represents a database specific error.
 type Error error
 
 type Logic interface {
+	Aliases
+	Analytics
+	Changes
+	DryRun
 	Entities
+	Health
+	Inference
 	Observations
+	Ontology
 	Relations
+	Trash
+}
+
+type Aliases interface {
+	// AddEntityAlias adds alias to entity. Adding an alias the entity already has is a no-op.
+	AddEntityAlias(ctx context.Context, entity *models.Entity, alias string) (*models.EntityAlias, error)
+	ReadEntityAliases(ctx context.Context, entityID int64) ([]*models.EntityAlias, error)
+	// RemoveEntityAlias removes alias and returns it with the entity it belonged to.
+	RemoveEntityAlias(ctx context.Context, alias string) (*models.EntityAlias, error)
+	// ResolveEntity finds the entity name refers to by its exact name, one of its aliases, or its name ignoring case,
+	// in that order.
+	ResolveEntity(ctx context.Context, name string) (*models.Entity, error)
+}
+
+type Analytics interface {
+	// AnalyzeGraph computes the centrality, components and communities of the relation graph.
+	AnalyzeGraph(ctx context.Context, opts analytics.Options) (*analytics.Report, error)
+}
+
+type Changes interface {
+	ReadChangesForRollback(ctx context.Context, target RollbackTarget) ([]*models.Change, error)
+	ReadGraphAsOf(ctx context.Context, asOf time.Time) ([]*models.Entity, []*models.Relation, error)
+	Rollback(ctx context.Context, changes []*models.Change) error
+}
+
+type DryRun interface {
+	// DryRun runs fn in a transaction that is always rolled back and returns the changes fn would have made.
+	DryRun(ctx context.Context, fn func(ctx context.Context, l Logic) error) ([]*PlannedChange, error)
 }
 
 type Entities interface {
@@ -20,6 +58,19 @@ type Entities interface {
 	DeleteEntity(ctx context.Context, entity *models.Entity) error
 	ReadAllEntities(ctx context.Context) ([]*models.Entity, error)
 	ReadEntityByName(ctx context.Context, name string) (*models.Entity, error)
+	// SuggestEntities returns up to limit entities with a name or alias similar to name, best first.
+	SuggestEntities(ctx context.Context, name string, limit int) ([]*models.EntitySuggestion, error)
+}
+
+type Health interface {
+	// LintGraph finds consistency problems in the graph. If fix is set the problems that can be fixed are, in the
+	// same transaction they were found in.
+	LintGraph(ctx context.Context, fix bool) (*LintReport, error)
+}
+
+type Inference interface {
+	// Infer returns the relations the inference rules derive from the stored ones that aren't stored themselves.
+	Infer(ctx context.Context, query InferenceQuery) (*InferenceResult, error)
 }
 
 type Observations interface {
@@ -29,10 +80,108 @@ type Observations interface {
 	ReadObservationByTextForEntityID(ctx context.Context, entityID int64, text string) (*models.Observation, error)
 }
 
+type Ontology interface {
+	// ReadOntology returns the ontology in effect, nil if there is none, and how strictly it is enforced.
+	ReadOntology() (*ontology.Ontology, ontology.Strictness)
+	// ValidateEntity returns the ways entity violates the ontology.
+	ValidateEntity(ctx context.Context, entity *models.Entity) ([]*ontology.Violation, error)
+	// ValidateRelation returns the ways relation violates the ontology if it was created.
+	ValidateRelation(ctx context.Context, relation *models.Relation) ([]*ontology.Violation, error)
+}
+
 type Relations interface {
+	// BackfillInverses creates the missing inverses of existing relations declared by the ontology.
+	BackfillInverses(ctx context.Context) ([]*models.Relation, error)
 	CreateRelation(ctx context.Context, relation *models.Relation) error
 	DeleteAllRelationsByEntityID(ctx context.Context, entityID int64) error
 	ReadAllRelations(ctx context.Context) ([]*models.Relation, error)
 	ReadExactRelation(ctx context.Context, fromID, toID int64, relationType string) (*models.Relation, error)
 	DeleteRelation(ctx context.Context, relation *models.Relation) error
 }
+
+type Trash interface {
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (*PurgeResult, error)
+	ReadTrash(ctx context.Context) ([]*models.Entity, []*models.Observation, []*models.Relation, error)
+	RestoreEntityByName(ctx context.Context, name string) (*models.Entity, []*models.Relation, error)
+}
+
+// LintKind is a class of consistency problem.
+type LintKind string
+
+const (
+	// LintDuplicateName is more than one entity with the same name. It's fixed by merging the later entities into
+	// the first.
+	LintDuplicateName LintKind = "duplicate_name"
+	// LintOrphanObservation is an observation of a missing or deleted entity. It's fixed by deleting it.
+	LintOrphanObservation LintKind = "orphan_observation"
+	// LintDanglingRelation is a relation to a missing or deleted entity. It's fixed by deleting it.
+	LintDanglingRelation LintKind = "dangling_relation"
+	// LintEmptyEntity is an entity without observations. It can't be fixed automatically.
+	LintEmptyEntity LintKind = "empty_entity"
+	// LintSelfLoop is a relation from an entity to itself. It's fixed by deleting it.
+	LintSelfLoop LintKind = "self_loop"
+	// LintNearDuplicateObservation is an observation that says nearly the same as an earlier one of its entity. It's
+	// fixed by deleting it if it only differs in case and spacing.
+	LintNearDuplicateObservation LintKind = "near_duplicate_observation"
+)
+
+// LintReport lists the consistency problems found in the graph.
+type LintReport struct {
+	Issues []*LintIssue
+}
+
+// LintIssue is a single consistency problem with the rows involved.
+type LintIssue struct {
+	Kind         LintKind
+	Message      string
+	Fixable      bool
+	Fixed        bool
+	Entities     []*models.Entity
+	Observations []*models.Observation
+	Relations    []*models.Relation
+}
+
+// InferenceQuery selects the inferred relations returned. Zero values select everything.
+type InferenceQuery struct {
+	// EntityID selects relations starting or ending at the entity.
+	EntityID     int64
+	RelationType string
+}
+
+// InferenceResult holds inferred relations, with the entities they refer to filled in.
+type InferenceResult struct {
+	Relations []*InferredRelation
+	// Truncated is true if the depth limit was reached before the rules stopped deriving relations.
+	Truncated bool
+}
+
+// InferredRelation is a relation derived by an inference rule. It isn't stored.
+type InferredRelation struct {
+	Relation *models.Relation
+	Rule     string
+	Depth    int
+}
+
+// PlannedChange is a change a dry run would have made. Exactly one of Entity, Observation and Relation is set, with
+// the entities it refers to filled in.
+type PlannedChange struct {
+	Action      models.ChangeAction
+	Entity      *models.Entity
+	Observation *models.Observation
+	Relation    *models.Relation
+}
+
+// PurgeResult counts the rows permanently removed from the trash.
+type PurgeResult struct {
+	Entities     int64
+	Observations int64
+	Relations    int64
+}
+
+// RollbackTarget selects the changes undone by a rollback. Every change after ChangeID, or after Time when
+// ChangeID is zero, is selected. If SessionID is set only changes made by that session are selected.
+type RollbackTarget struct {
+	ChangeID  int64
+	Time      time.Time
+	SessionID string
+}
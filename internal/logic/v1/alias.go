@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// suggestionMinScore is the lowest similarity suggested, the same as the pg_trgm default threshold.
+const suggestionMinScore = 0.3
+
+func (l *Logic) AddEntityAlias(ctx context.Context, entity *models.Entity, alias string) (*models.EntityAlias, error) {
+	ctx, span := tracer.Start(ctx, "AddEntityAlias", tracerAttrs...)
+	defer span.End()
+
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil, logic.ErrEmptyAlias
+	}
+
+	newAlias := &models.EntityAlias{
+		Alias:      alias,
+		Normalized: normalizeName(alias),
+		EntityID:   entity.ID,
+	}
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		existing, err := tx.ReadEntityAliasByNormalizedWithDeleted(ctx, newAlias.Normalized)
+		switch {
+		case err == nil && existing.EntityID == entity.ID:
+			newAlias = existing
+			return nil
+		case err == nil:
+			return newAliasCollisionError(alias, existing.Entity)
+		case !errors.Is(err, db.ErrNoEntries):
+			return err
+		}
+
+		if err := checkNameFree(ctx, tx, alias, entity.ID); err != nil {
+			return err
+		}
+
+		return tx.CreateEntityAlias(ctx, newAlias)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return newAlias, nil
+}
+
+func (l *Logic) ReadEntityAliases(ctx context.Context, entityID int64) ([]*models.EntityAlias, error) {
+	ctx, span := tracer.Start(ctx, "ReadEntityAliases", tracerAttrs...)
+	defer span.End()
+
+	aliases, err := l.db.ReadEntityAliasesByEntityID(ctx, entityID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return aliases, nil
+}
+
+func (l *Logic) RemoveEntityAlias(ctx context.Context, alias string) (*models.EntityAlias, error) {
+	ctx, span := tracer.Start(ctx, "RemoveEntityAlias", tracerAttrs...)
+	defer span.End()
+
+	var removed *models.EntityAlias
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		var err error
+		if removed, err = tx.ReadEntityAliasByNormalized(ctx, normalizeName(alias)); err != nil {
+			return err
+		}
+
+		return tx.DeleteEntityAlias(ctx, removed)
+	})
+	if err != nil {
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, logic.ProcessError(err)
+	}
+
+	return removed, nil
+}
+
+func (l *Logic) ResolveEntity(ctx context.Context, name string) (*models.Entity, error) {
+	ctx, span := tracer.Start(ctx, "ResolveEntity", tracerAttrs...)
+	defer span.End()
+
+	entity, err := resolveEntity(ctx, l.db, name)
+	if err != nil {
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, logic.ProcessError(err)
+	}
+
+	return entity, nil
+}
+
+func (l *Logic) SuggestEntities(ctx context.Context, name string, limit int) ([]*models.EntitySuggestion, error) {
+	ctx, span := tracer.Start(ctx, "SuggestEntities", tracerAttrs...)
+	defer span.End()
+
+	suggestions, err := l.db.SuggestEntities(ctx, name, suggestionMinScore, limit)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return suggestions, nil
+}
+
+func resolveEntity(ctx context.Context, tx db.DB, name string) (*models.Entity, error) {
+	entity, err := tx.ReadEntityByName(ctx, name)
+	if err == nil || !errors.Is(err, db.ErrNoEntries) {
+		return entity, err
+	}
+
+	alias, err := tx.ReadEntityAliasByNormalized(ctx, normalizeName(name))
+	switch {
+	case err == nil:
+		// read again so the observations are loaded
+		return tx.ReadEntityByName(ctx, alias.Entity.Name)
+	case !errors.Is(err, db.ErrNoEntries):
+		return nil, err
+	}
+
+	entities, err := tx.ReadEntitiesByNameFold(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	switch len(entities) {
+	case 0:
+		return nil, db.ErrNoEntries
+	case 1:
+		return entities[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %s matches %d entities", logic.ErrAmbiguousName, name, len(entities))
+	}
+}
+
+// checkNameFree returns an AliasCollisionError if alias is the name of an entity other than entityID, ignoring case.
+func checkNameFree(ctx context.Context, tx db.DB, alias string, entityID int64) error {
+	entities, err := tx.ReadEntitiesByNameFold(ctx, alias)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	for _, entity := range entities {
+		if entity.ID != entityID {
+			return &logic.AliasCollisionError{Alias: alias, EntityName: entity.Name}
+		}
+	}
+
+	return nil
+}
+
+// checkAliasFree returns an AliasCollisionError if name is an alias of an entity, including entities in the trash.
+func checkAliasFree(ctx context.Context, tx db.DB, name string) error {
+	alias, err := tx.ReadEntityAliasByNormalizedWithDeleted(ctx, normalizeName(name))
+	switch {
+	case err == nil:
+		return newAliasCollisionError(name, alias.Entity)
+	case errors.Is(err, db.ErrNoEntries):
+		return nil
+	default:
+		return err
+	}
+}
+
+func newAliasCollisionError(alias string, entity *models.Entity) *logic.AliasCollisionError {
+	return &logic.AliasCollisionError{
+		Alias:      alias,
+		EntityName: entity.Name,
+		Trashed:    !entity.DeletedAt.IsZero(),
+	}
+}
+
+// normalizeName folds a name or alias to the form aliases are looked up by.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
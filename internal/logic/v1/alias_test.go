@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestLogic_EntityAliases(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resolves ignoring case and spacing", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		l := NewLogic(LogicConfig{DB: newTestDB(t)})
+		people := createEntities(t, l, "alice")
+
+		alias, err := l.AddEntityAlias(ctx, people["alice"], "  Ali ")
+		require.NoError(t, err)
+		assert.Equal(t, "Ali", alias.Alias)
+		assert.Equal(t, "ali", alias.Normalized)
+
+		for _, name := range []string{"Ali", "ALI", " ali\t"} {
+			entity, err := l.ResolveEntity(ctx, name)
+			require.NoError(t, err, name)
+			assert.Equal(t, people["alice"].ID, entity.ID, name)
+		}
+
+		again, err := l.AddEntityAlias(ctx, people["alice"], "ALI")
+		require.NoError(t, err, "adding an alias twice is a no-op")
+		assert.Equal(t, alias.ID, again.ID)
+
+		_, err = l.RemoveEntityAlias(ctx, " aLi ")
+		require.NoError(t, err)
+		_, err = l.ResolveEntity(ctx, "ali")
+		assert.ErrorIs(t, err, logic.ErrNotFound)
+	})
+
+	t.Run("collides with entity names", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		l := NewLogic(LogicConfig{DB: newTestDB(t)})
+		people := createEntities(t, l, "alice", "bob")
+
+		_, err := l.AddEntityAlias(ctx, people["alice"], "BOB")
+		var collision *logic.AliasCollisionError
+		require.ErrorAs(t, err, &collision)
+		assert.Equal(t, "bob", collision.EntityName)
+		assert.False(t, collision.Trashed)
+
+		_, err = l.AddEntityAlias(ctx, people["alice"], "Ali")
+		require.NoError(t, err)
+		_, err = l.AddEntityAlias(ctx, people["bob"], "ali")
+		require.ErrorAs(t, err, &collision)
+		assert.Equal(t, "alice", collision.EntityName)
+		err = l.CreateEntity(ctx, &models.Entity{Name: "ALI", Type: "person"})
+		require.ErrorAs(t, err, &collision)
+		assert.Equal(t, "alice", collision.EntityName)
+	})
+
+	t.Run("stays taken by trashed entities", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		l := NewLogic(LogicConfig{DB: newTestDB(t)})
+		people := createEntities(t, l, "alice", "bob")
+		_, err := l.AddEntityAlias(ctx, people["alice"], "Ali")
+		require.NoError(t, err)
+		require.NoError(t, l.DeleteEntity(ctx, people["alice"]))
+
+		_, err = l.ResolveEntity(ctx, "ali")
+		assert.ErrorIs(t, err, logic.ErrNotFound)
+
+		_, err = l.AddEntityAlias(ctx, people["bob"], "ali")
+		var collision *logic.AliasCollisionError
+		require.ErrorAs(t, err, &collision)
+		assert.Equal(t, "alice", collision.EntityName)
+		assert.True(t, collision.Trashed)
+		err = l.CreateEntity(ctx, &models.Entity{Name: "Ali", Type: "person"})
+		require.ErrorAs(t, err, &collision)
+		assert.True(t, collision.Trashed)
+
+		_, _, err = l.RestoreEntityByName(ctx, "alice")
+		require.NoError(t, err)
+		entity, err := l.ResolveEntity(ctx, "ALI")
+		require.NoError(t, err)
+		assert.Equal(t, people["alice"].ID, entity.ID)
+	})
+}
@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/tyrm/mcp-dbmem/internal/analytics"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+)
+
+func (l *Logic) AnalyzeGraph(ctx context.Context, opts analytics.Options) (*analytics.Report, error) {
+	ctx, span := tracer.Start(ctx, "AnalyzeGraph", tracerAttrs...)
+	defer span.End()
+
+	g, err := analytics.Load(ctx, l.db, opts.BatchSize)
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	report := analytics.Analyze(g, opts)
+	if err := report.Name(ctx, l.db, opts.BatchSize); err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,337 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+)
+
+// ReadChangesForRollback returns the journaled changes selected by target, oldest first.
+func (l *Logic) ReadChangesForRollback(ctx context.Context, target logic.RollbackTarget) ([]*models.Change, error) {
+	ctx, span := tracer.Start(ctx, "ReadChangesForRollback", tracerAttrs...)
+	defer span.End()
+
+	var changes []*models.Change
+	var err db.Error
+	if target.ChangeID > 0 {
+		changes, err = l.db.ReadChangesAfterID(ctx, target.ChangeID)
+	} else {
+		changes, err = l.db.ReadChangesSince(ctx, target.Time)
+	}
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	if target.SessionID == "" {
+		return changes, nil
+	}
+
+	selected := make([]*models.Change, 0, len(changes))
+	for _, change := range changes {
+		if change.SessionID == target.SessionID {
+			selected = append(selected, change)
+		}
+	}
+
+	return selected, nil
+}
+
+// ReadGraphAsOf reconstructs the entities and relations as they were at asOf by reverting every journaled change
+// made after it. Changes made before the journal existed can't be reverted.
+func (l *Logic) ReadGraphAsOf(ctx context.Context, asOf time.Time) ([]*models.Entity, []*models.Relation, error) {
+	ctx, span := tracer.Start(ctx, "ReadGraphAsOf", tracerAttrs...)
+	defer span.End()
+
+	var state *graphState
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		entities, err := tx.ReadAllEntities(ctx)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+		relations, err := tx.ReadAllRelations(ctx)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+		changes, err := tx.ReadChangesSince(ctx, asOf)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+
+		state = newGraphState(entities, relations)
+		for i := len(changes) - 1; i >= 0; i-- {
+			if err := state.revert(changes[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, logic.ProcessError(err)
+	}
+
+	entities, relations := state.graph()
+	return entities, relations, nil
+}
+
+// Rollback inverts changes, newest first, in a single transaction. The inverting changes are journaled as well so
+// a rollback can itself be rolled back.
+func (l *Logic) Rollback(ctx context.Context, changes []*models.Change) error {
+	ctx, span := tracer.Start(ctx, "Rollback", tracerAttrs...)
+	defer span.End()
+
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		// the journal already holds both directions of inverse relations
+		txLogic := l.withTx(tx)
+		txLogic.ontology, txLogic.strictness = nil, ontology.StrictnessOff
+		for i := len(changes) - 1; i >= 0; i-- {
+			if err := txLogic.undo(ctx, changes[i]); err != nil {
+				return fmt.Errorf("undo change %d: %w", changes[i].ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return logic.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (l *Logic) undo(ctx context.Context, change *models.Change) error {
+	record, err := unmarshalChange(change)
+	if err != nil {
+		return err
+	}
+
+	if change.Action == models.ChangeActionCreate {
+		switch r := record.(type) {
+		case *models.Entity:
+			return l.DeleteEntity(ctx, r)
+		case *models.Observation:
+			return l.DeleteObservation(ctx, r)
+		case *models.Relation:
+			return l.DeleteRelation(ctx, r)
+		}
+	}
+
+	return l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		if change.Action == models.ChangeActionUpdate {
+			return l.undoUpdate(ctx, tx, record)
+		}
+
+		return l.restore(ctx, tx, record)
+	})
+}
+
+// undoUpdate puts record back the way the update found it.
+func (l *Logic) undoUpdate(ctx context.Context, tx db.DB, record any) error {
+	switch r := record.(type) {
+	case *models.Observation:
+		current, err := tx.ReadObservationByIDWithDeleted(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+
+		return l.moveObservation(ctx, tx, current, r.EntityID)
+	default:
+		return fmt.Errorf("can't undo an update of %T", record)
+	}
+}
+
+// moveObservation moves observation to the entity with entityID and journals the observation as it was before.
+func (l *Logic) moveObservation(ctx context.Context, tx db.DB, observation *models.Observation, entityID int64) error {
+	before := *observation
+	before.Entity = nil
+	if err := tx.MoveObservation(ctx, observation, entityID); err != nil {
+		return err
+	}
+
+	return l.journal(ctx, tx, models.ChangeActionUpdate, &before)
+}
+
+func (l *Logic) deleteAllObservationsByEntityID(ctx context.Context, tx db.DB, entityID int64, deletedAt time.Time) error {
+	observations, err := tx.ReadObservationsByEntityID(ctx, entityID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+
+	if err := tx.DeleteAllObservationsByEntityID(ctx, entityID, deletedAt); err != nil {
+		return err
+	}
+
+	for _, observation := range observations {
+		observation.DeletedAt = deletedAt
+		if err := l.journal(ctx, tx, models.ChangeActionDelete, observation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Logic) deleteAllRelationsByEntityID(ctx context.Context, tx db.DB, entityID int64, deletedAt time.Time) error {
+	relations, err := tx.ReadRelationsByEntityID(ctx, entityID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+
+	if err := tx.DeleteAllRelationsByEntityID(ctx, entityID, deletedAt); err != nil {
+		return err
+	}
+
+	for _, relation := range relations {
+		relation.DeletedAt = deletedAt
+		if err := l.journal(ctx, tx, models.ChangeActionDelete, relation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// journal records a change to record in the change journal.
+func (l *Logic) journal(ctx context.Context, tx db.DB, action models.ChangeAction, record any) error {
+	change, err := models.NewChange(action, record)
+	if err != nil {
+		return err
+	}
+	change.SessionID = l.sessionID
+
+	if l.planned != nil {
+		l.plan(action, record)
+	}
+
+	return tx.CreateChange(ctx, change)
+}
+
+func unmarshalChange(change *models.Change) (any, error) {
+	var record any
+	switch change.Kind {
+	case models.ChangeKindEntity:
+		record = new(models.Entity)
+	case models.ChangeKindObservation:
+		record = new(models.Observation)
+	case models.ChangeKindRelation:
+		record = new(models.Relation)
+	default:
+		return nil, fmt.Errorf("unknown change kind %q", change.Kind)
+	}
+
+	if err := json.Unmarshal([]byte(change.Data), record); err != nil {
+		return nil, fmt.Errorf("can't read change %d: %w", change.ID, err)
+	}
+
+	return record, nil
+}
+
+// graphState is an in memory copy of the graph that journaled changes can be reverted on.
+type graphState struct {
+	entities     map[int64]*models.Entity
+	observations map[int64]*models.Observation
+	relations    map[int64]*models.Relation
+}
+
+func newGraphState(entities []*models.Entity, relations []*models.Relation) *graphState {
+	state := &graphState{
+		entities:     make(map[int64]*models.Entity, len(entities)),
+		observations: make(map[int64]*models.Observation),
+		relations:    make(map[int64]*models.Relation, len(relations)),
+	}
+	for _, entity := range entities {
+		for _, observation := range entity.Observations {
+			state.observations[observation.ID] = observation
+		}
+		state.entities[entity.ID] = entity
+	}
+	for _, relation := range relations {
+		state.relations[relation.ID] = relation
+	}
+
+	return state
+}
+
+// revert undoes a single change.
+func (s *graphState) revert(change *models.Change) error {
+	record, err := unmarshalChange(change)
+	if err != nil {
+		return err
+	}
+
+	switch r := record.(type) {
+	case *models.Entity:
+		if change.Action == models.ChangeActionCreate {
+			delete(s.entities, change.RecordID)
+		} else {
+			s.entities[change.RecordID] = r
+		}
+	case *models.Observation:
+		if change.Action == models.ChangeActionCreate {
+			delete(s.observations, change.RecordID)
+		} else {
+			s.observations[change.RecordID] = r
+		}
+	case *models.Relation:
+		if change.Action == models.ChangeActionCreate {
+			delete(s.relations, change.RecordID)
+		} else {
+			s.relations[change.RecordID] = r
+		}
+	}
+
+	return nil
+}
+
+// graph returns the entities, with their observations, and relations in the state ordered by id.
+func (s *graphState) graph() ([]*models.Entity, []*models.Relation) {
+	entities := make([]*models.Entity, 0, len(s.entities))
+	for _, entity := range s.entities {
+		e := *entity
+		e.Observations = make([]*models.Observation, 0)
+		entities = append(entities, &e)
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+
+	byID := make(map[int64]*models.Entity, len(entities))
+	for _, entity := range entities {
+		byID[entity.ID] = entity
+	}
+
+	observations := make([]*models.Observation, 0, len(s.observations))
+	for _, observation := range s.observations {
+		observations = append(observations, observation)
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].ID < observations[j].ID })
+	for _, observation := range observations {
+		if entity, ok := byID[observation.EntityID]; ok {
+			entity.Observations = append(entity.Observations, observation)
+		}
+	}
+
+	relations := make([]*models.Relation, 0, len(s.relations))
+	for _, relation := range s.relations {
+		from, fromOK := byID[relation.FromID]
+		to, toOK := byID[relation.ToID]
+		if !fromOK || !toOK {
+			continue
+		}
+		r := *relation
+		r.From, r.To = from, to
+		relations = append(relations, &r)
+	}
+	sort.Slice(relations, func(i, j int) bool { return relations[i].ID < relations[j].ID })
+
+	return entities, relations
+}
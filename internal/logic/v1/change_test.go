@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func TestGraphState_revert(t *testing.T) {
+	t.Parallel()
+
+	alice := &models.Entity{ID: 1, Name: "alice", Type: "person"}
+	bob := &models.Entity{ID: 2, Name: "bob", Type: "person"}
+	knows := &models.Relation{ID: 1, FromID: 1, ToID: 2, Type: "knows"}
+	tea := &models.Observation{ID: 1, EntityID: 1, Contents: "likes tea"}
+
+	// bob was created, then alice was deleted along with her relation and observation
+	journal := make([]*models.Change, 0)
+	for _, step := range []struct {
+		action models.ChangeAction
+		record any
+	}{
+		{models.ChangeActionCreate, bob},
+		{models.ChangeActionDelete, knows},
+		{models.ChangeActionDelete, tea},
+		{models.ChangeActionDelete, alice},
+	} {
+		change, err := models.NewChange(step.action, step.record)
+		require.NoError(t, err)
+		journal = append(journal, change)
+	}
+
+	state := newGraphState([]*models.Entity{bob}, nil)
+	for i := len(journal) - 1; i >= 0; i-- {
+		require.NoError(t, state.revert(journal[i]))
+	}
+
+	entities, relations := state.graph()
+	if assert.Len(t, entities, 1) {
+		assert.Equal(t, "alice", entities[0].Name)
+		if assert.Len(t, entities[0].Observations, 1) {
+			assert.Equal(t, "likes tea", entities[0].Observations[0].Contents)
+		}
+	}
+	// bob didn't exist yet so the relation can't be shown
+	assert.Empty(t, relations)
+}
+
+func TestUnmarshalChange_unknownKind(t *testing.T) {
+	t.Parallel()
+
+	_, err := unmarshalChange(&models.Change{Kind: "widget", Data: "{}"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// errDryRun is returned from a dry run transaction to roll it back.
+var errDryRun = errors.New("dry run")
+
+// DryRun runs fn in a transaction that is always rolled back and returns the changes fn would have made, including
+// cascaded ones.
+func (l *Logic) DryRun(ctx context.Context, fn func(ctx context.Context, l logic.Logic) error) ([]*logic.PlannedChange, error) {
+	ctx, span := tracer.Start(ctx, "DryRun", tracerAttrs...)
+	defer span.End()
+
+	var planned []*logic.PlannedChange
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		txLogic := l.withTx(tx)
+		txLogic.planned = new([]*logic.PlannedChange)
+		if err := fn(ctx, txLogic); err != nil {
+			return err
+		}
+
+		// entity names have to be looked up before the transaction is gone
+		if err := resolvePlannedEntities(ctx, tx, *txLogic.planned); err != nil {
+			return err
+		}
+		planned = *txLogic.planned
+
+		return errDryRun
+	})
+	if !errors.Is(err, errDryRun) {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return planned, nil
+}
+
+// plan records a change while in a dry run.
+func (l *Logic) plan(action models.ChangeAction, record any) {
+	change := &logic.PlannedChange{
+		Action: action,
+	}
+	switch r := record.(type) {
+	case *models.Entity:
+		entity := *r
+		change.Entity = &entity
+	case *models.Observation:
+		observation := *r
+		change.Observation = &observation
+	case *models.Relation:
+		relation := *r
+		change.Relation = &relation
+	}
+
+	*l.planned = append(*l.planned, change)
+}
+
+// resolvePlannedEntities fills in the entities the planned observations and relations refer to.
+func resolvePlannedEntities(ctx context.Context, tx db.DB, planned []*logic.PlannedChange) error {
+	ids := make([]int64, 0)
+	for _, change := range planned {
+		switch {
+		case change.Observation != nil:
+			ids = append(ids, change.Observation.EntityID)
+		case change.Relation != nil:
+			ids = append(ids, change.Relation.FromID, change.Relation.ToID)
+		}
+	}
+
+	entities, err := tx.ReadEntitiesByIDs(ctx, ids)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	byID := make(map[int64]*models.Entity, len(entities))
+	for _, entity := range entities {
+		byID[entity.ID] = entity
+	}
+
+	for _, change := range planned {
+		switch {
+		case change.Observation != nil:
+			change.Observation.Entity = byID[change.Observation.EntityID]
+		case change.Relation != nil:
+			change.Relation.From = byID[change.Relation.FromID]
+			change.Relation.To = byID[change.Relation.ToID]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/inference"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func (l *Logic) Infer(ctx context.Context, query logic.InferenceQuery) (*logic.InferenceResult, error) {
+	ctx, span := tracer.Start(ctx, "Infer", tracerAttrs...)
+	defer span.End()
+
+	if l.rules == nil {
+		return nil, logic.ErrNoRules
+	}
+
+	relations, err := l.db.ReadAllRelations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	// derived relations only refer to entities of stored relations
+	entities := make(map[int64]*models.Entity)
+	facts := make([]inference.Fact, 0, len(relations))
+	for _, relation := range relations {
+		entities[relation.FromID], entities[relation.ToID] = relation.From, relation.To
+		facts = append(facts, inference.Fact{
+			From:     relation.FromID,
+			To:       relation.ToID,
+			Relation: relation.Type,
+		})
+	}
+
+	inferred := l.rules.Infer(facts, l.inferenceMaxDepth)
+
+	result := &logic.InferenceResult{
+		Relations: make([]*logic.InferredRelation, 0),
+		Truncated: inferred.Truncated,
+	}
+	for _, derived := range inferred.Derived {
+		if query.EntityID != 0 && derived.From != query.EntityID && derived.To != query.EntityID {
+			continue
+		}
+		if query.RelationType != "" && derived.Relation != query.RelationType {
+			continue
+		}
+
+		result.Relations = append(result.Relations, &logic.InferredRelation{
+			Relation: &models.Relation{
+				FromID: derived.From,
+				From:   entities[derived.From],
+				ToID:   derived.To,
+				To:     entities[derived.To],
+				Type:   derived.Relation,
+			},
+			Rule:  derived.Rule,
+			Depth: derived.Depth,
+		})
+	}
+
+	// shallowest first, the rules are applied in no particular order within a round
+	sort.SliceStable(result.Relations, func(i, j int) bool {
+		a, b := result.Relations[i], result.Relations[j]
+		switch {
+		case a.Depth != b.Depth:
+			return a.Depth < b.Depth
+		case a.Relation.From.Name != b.Relation.From.Name:
+			return a.Relation.From.Name < b.Relation.From.Name
+		case a.Relation.Type != b.Relation.Type:
+			return a.Relation.Type < b.Relation.Type
+		default:
+			return a.Relation.To.Name < b.Relation.To.Name
+		}
+	})
+
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// BackfillInverses creates the inverse of every relation the ontology declares one for that is missing it, and
+// returns the relations created.
+func (l *Logic) BackfillInverses(ctx context.Context) ([]*models.Relation, error) {
+	ctx, span := tracer.Start(ctx, "BackfillInverses", tracerAttrs...)
+	defer span.End()
+
+	if l.ontology == nil {
+		return nil, logic.ErrNoOntology
+	}
+
+	created := make([]*models.Relation, 0)
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		// a retried transaction starts over
+		created = created[:0]
+
+		relations, err := tx.ReadAllRelations(ctx)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+
+		for _, relation := range relations {
+			inverse, err := l.createInverseRelationIfMissing(ctx, tx, relation)
+			if err != nil {
+				return err
+			}
+			if inverse != nil {
+				created = append(created, inverse)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return created, nil
+}
+
+// createInverseRelation creates the inverse of relation if the ontology declares one and it doesn't exist yet.
+func (l *Logic) createInverseRelation(ctx context.Context, tx db.DB, relation *models.Relation) error {
+	_, err := l.createInverseRelationIfMissing(ctx, tx, relation)
+	return err
+}
+
+func (l *Logic) createInverseRelationIfMissing(ctx context.Context, tx db.DB, relation *models.Relation) (*models.Relation, error) {
+	inverse, ok := l.inverseOf(relation)
+	if !ok {
+		return nil, nil
+	}
+
+	_, err := tx.ReadExactRelation(ctx, inverse.FromID, inverse.ToID, inverse.Type)
+	switch {
+	case err == nil:
+		return nil, nil
+	case !errors.Is(err, db.ErrNoEntries):
+		return nil, err
+	}
+
+	// the inverse type can have a domain, range and limits of its own
+	violations, err := l.checkRelation(ctx, tx, inverse)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.enforce(violations); err != nil {
+		return nil, err
+	}
+
+	if err := tx.CreateRelation(ctx, inverse); err != nil {
+		return nil, err
+	}
+	if err := l.journal(ctx, tx, models.ChangeActionCreate, inverse); err != nil {
+		return nil, err
+	}
+
+	// entities are filled in for callers reporting the relation
+	inverse.From, inverse.To = relation.To, relation.From
+
+	return inverse, nil
+}
+
+// deleteInverseRelation deletes the inverse of relation if the ontology declares one and it exists.
+func (l *Logic) deleteInverseRelation(ctx context.Context, tx db.DB, relation *models.Relation) error {
+	inverse, ok := l.inverseOf(relation)
+	if !ok {
+		return nil
+	}
+
+	existing, err := tx.ReadExactRelation(ctx, inverse.FromID, inverse.ToID, inverse.Type)
+	switch {
+	case errors.Is(err, db.ErrNoEntries):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	// deleted together so they are restored together
+	existing.DeletedAt = relation.DeletedAt
+	if err := tx.DeleteRelation(ctx, existing); err != nil {
+		return err
+	}
+
+	return l.journal(ctx, tx, models.ChangeActionDelete, existing)
+}
+
+// inverseOf returns the relation the ontology says should exist in the opposite direction of relation.
+func (l *Logic) inverseOf(relation *models.Relation) (*models.Relation, bool) {
+	if l.ontology == nil {
+		return nil, false
+	}
+	inverseType, ok := l.ontology.InverseOf(relation.Type)
+	// a symmetric relation from an entity to itself is its own inverse
+	if !ok || (relation.FromID == relation.ToID && inverseType == relation.Type) {
+		return nil, false
+	}
+
+	return &models.Relation{
+		FromID: relation.ToID,
+		ToID:   relation.FromID,
+		Type:   inverseType,
+	}, true
+}
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+)
+
+// conflictingDB fails its first transaction with a serialization failure once failAfter relations were created in it.
+type conflictingDB struct {
+	db.DB
+	failAfter int
+	created   int
+	failed    bool
+}
+
+func (c *conflictingDB) RunInTx(ctx context.Context, fn func(ctx context.Context, tx db.DB) error) db.Error {
+	return c.DB.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		return fn(ctx, &conflictingTx{DB: tx, conflicting: c})
+	})
+}
+
+type conflictingTx struct {
+	db.DB
+	conflicting *conflictingDB
+}
+
+func (c *conflictingTx) CreateRelation(ctx context.Context, relation *models.Relation) db.Error {
+	if err := c.DB.CreateRelation(ctx, relation); err != nil {
+		return err
+	}
+
+	c.conflicting.created++
+	if !c.conflicting.failed && c.conflicting.created == c.conflicting.failAfter {
+		c.conflicting.failed = true
+		return db.NewErrSerialization("database is locked")
+	}
+
+	return nil
+}
+
+func TestLogic_BackfillInverses_retried(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newTestDB(t)
+	plain := NewLogic(LogicConfig{DB: client})
+	people := createEntities(t, plain, "alice", "bob", "carol")
+	require.NoError(t, plain.CreateRelation(ctx, &models.Relation{FromID: people["alice"].ID, ToID: people["bob"].ID, Type: "manages"}))
+	require.NoError(t, plain.CreateRelation(ctx, &models.Relation{FromID: people["alice"].ID, ToID: people["carol"].ID, Type: "manages"}))
+
+	o, err := ontology.Parse([]byte("relationTypes:\n  - name: manages\n    inverse: managed_by\n"))
+	require.NoError(t, err)
+	conflicting := &conflictingDB{DB: client, failAfter: 2}
+	l := NewLogic(LogicConfig{DB: conflicting, Ontology: o})
+
+	created, err := l.BackfillInverses(ctx)
+	require.NoError(t, err)
+	assert.True(t, conflicting.failed, "the first attempt failed")
+	described := make([]string, 0, len(created))
+	for _, relation := range created {
+		described = append(described, relation.From.Name+" "+relation.Type+" "+relation.To.Name)
+	}
+	assert.ElementsMatch(t, []string{"bob managed_by alice", "carol managed_by alice"}, described)
+
+	relations, err := client.ReadAllRelations(ctx)
+	require.NoError(t, err)
+	assert.Len(t, relations, 4)
+}
+
+func TestLogic_CreateRelation_inverseViolation(t *testing.T) {
+	t.Parallel()
+
+	o, err := ontology.Parse([]byte(`
+entityTypes:
+  - name: person
+relationTypes:
+  - name: parent_of
+    inverse: child_of
+  - name: child_of
+    maxPerFrom: 2
+`))
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		strictness ontology.Strictness
+		wantErr    bool
+	}{
+		{strictness: ontology.StrictnessReject, wantErr: true},
+		{strictness: ontology.StrictnessWarn},
+	} {
+		t.Run(string(tt.strictness), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			client := newTestDB(t)
+			l := NewLogic(LogicConfig{DB: client, Ontology: o, OntologyStrictness: tt.strictness})
+			people := createEntities(t, l, "kid", "ann", "ben", "cid")
+			for _, parent := range []string{"ann", "ben"} {
+				require.NoError(t, l.CreateRelation(ctx, &models.Relation{FromID: people[parent].ID, ToID: people["kid"].ID, Type: "parent_of"}))
+			}
+
+			// a third parent_of is fine, but kid child_of cid would be one parent too many
+			err := l.CreateRelation(ctx, &models.Relation{FromID: people["cid"].ID, ToID: people["kid"].ID, Type: "parent_of"})
+			relations, readErr := client.ReadAllRelations(ctx)
+			require.NoError(t, readErr)
+			if tt.wantErr {
+				var violation *logic.OntologyViolationError
+				require.ErrorAs(t, err, &violation)
+				assert.Len(t, relations, 4, "the relation is rejected along with its inverse")
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, relations, 6)
+		})
+	}
+}
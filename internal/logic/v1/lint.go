@@ -0,0 +1,320 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"github.com/tyrm/mcp-dbmem/internal/util"
+)
+
+// nearDuplicateSimilarity is how similar two observations of an entity have to be to count as saying the same thing.
+const nearDuplicateSimilarity = 0.9
+
+func (l *Logic) LintGraph(ctx context.Context, fix bool) (*logic.LintReport, error) {
+	ctx, span := tracer.Start(ctx, "LintGraph", tracerAttrs...)
+	defer span.End()
+
+	if !fix {
+		report, err := l.lint(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return nil, logic.ProcessError(err)
+		}
+		return report, nil
+	}
+
+	var report *logic.LintReport
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		// fixes only rearrange what is already there, so they aren't held to the ontology
+		txLogic := l.withTx(tx)
+		txLogic.strictness = ontology.StrictnessOff
+
+		var err error
+		report, err = txLogic.lint(ctx)
+		if err != nil {
+			return err
+		}
+
+		return txLogic.fix(ctx, report)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return report, nil
+}
+
+func (l *Logic) lint(ctx context.Context) (*logic.LintReport, error) {
+	report := &logic.LintReport{
+		Issues: make([]*logic.LintIssue, 0),
+	}
+
+	orphans, err := l.db.ReadOrphanObservations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return nil, err
+	}
+	for _, observation := range orphans {
+		report.Issues = append(report.Issues, &logic.LintIssue{
+			Kind:         logic.LintOrphanObservation,
+			Message:      fmt.Sprintf("observation %d %q belongs to missing or deleted entity %d", observation.ID, observation.Contents, observation.EntityID),
+			Fixable:      true,
+			Observations: []*models.Observation{observation},
+		})
+	}
+
+	dangling, err := l.db.ReadDanglingRelations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return nil, err
+	}
+	for _, relation := range dangling {
+		report.Issues = append(report.Issues, &logic.LintIssue{
+			Kind:      logic.LintDanglingRelation,
+			Message:   fmt.Sprintf("relation %d %s from entity %d to entity %d refers to a missing or deleted entity", relation.ID, relation.Type, relation.FromID, relation.ToID),
+			Fixable:   true,
+			Relations: []*models.Relation{relation},
+		})
+	}
+
+	relations, err := l.db.ReadAllRelations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if relation.FromID == relation.ToID {
+			report.Issues = append(report.Issues, &logic.LintIssue{
+				Kind:      logic.LintSelfLoop,
+				Message:   fmt.Sprintf("%s %s itself", relation.From.Name, relation.Type),
+				Fixable:   true,
+				Relations: []*models.Relation{relation},
+			})
+		}
+	}
+
+	entities, err := l.db.ReadAllEntities(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return nil, err
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+
+	byName := make(map[string][]*models.Entity)
+	names := make([]string, 0)
+	for _, entity := range entities {
+		if byName[entity.Name] == nil {
+			names = append(names, entity.Name)
+		}
+		byName[entity.Name] = append(byName[entity.Name], entity)
+
+		if len(entity.Observations) == 0 {
+			report.Issues = append(report.Issues, &logic.LintIssue{
+				Kind:     logic.LintEmptyEntity,
+				Message:  fmt.Sprintf("%s has no observations", entity.Name),
+				Entities: []*models.Entity{entity},
+			})
+		}
+
+		report.Issues = append(report.Issues, nearDuplicateObservations(entity)...)
+	}
+	for _, name := range names {
+		if duplicates := byName[name]; len(duplicates) > 1 {
+			report.Issues = append(report.Issues, &logic.LintIssue{
+				Kind:     logic.LintDuplicateName,
+				Message:  fmt.Sprintf("%d entities are named %s", len(duplicates), name),
+				Fixable:  true,
+				Entities: duplicates,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// nearDuplicateObservations returns an issue for every observation of entity that says nearly the same as an earlier
+// one, pairing it with the earlier one. Only observations that differ in case and spacing alone are fixable, similar
+// ones may still say different things, like born 1990 and born 1991.
+func nearDuplicateObservations(entity *models.Entity) []*logic.LintIssue {
+	observations := append([]*models.Observation{}, entity.Observations...)
+	sort.Slice(observations, func(i, j int) bool { return observations[i].ID < observations[j].ID })
+
+	var issues []*logic.LintIssue
+	kept := make([]*models.Observation, 0, len(observations))
+	for _, observation := range observations {
+		var original *models.Observation
+		same := false
+		for _, k := range kept {
+			same = strings.EqualFold(strings.Join(strings.Fields(k.Contents), " "), strings.Join(strings.Fields(observation.Contents), " "))
+			if same || util.TrigramSimilarity(k.Contents, observation.Contents) >= nearDuplicateSimilarity {
+				original = k
+				break
+			}
+		}
+		if original == nil {
+			kept = append(kept, observation)
+			continue
+		}
+
+		message := fmt.Sprintf("%s: %q is nearly the same as %q", entity.Name, observation.Contents, original.Contents)
+		if same {
+			message = fmt.Sprintf("%s: %q repeats %q", entity.Name, observation.Contents, original.Contents)
+		}
+		issues = append(issues, &logic.LintIssue{
+			Kind:         logic.LintNearDuplicateObservation,
+			Message:      message,
+			Fixable:      same,
+			Entities:     []*models.Entity{entity},
+			Observations: []*models.Observation{original, observation},
+		})
+	}
+
+	return issues
+}
+
+// fix fixes the fixable issues of report, deleting rows before merging entities so merges don't carry junk along.
+func (l *Logic) fix(ctx context.Context, report *logic.LintReport) error {
+	for _, issue := range report.Issues {
+		if !issue.Fixable || issue.Kind == logic.LintDuplicateName {
+			continue
+		}
+
+		var err error
+		switch issue.Kind {
+		case logic.LintOrphanObservation:
+			err = l.DeleteObservation(ctx, issue.Observations[0])
+		case logic.LintNearDuplicateObservation:
+			err = l.DeleteObservation(ctx, issue.Observations[1])
+		case logic.LintDanglingRelation:
+			err = l.DeleteRelation(ctx, issue.Relations[0])
+		case logic.LintSelfLoop:
+			err = l.deleteSelfLoop(ctx, issue.Relations[0])
+		}
+		if err != nil {
+			return err
+		}
+		issue.Fixed = true
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Kind != logic.LintDuplicateName {
+			continue
+		}
+
+		keeper := issue.Entities[0]
+		for _, duplicate := range issue.Entities[1:] {
+			if err := l.mergeEntity(ctx, keeper, duplicate); err != nil {
+				return err
+			}
+		}
+		issue.Fixed = true
+	}
+
+	return nil
+}
+
+// deleteSelfLoop deletes relation unless it was already deleted as the inverse of another self loop.
+func (l *Logic) deleteSelfLoop(ctx context.Context, relation *models.Relation) error {
+	_, err := l.db.ReadExactRelation(ctx, relation.FromID, relation.ToID, relation.Type)
+	switch {
+	case errors.Is(err, db.ErrNoEntries):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	return l.DeleteRelation(ctx, relation)
+}
+
+// mergeEntity moves the observations, relations and aliases of duplicate to keeper and deletes duplicate.
+func (l *Logic) mergeEntity(ctx context.Context, keeper, duplicate *models.Entity) error {
+	observations, err := l.db.ReadObservationsByEntityID(ctx, duplicate.ID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	kept, err := l.db.ReadObservationsByEntityID(ctx, keeper.ID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	// observations the keeper already has are deleted along with the duplicate
+	if err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		contents := make(map[string]bool, len(kept))
+		for _, observation := range kept {
+			contents[observation.Contents] = true
+		}
+		for _, observation := range observations {
+			if contents[observation.Contents] {
+				continue
+			}
+			contents[observation.Contents] = true
+
+			if err := l.moveObservation(ctx, tx, observation, keeper.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	relations, err := l.db.ReadRelationsByEntityID(ctx, duplicate.ID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	for _, relation := range relations {
+		moved := &models.Relation{
+			FromID: relation.FromID,
+			ToID:   relation.ToID,
+			Type:   relation.Type,
+		}
+		if moved.FromID == duplicate.ID {
+			moved.FromID = keeper.ID
+		}
+		if moved.ToID == duplicate.ID {
+			moved.ToID = keeper.ID
+		}
+		// relations between the duplicates would become self loops
+		if moved.FromID == moved.ToID {
+			continue
+		}
+
+		_, err := l.db.ReadExactRelation(ctx, moved.FromID, moved.ToID, moved.Type)
+		switch {
+		case err == nil:
+			continue
+		case !errors.Is(err, db.ErrNoEntries):
+			return err
+		}
+		if err := l.CreateRelation(ctx, moved); err != nil {
+			return err
+		}
+	}
+
+	aliases, err := l.db.ReadEntityAliasesByEntityID(ctx, duplicate.ID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return err
+	}
+	for _, alias := range aliases {
+		// the keeper's own name can't be its alias
+		if alias.Normalized == normalizeName(keeper.Name) {
+			if _, err := l.RemoveEntityAlias(ctx, alias.Alias); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := l.RemoveEntityAlias(ctx, alias.Alias); err != nil {
+			return err
+		}
+		if _, err := l.AddEntityAlias(ctx, keeper, alias.Alias); err != nil {
+			return err
+		}
+	}
+
+	return l.DeleteEntity(ctx, duplicate)
+}
@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// issuesOf returns the issues of report with kind.
+func issuesOf(report *logic.LintReport, kind logic.LintKind) []*logic.LintIssue {
+	var issues []*logic.LintIssue
+	for _, issue := range report.Issues {
+		if issue.Kind == kind {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+func TestLogic_LintGraph_fix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges duplicate names", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		client := newTestDB(t)
+		l := NewLogic(LogicConfig{DB: client})
+		people := createEntities(t, l, "alice", "bob")
+		keeper := people["alice"]
+		require.NoError(t, l.CreateObservation(ctx, &models.Observation{EntityID: keeper.ID, Contents: "likes tea"}))
+
+		// duplicates can only come from before names were checked, so they're made behind the logic's back
+		duplicate := &models.Entity{Name: "alice", Type: "person"}
+		require.NoError(t, client.CreateEntity(ctx, duplicate))
+		repeated := &models.Observation{EntityID: duplicate.ID, Contents: "likes tea"}
+		require.NoError(t, client.CreateObservation(ctx, repeated))
+		moved := &models.Observation{EntityID: duplicate.ID, Contents: "likes coffee"}
+		require.NoError(t, client.CreateObservation(ctx, moved))
+		require.NoError(t, client.CreateRelation(ctx, &models.Relation{FromID: duplicate.ID, ToID: people["bob"].ID, Type: "knows"}))
+		for _, alias := range []string{"ALICE", "Ali"} {
+			require.NoError(t, client.CreateEntityAlias(ctx, &models.EntityAlias{Alias: alias, Normalized: normalizeName(alias), EntityID: duplicate.ID}))
+		}
+		before, err := client.ReadObservationByIDWithDeleted(ctx, moved.ID)
+		require.NoError(t, err)
+
+		report, err := l.LintGraph(ctx, true)
+		require.NoError(t, err)
+		duplicates := issuesOf(report, logic.LintDuplicateName)
+		require.Len(t, duplicates, 1)
+		assert.True(t, duplicates[0].Fixed)
+
+		entities, err := client.ReadAllEntities(ctx)
+		require.NoError(t, err)
+		assert.Len(t, entities, 2, "the duplicate is gone")
+
+		observations, err := client.ReadObservationsByEntityID(ctx, keeper.ID)
+		require.NoError(t, err)
+		require.Len(t, observations, 2)
+		after, err := client.ReadObservationByIDWithDeleted(ctx, moved.ID)
+		require.NoError(t, err)
+		assert.Equal(t, keeper.ID, after.EntityID)
+		assert.Equal(t, before.GlobalID, after.GlobalID)
+		assert.True(t, before.CreatedAt.Equal(after.CreatedAt))
+		assert.True(t, after.DeletedAt.IsZero())
+		repeatedAfter, err := client.ReadObservationByIDWithDeleted(ctx, repeated.ID)
+		require.NoError(t, err)
+		assert.False(t, repeatedAfter.DeletedAt.IsZero(), "the keeper already says it")
+
+		changes, err := client.ReadChangesAfterID(ctx, 0)
+		require.NoError(t, err)
+		var updates []*models.Change
+		for _, change := range changes {
+			if change.Action == models.ChangeActionUpdate {
+				updates = append(updates, change)
+			}
+		}
+		require.Len(t, updates, 1)
+		assert.Equal(t, models.ChangeKindObservation, updates[0].Kind)
+		assert.Equal(t, moved.ID, updates[0].RecordID)
+
+		_, err = l.ReadExactRelation(ctx, keeper.ID, people["bob"].ID, "knows")
+		assert.NoError(t, err)
+
+		aliases, err := client.ReadEntityAliasesByEntityID(ctx, keeper.ID)
+		require.NoError(t, err)
+		require.Len(t, aliases, 1)
+		assert.Equal(t, "Ali", aliases[0].Alias)
+	})
+
+	t.Run("deletes orphans and dangling relations", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		client := newTestDB(t)
+		l := NewLogic(LogicConfig{DB: client})
+		people := createEntities(t, l, "alice", "bob")
+		orphan := &models.Observation{EntityID: people["bob"].ID, Contents: "likes tea"}
+		require.NoError(t, l.CreateObservation(ctx, orphan))
+		require.NoError(t, l.CreateRelation(ctx, &models.Relation{FromID: people["alice"].ID, ToID: people["bob"].ID, Type: "knows"}))
+		// deleting the entity alone leaves what used to be deleted along with it behind
+		require.NoError(t, client.DeleteEntity(ctx, people["bob"]))
+
+		report, err := l.LintGraph(ctx, true)
+		require.NoError(t, err)
+		orphans := issuesOf(report, logic.LintOrphanObservation)
+		require.Len(t, orphans, 1)
+		assert.True(t, orphans[0].Fixed)
+		assert.Equal(t, orphan.ID, orphans[0].Observations[0].ID)
+		dangling := issuesOf(report, logic.LintDanglingRelation)
+		require.Len(t, dangling, 1)
+		assert.True(t, dangling[0].Fixed)
+
+		deleted, err := client.ReadObservationByIDWithDeleted(ctx, orphan.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted.DeletedAt.IsZero())
+		relations, err := client.ReadAllRelations(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, relations)
+
+		report, err = l.LintGraph(ctx, false)
+		require.NoError(t, err)
+		assert.Empty(t, issuesOf(report, logic.LintOrphanObservation))
+		assert.Empty(t, issuesOf(report, logic.LintDanglingRelation))
+	})
+
+	t.Run("deletes self loops", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		client := newTestDB(t)
+		l := NewLogic(LogicConfig{DB: client})
+		people := createEntities(t, l, "alice")
+		require.NoError(t, client.CreateRelation(ctx, &models.Relation{FromID: people["alice"].ID, ToID: people["alice"].ID, Type: "knows"}))
+
+		report, err := l.LintGraph(ctx, true)
+		require.NoError(t, err)
+		loops := issuesOf(report, logic.LintSelfLoop)
+		require.Len(t, loops, 1)
+		assert.True(t, loops[0].Fixed)
+
+		relations, err := client.ReadAllRelations(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, relations)
+	})
+
+	t.Run("only deletes exact duplicate observations", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		client := newTestDB(t)
+		l := NewLogic(LogicConfig{DB: client})
+		people := createEntities(t, l, "alice")
+		contents := []string{
+			"was born in the city of Springfield in 1990",
+			"was born in the city of Springfield in 1991",
+			"Likes  Tea",
+			"likes tea",
+		}
+		for _, c := range contents {
+			require.NoError(t, l.CreateObservation(ctx, &models.Observation{EntityID: people["alice"].ID, Contents: c}))
+		}
+
+		report, err := l.LintGraph(ctx, true)
+		require.NoError(t, err)
+		issues := issuesOf(report, logic.LintNearDuplicateObservation)
+		require.Len(t, issues, 2)
+		fixed := make(map[string]bool, len(issues))
+		for _, issue := range issues {
+			assert.Equal(t, issue.Fixable, issue.Fixed)
+			fixed[issue.Observations[1].Contents] = issue.Fixed
+		}
+		assert.Equal(t, map[string]bool{
+			"was born in the city of Springfield in 1991": false,
+			"likes tea": true,
+		}, fixed)
+
+		observations, err := client.ReadObservationsByEntityID(ctx, people["alice"].ID)
+		require.NoError(t, err)
+		assert.Len(t, observations, 3)
+	})
+}
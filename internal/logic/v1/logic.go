@@ -2,15 +2,28 @@ package v1
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/inference"
 	"github.com/tyrm/mcp-dbmem/internal/logic"
 	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
 )
 
 // Logic implements the program logic.
 type Logic struct {
-	db db.DB
+	db        db.DB
+	sessionID string
+	// planned collects the changes made while in a dry run
+	planned *[]*logic.PlannedChange
+
+	ontology   *ontology.Ontology
+	strictness ontology.Strictness
+
+	rules             *inference.Rules
+	inferenceMaxDepth int
 }
 
 var _ logic.Logic = (*Logic)(nil)
@@ -18,27 +31,93 @@ var _ logic.Logic = (*Logic)(nil)
 // LogicConfig contains the configuration for the Logic instance.
 type LogicConfig struct {
 	DB db.DB
+	// SessionID is recorded with every change made through this instance. A random one is used if empty.
+	SessionID string
+	// Ontology is enforced on new entities and relations according to OntologyStrictness if set.
+	Ontology           *ontology.Ontology
+	OntologyStrictness ontology.Strictness
+	// Rules are used to infer relations, at most InferenceMaxDepth rounds deep.
+	Rules             *inference.Rules
+	InferenceMaxDepth int
 }
 
 // NewLogic creates a new Logic instance.
 func NewLogic(cfg LogicConfig) *Logic {
+	sessionID := cfg.SessionID
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+
+	strictness := cfg.OntologyStrictness
+	if cfg.Ontology == nil {
+		strictness = ontology.StrictnessOff
+	}
+
 	return &Logic{
-		db: cfg.DB,
+		db:         cfg.DB,
+		sessionID:  sessionID,
+		ontology:   cfg.Ontology,
+		strictness: strictness,
+
+		rules:             cfg.Rules,
+		inferenceMaxDepth: cfg.InferenceMaxDepth,
 	}
 }
 
+// SessionID returns the id recorded with every change made through this instance.
+func (l *Logic) SessionID() string {
+	return l.sessionID
+}
+
+// withTx returns a copy of l bound to tx.
+func (l *Logic) withTx(tx db.DB) *Logic {
+	txLogic := *l
+	txLogic.db = tx
+
+	return &txLogic
+}
+
 func (l *Logic) CreateEntity(ctx context.Context, entity *models.Entity) error {
 	ctx, span := tracer.Start(ctx, "CreateEntity", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.CreateEntity(ctx, entity))
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := checkAliasFree(ctx, tx, entity.Name); err != nil {
+			return err
+		}
+		if err := l.enforce(l.checkEntity(entity)); err != nil {
+			return err
+		}
+		if err := tx.CreateEntity(ctx, entity); err != nil {
+			return err
+		}
+
+		return l.journal(ctx, tx, models.ChangeActionCreate, entity)
+	}))
 }
 
 func (l *Logic) DeleteEntity(ctx context.Context, entity *models.Entity) error {
 	ctx, span := tracer.Start(ctx, "DeleteEntity", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.DeleteEntity(ctx, entity))
+	// cascaded rows share the deletion time so they can be restored together
+	entity.DeletedAt = time.Now().UTC()
+
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		// cascaded rows are removed explicitly so they are journaled
+		if err := l.deleteAllRelationsByEntityID(ctx, tx, entity.ID, entity.DeletedAt); err != nil {
+			return err
+		}
+		if err := l.deleteAllObservationsByEntityID(ctx, tx, entity.ID, entity.DeletedAt); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteEntity(ctx, entity); err != nil {
+			return err
+		}
+
+		return l.journal(ctx, tx, models.ChangeActionDelete, entity)
+	}))
 }
 
 func (l *Logic) ReadAllEntities(ctx context.Context) ([]*models.Entity, error) {
@@ -67,21 +146,37 @@ func (l *Logic) CreateObservation(ctx context.Context, observation *models.Obser
 	ctx, span := tracer.Start(ctx, "CreateObservation", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.CreateObservation(ctx, observation))
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := tx.CreateObservation(ctx, observation); err != nil {
+			return err
+		}
+
+		return l.journal(ctx, tx, models.ChangeActionCreate, observation)
+	}))
 }
 
 func (l *Logic) DeleteAllObservationsByEntityID(ctx context.Context, entityID int64) error {
 	ctx, span := tracer.Start(ctx, "DeleteAllObservationsByEntityID", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.DeleteAllObservationsByEntityID(ctx, entityID))
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		return l.deleteAllObservationsByEntityID(ctx, tx, entityID, time.Now().UTC())
+	}))
 }
 
 func (l *Logic) DeleteObservation(ctx context.Context, observation *models.Observation) error {
 	ctx, span := tracer.Start(ctx, "DeleteObservation", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.DeleteObservation(ctx, observation))
+	observation.DeletedAt = time.Now().UTC()
+
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := tx.DeleteObservation(ctx, observation); err != nil {
+			return err
+		}
+
+		return l.journal(ctx, tx, models.ChangeActionDelete, observation)
+	}))
 }
 
 func (l *Logic) ReadObservationByTextForEntityID(ctx context.Context, entityID int64, text string) (*models.Observation, error) {
@@ -99,14 +194,33 @@ func (l *Logic) CreateRelation(ctx context.Context, relation *models.Relation) e
 	ctx, span := tracer.Start(ctx, "CreateRelation", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.CreateRelation(ctx, relation))
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		violations, err := l.checkRelation(ctx, tx, relation)
+		if err != nil {
+			return err
+		}
+		if err := l.enforce(violations); err != nil {
+			return err
+		}
+
+		if err := tx.CreateRelation(ctx, relation); err != nil {
+			return err
+		}
+		if err := l.journal(ctx, tx, models.ChangeActionCreate, relation); err != nil {
+			return err
+		}
+
+		return l.createInverseRelation(ctx, tx, relation)
+	}))
 }
 
 func (l *Logic) DeleteAllRelationsByEntityID(ctx context.Context, entityID int64) error {
 	ctx, span := tracer.Start(ctx, "DeleteAllRelationsByEntityID", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.DeleteAllRelationsByEntityID(ctx, entityID))
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		return l.deleteAllRelationsByEntityID(ctx, tx, entityID, time.Now().UTC())
+	}))
 }
 
 func (l *Logic) ReadAllRelations(ctx context.Context) ([]*models.Relation, error) {
@@ -135,7 +249,18 @@ func (l *Logic) DeleteRelation(ctx context.Context, relation *models.Relation) e
 	ctx, span := tracer.Start(ctx, "DeleteRelation", tracerAttrs...)
 	defer span.End()
 
-	return logic.ProcessError(l.db.DeleteRelation(ctx, relation))
+	relation.DeletedAt = time.Now().UTC()
+
+	return logic.ProcessError(l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		if err := tx.DeleteRelation(ctx, relation); err != nil {
+			return err
+		}
+		if err := l.journal(ctx, tx, models.ChangeActionDelete, relation); err != nil {
+			return err
+		}
+
+		return l.deleteInverseRelation(ctx, tx, relation)
+	}))
 }
 
 //func toolJSONResponse(ctx context.Context, response any) (*mcp.ToolResponse, error) {
@@ -1,5 +1,52 @@
 package v1
 
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/db/bun"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// newTestDB returns a migrated SQLite database of its own, retrying transactions quickly.
+func newTestDB(t *testing.T) *bun.Client {
+	t.Helper()
+
+	ctx := context.Background()
+	client, err := bun.New(ctx, bun.ClientConfig{
+		Type:    "sqlite",
+		Address: filepath.Join(t.TempDir(), "test.db"),
+		Retry: &bun.RetryConfig{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     2 * time.Millisecond,
+			Multiplier:      2,
+			MaxElapsedTime:  time.Second,
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	require.NoError(t, client.DoMigration(ctx))
+
+	return client
+}
+
+// createEntities creates an entity of type person for every name and returns them by name.
+func createEntities(t *testing.T, l *Logic, names ...string) map[string]*models.Entity {
+	t.Helper()
+
+	entities := make(map[string]*models.Entity, len(names))
+	for _, name := range names {
+		entity := &models.Entity{Name: name, Type: "person"}
+		require.NoError(t, l.CreateEntity(context.Background(), entity))
+		entities[name] = entity
+	}
+
+	return entities
+}
+
 //type unmarshalable struct{}
 //
 //func (u unmarshalable) MarshalJSON() ([]byte, error) {
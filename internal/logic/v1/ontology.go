@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"github.com/tyrm/mcp-dbmem/internal/ontology"
+	"go.uber.org/zap"
+)
+
+func (l *Logic) ReadOntology() (*ontology.Ontology, ontology.Strictness) {
+	return l.ontology, l.strictness
+}
+
+func (l *Logic) ValidateEntity(ctx context.Context, entity *models.Entity) ([]*ontology.Violation, error) {
+	_, span := tracer.Start(ctx, "ValidateEntity", tracerAttrs...)
+	defer span.End()
+
+	return l.checkEntity(entity), nil
+}
+
+func (l *Logic) ValidateRelation(ctx context.Context, relation *models.Relation) ([]*ontology.Violation, error) {
+	ctx, span := tracer.Start(ctx, "ValidateRelation", tracerAttrs...)
+	defer span.End()
+
+	violations, err := l.checkRelation(ctx, l.db, relation)
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return violations, nil
+}
+
+func (l *Logic) checkEntity(entity *models.Entity) []*ontology.Violation {
+	if l.strictness == ontology.StrictnessOff {
+		return nil
+	}
+
+	if violation := l.ontology.CheckEntityType(entity.Type); violation != nil {
+		return []*ontology.Violation{violation}
+	}
+
+	return nil
+}
+
+func (l *Logic) checkRelation(ctx context.Context, tx db.DB, relation *models.Relation) ([]*ontology.Violation, error) {
+	if l.strictness == ontology.StrictnessOff {
+		return nil, nil
+	}
+
+	entities, err := tx.ReadEntitiesByIDs(ctx, []int64{relation.FromID, relation.ToID})
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return nil, err
+	}
+	var fromType, toType string
+	for _, entity := range entities {
+		if entity.ID == relation.FromID {
+			fromType = entity.Type
+		}
+		if entity.ID == relation.ToID {
+			toType = entity.Type
+		}
+	}
+
+	fromCount, err := countRelations(ctx, tx, relation.FromID, func(r *models.Relation) bool {
+		return r.FromID == relation.FromID && r.Type == relation.Type
+	})
+	if err != nil {
+		return nil, err
+	}
+	toCount, err := countRelations(ctx, tx, relation.ToID, func(r *models.Relation) bool {
+		return r.ToID == relation.ToID && r.Type == relation.Type
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.ontology.CheckRelation(relation.Type, fromType, toType, fromCount, toCount), nil
+}
+
+// enforce returns an error for violations when rejecting them, and logs them when only warning.
+func (l *Logic) enforce(violations []*ontology.Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if l.strictness == ontology.StrictnessReject {
+		return &logic.OntologyViolationError{Violations: violations}
+	}
+
+	for _, violation := range violations {
+		zap.L().Warn("Ontology violation", zap.String("kind", string(violation.Kind)), zap.String("message", violation.Message))
+	}
+
+	return nil
+}
+
+// countRelations counts the relations of entityID matching match.
+func countRelations(ctx context.Context, tx db.DB, entityID int64, match func(r *models.Relation) bool) (int, error) {
+	relations, err := tx.ReadRelationsByEntityID(ctx, entityID)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		return 0, err
+	}
+
+	count := 0
+	for _, relation := range relations {
+		if match(relation) {
+			count++
+		}
+	}
+
+	return count, nil
+}
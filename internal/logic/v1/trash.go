@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tyrm/mcp-dbmem/internal/db"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// PurgeTrash permanently removes everything moved to the trash before deletedBefore.
+func (l *Logic) PurgeTrash(ctx context.Context, deletedBefore time.Time) (*logic.PurgeResult, error) {
+	ctx, span := tracer.Start(ctx, "PurgeTrash", tracerAttrs...)
+	defer span.End()
+
+	result := new(logic.PurgeResult)
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		var err db.Error
+		if result.Relations, err = tx.PurgeRelations(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if result.Observations, err = tx.PurgeObservations(ctx, deletedBefore); err != nil {
+			return err
+		}
+		if result.Entities, err = tx.PurgeEntities(ctx, deletedBefore); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, logic.ProcessError(err)
+	}
+
+	return result, nil
+}
+
+// ReadTrash returns the entities in the trash along with the observations deleted with them, and the observations
+// and relations that were deleted on their own.
+func (l *Logic) ReadTrash(ctx context.Context) ([]*models.Entity, []*models.Observation, []*models.Relation, error) {
+	ctx, span := tracer.Start(ctx, "ReadTrash", tracerAttrs...)
+	defer span.End()
+
+	entities, err := l.db.ReadDeletedEntities(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, nil, nil, logic.ProcessError(err)
+	}
+	observations, err := l.db.ReadDeletedObservations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, nil, nil, logic.ProcessError(err)
+	}
+	relations, err := l.db.ReadDeletedRelations(ctx)
+	if err != nil && !errors.Is(err, db.ErrNoEntries) {
+		span.RecordError(err)
+		return nil, nil, nil, logic.ProcessError(err)
+	}
+
+	byID := make(map[int64]*models.Entity, len(entities))
+	for _, entity := range entities {
+		entity.Observations = make([]*models.Observation, 0)
+		byID[entity.ID] = entity
+	}
+
+	deletedAlone := make([]*models.Observation, 0)
+	for _, observation := range observations {
+		if observation.Entity == nil {
+			continue
+		}
+		entity, ok := byID[observation.EntityID]
+		if ok && deletedTogether(entity.DeletedAt, observation.DeletedAt) {
+			entity.Observations = append(entity.Observations, observation)
+			continue
+		}
+		deletedAlone = append(deletedAlone, observation)
+	}
+
+	withEntities := make([]*models.Relation, 0, len(relations))
+	for _, relation := range relations {
+		if relation.From != nil && relation.To != nil {
+			withEntities = append(withEntities, relation)
+		}
+	}
+
+	return entities, deletedAlone, withEntities, nil
+}
+
+// RestoreEntityByName takes the most recently deleted entity named name out of the trash, together with the
+// observations and relations that were deleted with it.
+func (l *Logic) RestoreEntityByName(ctx context.Context, name string) (*models.Entity, []*models.Relation, error) {
+	ctx, span := tracer.Start(ctx, "RestoreEntityByName", tracerAttrs...)
+	defer span.End()
+
+	var entity *models.Entity
+	restoredRelations := make([]*models.Relation, 0)
+	err := l.db.RunInTx(ctx, func(ctx context.Context, tx db.DB) error {
+		// a retried transaction starts over
+		restoredRelations = restoredRelations[:0]
+
+		var err db.Error
+		entity, err = tx.ReadDeletedEntityByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		deletedAt := entity.DeletedAt
+
+		observations, err := tx.ReadDeletedObservationsByEntityID(ctx, entity.ID)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+		relations, err := tx.ReadDeletedRelationsByEntityID(ctx, entity.ID)
+		if err != nil && !errors.Is(err, db.ErrNoEntries) {
+			return err
+		}
+
+		if err := l.restore(ctx, tx, entity); err != nil {
+			return err
+		}
+
+		entity.Observations = make([]*models.Observation, 0, len(observations))
+		for _, observation := range observations {
+			if !deletedTogether(deletedAt, observation.DeletedAt) {
+				continue
+			}
+			if err := l.restore(ctx, tx, observation); err != nil {
+				return err
+			}
+			entity.Observations = append(entity.Observations, observation)
+		}
+
+		for _, relation := range relations {
+			if !deletedTogether(deletedAt, relation.DeletedAt) || relation.From == nil || relation.To == nil {
+				continue
+			}
+			if err := l.restore(ctx, tx, relation); err != nil {
+				return err
+			}
+			restoredRelations = append(restoredRelations, relation)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, db.ErrNoEntries) {
+			span.RecordError(err)
+		}
+		return nil, nil, logic.ProcessError(err)
+	}
+
+	return entity, restoredRelations, nil
+}
+
+// restore takes record out of the trash and journals it.
+func (l *Logic) restore(ctx context.Context, tx db.DB, record any) error {
+	var err db.Error
+	switch r := record.(type) {
+	case *models.Entity:
+		err = tx.RestoreEntity(ctx, r)
+	case *models.Observation:
+		err = tx.RestoreObservation(ctx, r)
+	case *models.Relation:
+		err = tx.RestoreRelation(ctx, r)
+	}
+	if err != nil {
+		return err
+	}
+
+	return l.journal(ctx, tx, models.ChangeActionCreate, record)
+}
+
+// deletedTogether reports if two rows read from the trash were deleted by the same call.
+func deletedTogether(a, b time.Time) bool {
+	return a.Equal(b)
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/logic"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+// trashFixture is alice, bob and carol with some observations and relations, where an observation and a relation of
+// alice were deleted on their own before alice.
+type trashFixture struct {
+	l            *Logic
+	people       map[string]*models.Entity
+	cascaded     *models.Observation
+	deletedAlone *models.Observation
+}
+
+func newTrashFixture(t *testing.T) *trashFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	l := NewLogic(LogicConfig{DB: newTestDB(t)})
+	people := createEntities(t, l, "alice", "bob", "carol")
+	cascaded := &models.Observation{EntityID: people["alice"].ID, Contents: "likes tea"}
+	require.NoError(t, l.CreateObservation(ctx, cascaded))
+	deletedAlone := &models.Observation{EntityID: people["alice"].ID, Contents: "likes coffee"}
+	require.NoError(t, l.CreateObservation(ctx, deletedAlone))
+	knowsBob := &models.Relation{FromID: people["alice"].ID, ToID: people["bob"].ID, Type: "knows"}
+	require.NoError(t, l.CreateRelation(ctx, knowsBob))
+	require.NoError(t, l.CreateRelation(ctx, &models.Relation{FromID: people["carol"].ID, ToID: people["alice"].ID, Type: "knows"}))
+	require.NoError(t, l.CreateRelation(ctx, &models.Relation{FromID: people["bob"].ID, ToID: people["carol"].ID, Type: "knows"}))
+
+	require.NoError(t, l.DeleteObservation(ctx, deletedAlone))
+	require.NoError(t, l.DeleteRelation(ctx, knowsBob))
+	require.NoError(t, l.DeleteEntity(ctx, people["alice"]))
+
+	return &trashFixture{
+		l:            l,
+		people:       people,
+		cascaded:     cascaded,
+		deletedAlone: deletedAlone,
+	}
+}
+
+func TestLogic_DeleteEntity_cascades(t *testing.T) {
+	t.Parallel()
+
+	f := newTrashFixture(t)
+	entities, observations, relations, err := f.l.ReadTrash(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, entities, 1)
+	alice := entities[0]
+	assert.Equal(t, "alice", alice.Name)
+	require.Len(t, alice.Observations, 1)
+	assert.Equal(t, f.cascaded.ID, alice.Observations[0].ID)
+	assert.True(t, alice.DeletedAt.Equal(alice.Observations[0].DeletedAt))
+
+	require.Len(t, observations, 1)
+	assert.Equal(t, f.deletedAlone.ID, observations[0].ID)
+	assert.False(t, alice.DeletedAt.Equal(observations[0].DeletedAt))
+
+	require.Len(t, relations, 2)
+	shared := 0
+	for _, relation := range relations {
+		if relation.DeletedAt.Equal(alice.DeletedAt) {
+			shared++
+			assert.Equal(t, "carol", relation.From.Name)
+		}
+	}
+	assert.Equal(t, 1, shared, "only carol knows alice was deleted along with alice")
+
+	live, err := f.l.ReadAllRelations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, live, 1)
+	assert.Equal(t, f.people["bob"].ID, live[0].FromID)
+}
+
+func TestLogic_RestoreEntityByName(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := newTrashFixture(t)
+
+	entity, relations, err := f.l.RestoreEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, f.people["alice"].ID, entity.ID)
+	require.Len(t, entity.Observations, 1)
+	assert.Equal(t, f.cascaded.ID, entity.Observations[0].ID)
+	require.Len(t, relations, 1)
+	assert.Equal(t, f.people["carol"].ID, relations[0].FromID)
+
+	restored, err := f.l.ReadEntityByName(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	_, err = f.l.ReadObservationByTextForEntityID(ctx, entity.ID, "likes tea")
+	assert.NoError(t, err)
+	_, err = f.l.ReadObservationByTextForEntityID(ctx, entity.ID, "likes coffee")
+	assert.Error(t, err, "deleted on its own before alice")
+	_, err = f.l.ReadExactRelation(ctx, f.people["alice"].ID, f.people["bob"].ID, "knows")
+	assert.Error(t, err, "deleted on its own before alice")
+
+	entities, observations, trashed, err := f.l.ReadTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entities)
+	assert.Len(t, observations, 1)
+	assert.Len(t, trashed, 1)
+
+	_, _, err = f.l.RestoreEntityByName(ctx, "alice")
+	assert.ErrorIs(t, err, logic.ErrNotFound)
+}
+
+func TestLogic_PurgeTrash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := newTrashFixture(t)
+	deletedAfter := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, f.l.DeleteEntity(ctx, f.people["bob"]))
+
+	result, err := f.l.PurgeTrash(ctx, deletedAfter.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, &logic.PurgeResult{}, result, "nothing is that old")
+
+	result, err = f.l.PurgeTrash(ctx, deletedAfter)
+	require.NoError(t, err)
+	// alice with both observations, knows bob, carol knows alice
+	assert.Equal(t, &logic.PurgeResult{Entities: 1, Observations: 2, Relations: 2}, result)
+
+	entities, observations, relations, err := f.l.ReadTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, entities, 1)
+	assert.Equal(t, "bob", entities[0].Name)
+	assert.Empty(t, observations)
+	require.Len(t, relations, 1)
+	assert.Equal(t, f.people["carol"].ID, relations[0].ToID, "bob knows carol is younger")
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tyrm/mcp-dbmem/internal/logging"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+	"go.uber.org/zap"
+)
+
+// Namespace prefixes the names of all metrics.
+const Namespace = "mcp_dbmem"
+
+// ErrorClassNone is the error class of successful calls.
+const ErrorClassNone = ""
+
+// Metrics holds the Prometheus metrics of the application. A nil *Metrics records nothing, so callers don't have to
+// check whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls    *prometheus.CounterVec
+	toolErrors   *prometheus.CounterVec
+	toolDuration *prometheus.HistogramVec
+
+	entities     prometheus.Gauge
+	observations prometheus.Gauge
+	relations    prometheus.Gauge
+}
+
+// New creates the metrics in a registry of their own, along with the Go runtime and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tool_calls_total",
+			Help:      "Tool calls by tool.",
+		}, []string{"tool"}),
+		toolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "tool_errors_total",
+			Help:      "Failed tool calls by tool and error class.",
+		}, []string{"tool", "class"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "tool_duration_seconds",
+			Help:      "How long tool calls took by tool.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		}, []string{"tool"}),
+		entities: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "entities",
+			Help:      "Entities in the knowledge graph, not counting the trash.",
+		}),
+		observations: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "observations",
+			Help:      "Observations in the knowledge graph, not counting the trash.",
+		}),
+		relations: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "relations",
+			Help:      "Relations in the knowledge graph, not counting the trash.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolErrors,
+		m.toolDuration,
+		m.entities,
+		m.observations,
+		m.relations,
+	)
+
+	return m
+}
+
+// Registerer returns the registry other packages add their metrics to, nil if m is nil.
+func (m *Metrics) Registerer() prometheus.Registerer {
+	if m == nil {
+		return nil
+	}
+
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveToolCall records a call of tool that took duration and failed with an error of class, ErrorClassNone if it
+// succeeded.
+func (m *Metrics) ObserveToolCall(tool string, duration time.Duration, class string) {
+	if m == nil {
+		return
+	}
+
+	m.toolCalls.WithLabelValues(tool).Inc()
+	m.toolDuration.WithLabelValues(tool).Observe(duration.Seconds())
+	if class != ErrorClassNone {
+		m.toolErrors.WithLabelValues(tool, class).Inc()
+	}
+}
+
+// GraphCounter counts the entries of the knowledge graph.
+type GraphCounter func(ctx context.Context) (*models.GraphCounts, error)
+
+// RefreshGraphCounts sets the entity, observation and relation gauges with count every interval until ctx is done.
+func (m *Metrics) RefreshGraphCounts(ctx context.Context, count GraphCounter, interval time.Duration) {
+	if m == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.refreshGraphCounts(ctx, count)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Metrics) refreshGraphCounts(ctx context.Context, count GraphCounter) {
+	counts, err := count(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.L(ctx).Warn("Can't count the knowledge graph", zap.Error(err))
+		}
+		return
+	}
+
+	m.entities.Set(float64(counts.Entities))
+	m.observations.Set(float64(counts.Observations))
+	m.relations.Set(float64(counts.Relations))
+}
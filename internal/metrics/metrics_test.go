@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyrm/mcp-dbmem/internal/models"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	m.ObserveToolCall("create_entities", 10*time.Millisecond, ErrorClassNone)
+	m.ObserveToolCall("create_entities", 20*time.Millisecond, "conflict")
+	m.ObserveToolCall("read_graph", time.Millisecond, ErrorClassNone)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	m.RefreshGraphCounts(ctx, func(context.Context) (*models.GraphCounts, error) {
+		calls++
+		if calls > 1 {
+			cancel()
+			return nil, errors.New("database is gone")
+		}
+		return &models.GraphCounts{Entities: 3, Observations: 5, Relations: 2}, nil
+	}, time.Millisecond)
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		`mcp_dbmem_tool_calls_total{tool="create_entities"} 2`,
+		`mcp_dbmem_tool_calls_total{tool="read_graph"} 1`,
+		`mcp_dbmem_tool_errors_total{class="conflict",tool="create_entities"} 1`,
+		`mcp_dbmem_tool_duration_seconds_count{tool="create_entities"} 2`,
+		// a failed refresh keeps the last counts
+		"mcp_dbmem_entities 3",
+		"mcp_dbmem_observations 5",
+		"mcp_dbmem_relations 2",
+		"go_goroutines",
+	} {
+		assert.Contains(t, body, line)
+	}
+	assert.NotRegexp(t, `tool_errors_total\{[^}]*tool="read_graph"`, body)
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	t.Parallel()
+
+	var m *Metrics
+	assert.Nil(t, m.Registerer())
+	m.ObserveToolCall("read_graph", time.Millisecond, ErrorClassNone)
+	m.RefreshGraphCounts(context.Background(), nil, time.Millisecond)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ChangeAction describes what happened to a record in a Change.
+type ChangeAction string
+
+const (
+	// ChangeActionCreate is used when a record was inserted.
+	ChangeActionCreate ChangeAction = "create"
+	// ChangeActionDelete is used when a record was removed.
+	ChangeActionDelete ChangeAction = "delete"
+	// ChangeActionUpdate is used when a record was changed in place, the change holds the record as it was before.
+	ChangeActionUpdate ChangeAction = "update"
+)
+
+// ChangeKind describes which kind of record a Change refers to.
+type ChangeKind string
+
+const (
+	// ChangeKindEntity is used for changes to entities.
+	ChangeKindEntity ChangeKind = "entity"
+	// ChangeKindObservation is used for changes to observations.
+	ChangeKindObservation ChangeKind = "observation"
+	// ChangeKindRelation is used for changes to relations.
+	ChangeKindRelation ChangeKind = "relation"
+)
+
+// Change represents a single mutation of the knowledge graph in the change journal.
+type Change struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	SessionID string       `bun:"session_id,notnull"     json:"session_id"`
+	Action    ChangeAction `bun:"action,notnull"         json:"action"`
+	Kind      ChangeKind   `bun:"kind,notnull"           json:"kind"`
+	RecordID  int64        `bun:"record_id,notnull"      json:"record_id"`
+	Data      string       `bun:"data,type:text,notnull" json:"data"`
+}
+
+var _ bun.BeforeAppendModelHook = (*Change)(nil)
+
+// BeforeAppendModel stamps new changes with the time they're made. The default of the database only has second
+// precision, which isn't enough to tell changes apart by time.
+func (c *Change) BeforeAppendModel(_ context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+
+	return nil
+}
+
+// NewChange returns a change of action on record, holding a snapshot of the record.
+func NewChange(action ChangeAction, record any) (*Change, error) {
+	change := &Change{
+		Action: action,
+	}
+
+	// snapshots only hold the record's own columns
+	var snapshot any
+	switch r := record.(type) {
+	case *Entity:
+		entity := *r
+		entity.Observations = nil
+		change.Kind, change.RecordID, snapshot = ChangeKindEntity, r.ID, &entity
+	case *Observation:
+		observation := *r
+		observation.Entity = nil
+		change.Kind, change.RecordID, snapshot = ChangeKindObservation, r.ID, &observation
+	case *Relation:
+		relation := *r
+		relation.From, relation.To = nil, nil
+		change.Kind, change.RecordID, snapshot = ChangeKindRelation, r.ID, &relation
+	default:
+		return nil, fmt.Errorf("can't journal %T", record)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	change.Data = string(data)
+
+	return change, nil
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CopyMapping maps the id of a row copied from another database to the id of its copy.
+type CopyMapping struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	// Source identifies the database and namespace the row was copied from.
+	Source      string `bun:"source,type:varchar(64),notnull,unique:copy_mappings_source_row"       json:"source"`
+	SourceTable string `bun:"source_table,type:varchar(32),notnull,unique:copy_mappings_source_row" json:"source_table"`
+	SourceID    int64  `bun:"source_id,notnull,unique:copy_mappings_source_row"                     json:"source_id"`
+	// TargetID is the id of the copy, 0 if the row was skipped.
+	TargetID int64 `bun:"target_id,notnull" json:"target_id"`
+}
@@ -1,14 +1,32 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
 
 // Entity represents an entity in a knowledge graph.
 type Entity struct {
 	ID        int64     `bun:",pk,autoincrement"`
 	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	GlobalID  string    `bun:"global_id,type:varchar(36)"`
 
 	Name         string         `bun:"name,notnull"                   json:"name"`
 	Type         string         `bun:"type,notnull"                   json:"type"`
 	Observations []*Observation `bun:"rel:has-many,join:id=entity_id" json:"observations"`
 }
+
+var _ bun.BeforeAppendModelHook = (*Entity)(nil)
+
+// BeforeAppendModel gives new entities a global id.
+func (e *Entity) BeforeAppendModel(_ context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && e.GlobalID == "" {
+		e.GlobalID = NewGlobalID()
+	}
+
+	return nil
+}
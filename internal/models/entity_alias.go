@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// EntityAlias represents an alternative name an entity can be referred to by.
+type EntityAlias struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Alias string `bun:"alias,notnull" json:"alias"`
+	// Normalized is the alias folded to lower case. It is unique across all entities.
+	Normalized string `bun:"normalized,notnull,unique" json:"normalized"`
+
+	EntityID int64   `bun:"entity_id,notnull"                json:"entity_id"`
+	Entity   *Entity `bun:"rel:belongs-to,join:entity_id=id" json:"entity"`
+}
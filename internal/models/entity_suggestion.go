@@ -0,0 +1,10 @@
+package models
+
+// EntitySuggestion is an existing entity whose name or one of its aliases is similar to a name that was looked up.
+type EntitySuggestion struct {
+	Entity *Entity
+	// Alias is set when the entity was suggested for one of its aliases rather than its name.
+	Alias string
+	// Score is the trigram similarity between 0 and 1.
+	Score float64
+}
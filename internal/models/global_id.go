@@ -0,0 +1,9 @@
+package models
+
+import "github.com/google/uuid"
+
+// NewGlobalID returns a new global id. Global ids identify entities, observations and relations across the databases
+// they're synced between. They're time ordered UUIDs.
+func NewGlobalID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
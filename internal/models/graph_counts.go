@@ -0,0 +1,8 @@
+package models
+
+// GraphCounts is how many entries the knowledge graph holds, not counting the trash.
+type GraphCounts struct {
+	Entities     int
+	Observations int
+	Relations    int
+}
@@ -1,15 +1,33 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
 
 // Observation represents an observation about an entity in a knowledge graph.
 type Observation struct {
 	ID        int64     `bun:",pk,autoincrement"`
 	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	GlobalID  string    `bun:"global_id,type:varchar(36)"`
 
 	Contents string `bun:"contents,notnull" json:"contents"`
 
 	EntityID int64   `bun:"entity_id,notnull"                json:"entity_id"`
 	Entity   *Entity `bun:"rel:belongs-to,join:entity_id=id" json:"entity"`
 }
+
+var _ bun.BeforeAppendModelHook = (*Observation)(nil)
+
+// BeforeAppendModel gives new observations a global id.
+func (o *Observation) BeforeAppendModel(_ context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && o.GlobalID == "" {
+		o.GlobalID = NewGlobalID()
+	}
+
+	return nil
+}
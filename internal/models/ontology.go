@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Ontology represents a stored ontology document. The most recently created one is in effect.
+type Ontology struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	// Document is the ontology as YAML.
+	Document string `bun:"document,type:text,notnull" json:"document"`
+}
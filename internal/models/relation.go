@@ -1,12 +1,19 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
 
 // Relation represents a relation between two entities in a knowledge graph.
 type Relation struct {
 	ID        int64     `bun:",pk,autoincrement"`
 	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	GlobalID  string    `bun:"global_id,type:varchar(36)"`
 
 	Type string `bun:"type,notnull" json:"type"`
 
@@ -15,3 +22,14 @@ type Relation struct {
 	ToID   int64   `bun:"to_id,notnull"                  json:"to_id"`
 	To     *Entity `bun:"rel:belongs-to,join:to_id=id"   json:"to"`
 }
+
+var _ bun.BeforeAppendModelHook = (*Relation)(nil)
+
+// BeforeAppendModel gives new relations a global id.
+func (r *Relation) BeforeAppendModel(_ context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && r.GlobalID == "" {
+		r.GlobalID = NewGlobalID()
+	}
+
+	return nil
+}
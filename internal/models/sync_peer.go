@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SyncPeer holds the watermarks of the last sync with another database.
+type SyncPeer struct {
+	ID        int64     `bun:",pk,autoincrement"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	// NodeID is the node id of the other database.
+	NodeID string `bun:"node_id,type:varchar(36),notnull,unique" json:"node_id"`
+	// SentChangeID is the last change of this database the other database has.
+	SentChangeID int64 `bun:"sent_change_id,notnull" json:"sent_change_id"`
+	// ReceivedChangeID is the last change of the other database this database has.
+	ReceivedChangeID int64     `bun:"received_change_id,notnull" json:"received_change_id"`
+	SyncedAt         time.Time `bun:"synced_at,notnull"          json:"synced_at"`
+}
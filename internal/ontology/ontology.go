@@ -0,0 +1,231 @@
+package ontology
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ontology declares the entity and relation types a knowledge graph may use.
+type Ontology struct {
+	EntityTypes   []*EntityType   `json:"entityTypes"   yaml:"entityTypes"`
+	RelationTypes []*RelationType `json:"relationTypes" yaml:"relationTypes"`
+}
+
+// EntityType is an allowed entity type.
+type EntityType struct {
+	Name        string `json:"name"                  yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+}
+
+// RelationType is an allowed relation type.
+type RelationType struct {
+	Name        string `json:"name"                  yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+	// Domain lists the entity types a relation may start at. Any type is allowed if it's empty.
+	Domain []string `json:"domain,omitempty" yaml:"domain"`
+	// Range lists the entity types a relation may end at. Any type is allowed if it's empty.
+	Range []string `json:"range,omitempty" yaml:"range"`
+	// MaxPerFrom is the most relations of this type an entity may start, 0 is unlimited.
+	MaxPerFrom int `json:"maxPerFrom,omitempty" yaml:"maxPerFrom"`
+	// MaxPerTo is the most relations of this type an entity may be the end of, 0 is unlimited.
+	MaxPerTo int `json:"maxPerTo,omitempty" yaml:"maxPerTo"`
+	// Inverse is the relation type kept in the opposite direction, e.g. managed_by for manages. It is declared
+	// automatically if it isn't already.
+	Inverse string `json:"inverse,omitempty" yaml:"inverse"`
+	// Symmetric relation types mean the same in both directions, e.g. married_to.
+	Symmetric bool `json:"symmetric,omitempty" yaml:"symmetric"`
+}
+
+// Load reads an ontology from a YAML file.
+func Load(path string) (*Ontology, error) {
+	/* #nosec G304 */
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read ontology %s: %w", path, err)
+	}
+
+	return Parse(data)
+}
+
+// Parse reads an ontology from a YAML document and checks it's consistent.
+func Parse(data []byte) (*Ontology, error) {
+	o := new(Ontology)
+	if err := yaml.Unmarshal(data, o); err != nil {
+		return nil, fmt.Errorf("can't parse ontology: %w", err)
+	}
+	if err := o.check(); err != nil {
+		return nil, err
+	}
+	o.declareInverses()
+
+	return o, nil
+}
+
+// EntityType returns the entity type named name.
+func (o *Ontology) EntityType(name string) (*EntityType, bool) {
+	for _, entityType := range o.EntityTypes {
+		if entityType.Name == name {
+			return entityType, true
+		}
+	}
+
+	return nil, false
+}
+
+// RelationType returns the relation type named name.
+func (o *Ontology) RelationType(name string) (*RelationType, bool) {
+	for _, relationType := range o.RelationTypes {
+		if relationType.Name == name {
+			return relationType, true
+		}
+	}
+
+	return nil, false
+}
+
+// InverseOf returns the relation type kept in the opposite direction of relationType. For symmetric types that is
+// relationType itself.
+func (o *Ontology) InverseOf(relationType string) (string, bool) {
+	t, ok := o.RelationType(relationType)
+	switch {
+	case !ok:
+		return "", false
+	case t.Symmetric:
+		return t.Name, true
+	case t.Inverse != "":
+		return t.Inverse, true
+	default:
+		return "", false
+	}
+}
+
+// IsSymmetric returns true if relationType means the same in both directions.
+func (o *Ontology) IsSymmetric(relationType string) bool {
+	t, ok := o.RelationType(relationType)
+	return ok && t.Symmetric
+}
+
+// declareInverses declares the inverse of every relation type that names one, or points it back if it's declared.
+func (o *Ontology) declareInverses() {
+	for _, t := range o.RelationTypes {
+		if t.Inverse == "" {
+			continue
+		}
+
+		inverse, ok := o.RelationType(t.Inverse)
+		if !ok {
+			inverse = &RelationType{
+				Name:       t.Inverse,
+				Domain:     t.Range,
+				Range:      t.Domain,
+				MaxPerFrom: t.MaxPerTo,
+				MaxPerTo:   t.MaxPerFrom,
+			}
+			o.RelationTypes = append(o.RelationTypes, inverse)
+		}
+		if inverse.Inverse == "" {
+			inverse.Inverse = t.Name
+		}
+	}
+}
+
+func (o *Ontology) check() error {
+	var errs []error
+
+	entityTypes := make(map[string]bool, len(o.EntityTypes))
+	for _, entityType := range o.EntityTypes {
+		switch {
+		case entityType.Name == "":
+			errs = append(errs, errors.New("entity type without a name"))
+		case entityTypes[entityType.Name]:
+			errs = append(errs, fmt.Errorf("entity type %s is declared twice", entityType.Name))
+		}
+		entityTypes[entityType.Name] = true
+	}
+
+	relationTypes := make(map[string]bool, len(o.RelationTypes))
+	for _, relationType := range o.RelationTypes {
+		switch {
+		case relationType.Name == "":
+			errs = append(errs, errors.New("relation type without a name"))
+		case relationTypes[relationType.Name]:
+			errs = append(errs, fmt.Errorf("relation type %s is declared twice", relationType.Name))
+		}
+		relationTypes[relationType.Name] = true
+
+		for _, name := range append(append([]string{}, relationType.Domain...), relationType.Range...) {
+			if !entityTypes[name] {
+				errs = append(errs, fmt.Errorf("relation type %s refers to undeclared entity type %s", relationType.Name, name))
+			}
+		}
+		if relationType.MaxPerFrom < 0 || relationType.MaxPerTo < 0 {
+			errs = append(errs, fmt.Errorf("relation type %s has a negative cardinality", relationType.Name))
+		}
+		if relationType.Symmetric && relationType.Inverse != "" {
+			errs = append(errs, fmt.Errorf("relation type %s is symmetric so it can't have an inverse", relationType.Name))
+		}
+		if relationType.Inverse == relationType.Name && relationType.Name != "" {
+			errs = append(errs, fmt.Errorf("relation type %s is its own inverse, declare it symmetric instead", relationType.Name))
+		}
+	}
+
+	// inverses have to agree with each other
+	for _, relationType := range o.RelationTypes {
+		if relationType.Inverse == "" {
+			continue
+		}
+		inverse, ok := o.RelationType(relationType.Inverse)
+		if !ok {
+			continue
+		}
+		switch {
+		case inverse.Symmetric:
+			errs = append(errs, fmt.Errorf("relation type %s is symmetric so it can't be the inverse of %s", inverse.Name, relationType.Name))
+		case inverse.Inverse != "" && inverse.Inverse != relationType.Name:
+			errs = append(errs, fmt.Errorf("relation type %s is the inverse of both %s and %s", inverse.Name, relationType.Name, inverse.Inverse))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid ontology: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// similarName returns the declared name that matches name ignoring case, if there is one.
+func similarName(name string, names []string) string {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+	}
+
+	return ""
+}
+
+// Strictness says what happens when an entity or relation violates the ontology.
+type Strictness string
+
+const (
+	// StrictnessOff ignores the ontology.
+	StrictnessOff Strictness = "off"
+	// StrictnessWarn reports violations but makes the change anyway.
+	StrictnessWarn Strictness = "warn"
+	// StrictnessReject refuses changes that violate the ontology.
+	StrictnessReject Strictness = "reject"
+)
+
+// ParseStrictness returns the strictness named s.
+func ParseStrictness(s string) (Strictness, error) {
+	switch strictness := Strictness(s); strictness {
+	case StrictnessOff, StrictnessWarn, StrictnessReject:
+		return strictness, nil
+	default:
+		return "", fmt.Errorf("unknown ontology strictness %q, use one of off, warn or reject", s)
+	}
+}
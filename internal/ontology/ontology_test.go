@@ -0,0 +1,181 @@
+package ontology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOntology = `
+entityTypes:
+  - name: person
+  - name: organization
+relationTypes:
+  - name: works_at
+    domain: [person]
+    range: [organization]
+    maxPerFrom: 1
+  - name: knows
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			document: testOntology,
+		},
+		{
+			name:     "undeclared domain",
+			document: "relationTypes:\n  - name: works_at\n    domain: [person]\n",
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate entity type",
+			document: "entityTypes:\n  - name: person\n  - name: person\n",
+			wantErr:  true,
+		},
+		{
+			name:     "symmetric with inverse",
+			document: "relationTypes:\n  - name: knows\n    symmetric: true\n    inverse: known_by\n",
+			wantErr:  true,
+		},
+		{
+			name:     "own inverse",
+			document: "relationTypes:\n  - name: knows\n    inverse: knows\n",
+			wantErr:  true,
+		},
+		{
+			name:     "conflicting inverses",
+			document: "relationTypes:\n  - name: manages\n    inverse: managed_by\n  - name: managed_by\n    inverse: leads\n",
+			wantErr:  true,
+		},
+		{
+			name:     "not yaml",
+			document: "entityTypes: [",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.document))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOntology_CheckRelation(t *testing.T) {
+	o, err := Parse([]byte(testOntology))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		relationType string
+		fromType     string
+		toType       string
+		fromCount    int
+		want         []ViolationKind
+	}{
+		{
+			name:         "allowed",
+			relationType: "works_at",
+			fromType:     "person",
+			toType:       "organization",
+		},
+		{
+			name:         "undeclared type",
+			relationType: "Works_At",
+			fromType:     "person",
+			toType:       "organization",
+			want:         []ViolationKind{ViolationRelationType},
+		},
+		{
+			name:         "wrong domain and range",
+			relationType: "works_at",
+			fromType:     "organization",
+			toType:       "person",
+			want:         []ViolationKind{ViolationDomain, ViolationRange},
+		},
+		{
+			name:         "too many",
+			relationType: "works_at",
+			fromType:     "person",
+			toType:       "organization",
+			fromCount:    1,
+			want:         []ViolationKind{ViolationCardinality},
+		},
+		{
+			name:         "unconstrained",
+			relationType: "knows",
+			fromType:     "organization",
+			toType:       "person",
+			fromCount:    10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := o.CheckRelation(tt.relationType, tt.fromType, tt.toType, tt.fromCount, 0)
+			kinds := make([]ViolationKind, 0, len(violations))
+			for _, violation := range violations {
+				kinds = append(kinds, violation.Kind)
+			}
+			assert.ElementsMatch(t, tt.want, kinds)
+		})
+	}
+}
+
+func TestOntology_InverseOf(t *testing.T) {
+	o, err := Parse([]byte(`
+entityTypes:
+  - name: person
+  - name: team
+relationTypes:
+  - name: manages
+    domain: [person]
+    range: [team]
+    maxPerTo: 1
+    inverse: managed_by
+  - name: married_to
+    symmetric: true
+  - name: knows
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		relationType string
+		want         string
+		wantOK       bool
+	}{
+		{relationType: "manages", want: "managed_by", wantOK: true},
+		{relationType: "managed_by", want: "manages", wantOK: true},
+		{relationType: "married_to", want: "married_to", wantOK: true},
+		{relationType: "knows"},
+		{relationType: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relationType, func(t *testing.T) {
+			got, ok := o.InverseOf(tt.relationType)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// the declared inverse mirrors the relation it was declared for
+	managedBy, ok := o.RelationType("managed_by")
+	require.True(t, ok)
+	assert.Equal(t, []string{"team"}, managedBy.Domain)
+	assert.Equal(t, []string{"person"}, managedBy.Range)
+	assert.Equal(t, 1, managedBy.MaxPerFrom)
+	assert.Zero(t, managedBy.MaxPerTo)
+}
@@ -0,0 +1,93 @@
+package ontology
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ViolationKind says which rule of the ontology was broken.
+type ViolationKind string
+
+const (
+	ViolationEntityType   ViolationKind = "entity_type"
+	ViolationRelationType ViolationKind = "relation_type"
+	ViolationDomain       ViolationKind = "domain"
+	ViolationRange        ViolationKind = "range"
+	ViolationCardinality  ViolationKind = "cardinality"
+)
+
+// Violation is a single way an entity or relation doesn't fit the ontology.
+type Violation struct {
+	Kind    ViolationKind `json:"kind"`
+	Message string        `json:"message"`
+}
+
+// CheckEntityType returns a violation if entityType isn't declared.
+func (o *Ontology) CheckEntityType(entityType string) *Violation {
+	if _, ok := o.EntityType(entityType); ok {
+		return nil
+	}
+
+	names := make([]string, 0, len(o.EntityTypes))
+	for _, t := range o.EntityTypes {
+		names = append(names, t.Name)
+	}
+
+	return &Violation{
+		Kind:    ViolationEntityType,
+		Message: undeclared("entity type", entityType, names),
+	}
+}
+
+// CheckRelation returns the violations of a relation of relationType from an entity of fromType to an entity of
+// toType. fromCount and toCount are the number of relations of relationType the entities already have.
+func (o *Ontology) CheckRelation(relationType, fromType, toType string, fromCount, toCount int) []*Violation {
+	t, ok := o.RelationType(relationType)
+	if !ok {
+		names := make([]string, 0, len(o.RelationTypes))
+		for _, t := range o.RelationTypes {
+			names = append(names, t.Name)
+		}
+
+		return []*Violation{{
+			Kind:    ViolationRelationType,
+			Message: undeclared("relation type", relationType, names),
+		}}
+	}
+
+	var violations []*Violation
+	if len(t.Domain) > 0 && !slices.Contains(t.Domain, fromType) {
+		violations = append(violations, &Violation{
+			Kind:    ViolationDomain,
+			Message: fmt.Sprintf("%s relations must start at one of %v, not %s", relationType, t.Domain, fromType),
+		})
+	}
+	if len(t.Range) > 0 && !slices.Contains(t.Range, toType) {
+		violations = append(violations, &Violation{
+			Kind:    ViolationRange,
+			Message: fmt.Sprintf("%s relations must end at one of %v, not %s", relationType, t.Range, toType),
+		})
+	}
+	if t.MaxPerFrom > 0 && fromCount >= t.MaxPerFrom {
+		violations = append(violations, &Violation{
+			Kind:    ViolationCardinality,
+			Message: fmt.Sprintf("an entity can start at most %d %s relations", t.MaxPerFrom, relationType),
+		})
+	}
+	if t.MaxPerTo > 0 && toCount >= t.MaxPerTo {
+		violations = append(violations, &Violation{
+			Kind:    ViolationCardinality,
+			Message: fmt.Sprintf("an entity can be the end of at most %d %s relations", t.MaxPerTo, relationType),
+		})
+	}
+
+	return violations
+}
+
+func undeclared(what, name string, declared []string) string {
+	if similar := similarName(name, declared); similar != "" {
+		return fmt.Sprintf("%s %s is not declared, did you mean %s", what, name, similar)
+	}
+
+	return fmt.Sprintf("%s %s is not declared, use one of %v", what, name, declared)
+}
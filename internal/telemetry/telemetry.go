@@ -0,0 +1,264 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/uptrace/uptrace-go/uptrace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone    = "none"
+	ExporterOTLP    = "otlp"
+	ExporterStdout  = "stdout"
+	ExporterUptrace = "uptrace"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// ServiceName identifies the spans of this application.
+const ServiceName = "mcp-dbmem"
+
+// Config says where traces are exported to. Empty values fall back to the standard OTEL_* environment variables.
+type Config struct {
+	// Exporter is none, otlp, stdout or uptrace. If it's empty OTEL_TRACES_EXPORTER is used, or uptrace if UptraceDSN
+	// is set.
+	Exporter string
+	// Protocol is grpc or http/protobuf, the protocol the otlp exporter talks.
+	Protocol string
+	// Endpoint is the collector the otlp exporter sends to, a url for http/protobuf and a url or host:port for grpc.
+	Endpoint string
+	// Headers are sent with every export, e.g. to authenticate.
+	Headers map[string]string
+	// Insecure sends to the collector without TLS.
+	Insecure bool
+	// SamplerRatio is the fraction of traces started here that are sampled. If it's nil OTEL_TRACES_SAMPLER is used.
+	SamplerRatio *float64
+	// ResourceAttributes describe the process, they're overridden by OTEL_RESOURCE_ATTRIBUTES.
+	ResourceAttributes map[string]string
+	ServiceVersion     string
+	UptraceDSN         string
+}
+
+// Setup installs the global tracer provider and returns a function sending buffered spans and freeing resources. Without
+// an exporter nothing is installed and the global no-op provider stays in place.
+func Setup(ctx context.Context, cfg Config) (func(ctx context.Context) error, error) {
+	exporter := cfg.Exporter
+	if exporter == "" {
+		exporter = defaultExporter(cfg.UptraceDSN)
+	}
+
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterUptrace:
+		return setupUptrace(cfg)
+	}
+
+	spanExporter, err := newExporter(ctx, exporter, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	}
+	if cfg.SamplerRatio != nil {
+		options = append(options, sdktrace.WithSampler(newSampler(*cfg.SamplerRatio)))
+	}
+	provider := sdktrace.NewTracerProvider(options...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// defaultExporter returns the exporter configured with OTEL_TRACES_EXPORTER, falling back to uptrace if there is a dsn.
+func defaultExporter(uptraceDSN string) string {
+	switch exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter {
+	case "":
+		if uptraceDSN != "" {
+			return ExporterUptrace
+		}
+
+		return ExporterNone
+	case "console":
+		return ExporterStdout
+	default:
+		return exporter
+	}
+}
+
+func newExporter(ctx context.Context, exporter string, cfg Config) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case ExporterStdout:
+		// stdout carries the MCP messages
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		protocol := cfg.Protocol
+		if protocol == "" {
+			protocol = firstEnv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL")
+		}
+
+		switch protocol {
+		case ProtocolHTTP, "":
+			return otlptrace.New(ctx, newHTTPClient(cfg))
+		case ProtocolGRPC:
+			client, err := newGRPCClient(cfg)
+			if err != nil {
+				return nil, err
+			}
+
+			return otlptrace.New(ctx, client)
+		default:
+			return nil, fmt.Errorf("otlp protocol %s not supported, use %s or %s", protocol, ProtocolGRPC, ProtocolHTTP)
+		}
+	default:
+		return nil, fmt.Errorf("trace exporter %s not supported, use %s, %s, %s or %s", exporter, ExporterNone, ExporterOTLP, ExporterStdout, ExporterUptrace)
+	}
+}
+
+// newHTTPClient returns an otlp client for cfg, the exporter reads the environment for anything not set.
+func newHTTPClient(cfg Config) otlptrace.Client {
+	var options []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	return otlptracehttp.NewClient(options...)
+}
+
+// newGRPCClient returns an otlp client for cfg, the exporter reads the environment for anything not set. The endpoint
+// is a url, its scheme says whether to use TLS, or a host:port.
+func newGRPCClient(cfg Config) (otlptrace.Client, error) {
+	var options []otlptracegrpc.Option
+	if strings.Contains(cfg.Endpoint, "://") {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse otlp endpoint: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("otlp endpoint scheme %s not supported, use http or https", u.Scheme)
+		}
+		options = append(options, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+	} else if cfg.Endpoint != "" {
+		options = append(options, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	return otlptracegrpc.NewClient(options...), nil
+}
+
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	}
+	for key, value := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attrs...),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win over the config
+		resource.WithFromEnv(),
+		resource.WithHost(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("can't detect resource: %w", err)
+	}
+
+	return res, nil
+}
+
+// newSampler samples ratio of the traces started here and follows the decision of the caller for the rest.
+func newSampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+func setupUptrace(cfg Config) (func(ctx context.Context) error, error) {
+	if cfg.UptraceDSN == "" {
+		return nil, errors.New("uptrace exporter needs an uptrace dsn")
+	}
+
+	options := []uptrace.Option{
+		uptrace.WithServiceName(ServiceName),
+		uptrace.WithServiceVersion(cfg.ServiceVersion),
+		uptrace.WithDSN(cfg.UptraceDSN),
+	}
+	for key, value := range cfg.ResourceAttributes {
+		options = append(options, uptrace.WithResourceAttributes(attribute.String(key, value)))
+	}
+	if cfg.SamplerRatio != nil {
+		options = append(options, uptrace.WithTraceSampler(newSampler(*cfg.SamplerRatio)))
+	}
+	uptrace.ConfigureOpentelemetry(options...)
+
+	return uptrace.Shutdown, nil
+}
+
+// ParseKeyValues parses key=value pairs like the OTEL_EXPORTER_OTLP_HEADERS and OTEL_RESOURCE_ATTRIBUTES environment
+// variables hold, values may be url encoded.
+func ParseKeyValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q isn't a key=value pair", pair)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("can't decode value of %s: %w", key, err)
+		}
+		values[key] = decoded
+	}
+
+	return values, nil
+}
+
+// firstEnv returns the first of the environment variables that is set.
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,158 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector records the spans and headers it receives.
+type collector struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	mu     sync.Mutex
+	spans  []string
+	tokens []string
+}
+
+func (c *collector) record(req *coltracepb.ExportTraceServiceRequest, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, resourceSpans := range req.GetResourceSpans() {
+		for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+			for _, span := range scopeSpans.GetSpans() {
+				c.spans = append(c.spans, span.GetName())
+				c.tokens = append(c.tokens, token)
+			}
+		}
+	}
+}
+
+func (c *collector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := ""
+	if values := md.Get("x-token"); len(values) > 0 {
+		token = values[0]
+	}
+	c.record(req, token)
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.record(req, r.Header.Get("X-Token"))
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func sendSpan(t *testing.T, cfg Config) {
+	t.Helper()
+
+	ctx := context.Background()
+	shutdown, err := Setup(ctx, cfg)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(ctx, "test span")
+	span.End()
+	require.NoError(t, shutdown(ctx))
+}
+
+func TestSetup(t *testing.T) {
+	ratio := 1.0
+
+	t.Run("grpc", func(t *testing.T) {
+		c := &collector{}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(server, c)
+		go func() { _ = server.Serve(listener) }()
+		t.Cleanup(server.Stop)
+
+		sendSpan(t, Config{
+			Exporter:     ExporterOTLP,
+			Protocol:     ProtocolGRPC,
+			Endpoint:     "http://" + listener.Addr().String(),
+			Headers:      map[string]string{"x-token": "secret"},
+			SamplerRatio: &ratio,
+		})
+		assert.Equal(t, []string{"test span"}, c.spans)
+		assert.Equal(t, []string{"secret"}, c.tokens)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		t.Cleanup(server.Close)
+
+		sendSpan(t, Config{
+			Exporter:     ExporterOTLP,
+			Protocol:     ProtocolHTTP,
+			Endpoint:     server.URL + "/v1/traces",
+			Headers:      map[string]string{"X-Token": "secret"},
+			SamplerRatio: &ratio,
+		})
+		assert.Equal(t, []string{"test span"}, c.spans)
+		assert.Equal(t, []string{"secret"}, c.tokens)
+	})
+
+	t.Run("sampled out", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		t.Cleanup(server.Close)
+
+		never := 0.0
+		sendSpan(t, Config{Exporter: ExporterOTLP, Endpoint: server.URL + "/v1/traces", SamplerRatio: &never})
+		assert.Empty(t, c.spans)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctx := context.Background()
+		for _, cfg := range []Config{
+			{Exporter: "jaeger"},
+			{Exporter: ExporterOTLP, Protocol: "http/json"},
+			{Exporter: ExporterOTLP, Protocol: ProtocolGRPC, Endpoint: "ftp://collector:4317"},
+			{Exporter: ExporterUptrace},
+		} {
+			_, err := Setup(ctx, cfg)
+			assert.Error(t, err, cfg)
+		}
+	})
+}
+
+func TestParseKeyValues(t *testing.T) {
+	t.Parallel()
+
+	values, err := ParseKeyValues([]string{"authorization=Bearer%20token", " deployment.environment = prod "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer token", "deployment.environment": "prod"}, values)
+
+	_, err = ParseKeyValues([]string{"no value"})
+	assert.Error(t, err)
+	_, err = ParseKeyValues([]string{"=value"})
+	assert.Error(t, err)
+}
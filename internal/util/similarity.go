@@ -0,0 +1,43 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TrigramSimilarity scores how alike a and b are between 0 and 1 the same way the postgres pg_trgm extension's
+// similarity function does, so scores are comparable across databases.
+func TrigramSimilarity(a, b string) float64 {
+	trigramsA := trigrams(a)
+	trigramsB := trigrams(b)
+	if len(trigramsA) == 0 || len(trigramsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range trigramsA {
+		if _, ok := trigramsB[trigram]; ok {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(trigramsA)+len(trigramsB)-shared)
+}
+
+// trigrams returns the set of trigrams in s. Like pg_trgm each word is lower cased and padded with two spaces in
+// front and one behind.
+func trigrams(s string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	set := make(map[string]struct{})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want float64
+	}{
+		{
+			name: "identical",
+			a:    "Robert",
+			b:    "Robert",
+			want: 1,
+		},
+		{
+			name: "case is ignored",
+			a:    "robert",
+			b:    "ROBERT",
+			want: 1,
+		},
+		{
+			name: "nothing shared",
+			a:    "abc",
+			b:    "xyz",
+			want: 0,
+		},
+		{
+			name: "empty",
+			a:    "",
+			b:    "Robert",
+			want: 0,
+		},
+		{
+			// matches SELECT similarity('word', 'two words') in postgres
+			name: "pg_trgm example",
+			a:    "word",
+			b:    "two words",
+			want: 4.0 / 11.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, TrigramSimilarity(tt.a, tt.b), 0.0001)
+		})
+	}
+}